@@ -0,0 +1,193 @@
+package cmddeploy
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/deploy"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/validate"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var DeployCmd = &cobra.Command{
+	Use:   "deploy <pack-or-mcp-server@version>",
+	Short: "Render a generated pack and run its job on Nomad",
+	Long: "\nRender a generated Nomad Pack with the supplied variables and register its job through the Nomad HTTP API.\n\n" +
+		"The pack argument is a pack name in the output directory, a path to a pack directory or archive, or " +
+		"<mcp-server@version> to deploy the pack generated for that MCP Server Version. When several packs were " +
+		"generated for the version, deploy one of them by name.\n\n" +
+		"Variables take their defaults from the pack's variables.hcl and are overridden by var files, in order, then by " +
+		"--var name=value flags. Var files set variables as HCL attributes, or as a JSON object when they end in .json.\n\n" +
+		"The Nomad API address, token, namespace and region are read from the NOMAD_ADDR, NOMAD_TOKEN, NOMAD_NAMESPACE " +
+		"and NOMAD_REGION environment variables, as with the nomad CLI.\n\n" +
+		"After registering a service job the command waits for its deployment to become healthy, and fails if the " +
+		"deployment fails or does not complete within the wait timeout. Use --plan to show the changes Nomad would make " +
+		"without registering the job, and --dry-run to print the rendered jobspecs without contacting Nomad.",
+	Example: `  # Deploy a generated pack
+  nomad-mcp-pack deploy io-github-datastax-astra-db-mcp-0-0-1-seed-oci-http
+
+  # Deploy the pack generated for a server version
+  nomad-mcp-pack deploy io.github.datastax/astra-db-mcp@0.0.1-seed
+
+  # Deploy with a var file and variable overrides
+  nomad-mcp-pack deploy io.github.datastax/astra-db-mcp@0.0.1-seed --var-file prod.hcl --var count=2
+
+  # Show the changes Nomad would make without registering the job
+  nomad-mcp-pack deploy io.github.datastax/astra-db-mcp@0.0.1-seed --plan
+
+  # Print the rendered jobspecs without contacting Nomad
+  nomad-mcp-pack deploy ./packs/io-github-datastax-astra-db-mcp-0-0-1-seed-oci-http --dry-run
+
+  # Register the job without waiting for its deployment
+  nomad-mcp-pack deploy io.github.datastax/astra-db-mcp@0.0.1-seed --detach`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: runValidate,
+	RunE:    runDeploy,
+}
+
+func init() {
+	DeployCmd.Flags().StringSlice("var-file", config.DefaultConfig.DeployVarFiles, "Var file setting pack variables, applied in order (can be repeated)")
+	DeployCmd.Flags().StringArray("var", []string{}, "Pack variable as name=value, applied after var files (can be repeated)")
+	DeployCmd.Flags().Bool("plan", config.DefaultConfig.DeployPlan, "Show the Nomad plan diff without registering the job")
+	DeployCmd.Flags().Bool("detach", config.DefaultConfig.DeployDetach, "Return once the job is registered without waiting for its deployment")
+	DeployCmd.Flags().Int("wait-timeout", config.DefaultConfig.DeployWaitTimeout, "Seconds to wait for the deployment to become healthy")
+
+	viper.BindPFlag("deploy.var_files", DeployCmd.Flags().Lookup("var-file"))
+	viper.BindPFlag("deploy.plan", DeployCmd.Flags().Lookup("plan"))
+	viper.BindPFlag("deploy.detach", DeployCmd.Flags().Lookup("detach"))
+	viper.BindPFlag("deploy.wait_timeout", DeployCmd.Flags().Lookup("wait-timeout"))
+
+	DeployCmd.Flags().SortFlags = false
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	slog.Info("starting deploy command input validation")
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	slog.Debug("validating deploy command inputs with configuration",
+		slog.Group("common_config",
+			"log_level", cfg.LogLevel,
+			"env", cfg.Env,
+			"output_dir", cfg.OutputDir,
+			"dry_run", cfg.DryRun,
+		),
+		slog.Group("deploy_config",
+			"var_files", cfg.Deploy.VarFiles,
+			"plan", cfg.Deploy.Plan,
+			"detach", cfg.Deploy.Detach,
+			"wait_timeout", cfg.Deploy.WaitTimeout,
+		),
+	)
+
+	if err := validate.VarFiles(cfg.Deploy.VarFiles); err != nil {
+		return fmt.Errorf("could not validate var files; %w", err)
+	}
+
+	vars, _ := cmd.Flags().GetStringArray("var")
+	for _, v := range vars {
+		if name, _, ok := strings.Cut(v, "="); !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("could not validate variable %q; expected name=value", v)
+		}
+	}
+
+	if cfg.Deploy.WaitTimeout < 1 {
+		return fmt.Errorf("could not validate wait timeout; timeout must be at least 1 second")
+	}
+
+	if cfg.Deploy.Plan && cfg.DryRun {
+		return fmt.Errorf("could not validate deploy mode; --plan cannot be combined with --dry-run")
+	}
+
+	slog.Info("deploy command input validation completed successfully")
+
+	// Any errors after this point are runtime errors, not usage-related errors
+	cmd.SilenceUsage = true
+
+	return nil
+}
+
+func runDeploy(cmd *cobra.Command, args []string) (err error) {
+	slog.Info("starting deploy command run")
+
+	// Nomad requests share the command's correlation ID and trace
+	ctx := utils.WithRequestID(cmd.Context(), tracing.NewRequestID())
+	ctx, span := tracing.Start(ctx, "deploy", attribute.String("pack", args[0]))
+	defer func() { tracing.End(span, err) }()
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	vars, _ := cmd.Flags().GetStringArray("var")
+
+	rendered, name, err := deploy.RenderPack(cfg.OutputDir, args[0], cfg.Deploy.VarFiles, vars)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "rendered pack", "pack", name, "jobs", len(rendered.Jobs), "var_files", cfg.Deploy.VarFiles, "vars", len(vars))
+
+	if cfg.DryRun {
+		output.Info("Dry run - rendered %d job(s) from pack %s:", len(rendered.Jobs), name)
+		for _, job := range rendered.Jobs {
+			output.Println("\n--- %s ---", job.Name)
+			output.Print("%s", job.Content)
+		}
+		return nil
+	}
+
+	client, err := nomad.NewClient(nomad.DefaultConfig())
+	if err != nil {
+		return fmt.Errorf("could not create nomad client; %w", err)
+	}
+
+	jobs, err := deploy.ParseJobs(ctx, client, rendered)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Deploy.Plan {
+		for _, job := range jobs {
+			plan, err := client.PlanJob(ctx, job)
+			if err != nil {
+				return err
+			}
+			output.Print("%s", deploy.FormatPlan(plan))
+		}
+		return nil
+	}
+
+	if err := deploy.Register(ctx, client, jobs); err != nil {
+		return err
+	}
+
+	if !cfg.Deploy.Detach {
+		for _, job := range jobs {
+			if err := deploy.Await(ctx, client, job, time.Duration(cfg.Deploy.WaitTimeout)*time.Second); err != nil {
+				return err
+			}
+		}
+	}
+
+	if outputs := strings.TrimSpace(rendered.Outputs); outputs != "" {
+		output.Println("\n%s", outputs)
+	}
+
+	slog.Info("deploy command run completed successfully")
+
+	return nil
+}
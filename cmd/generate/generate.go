@@ -3,18 +3,24 @@ package cmdgenerate
 import (
 	"fmt"
 	"log/slog"
-	"net/url"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/leefowlercu/go-mcp-registry/mcp"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/gitrepo"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/hooks"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/server"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/validate"
 	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
 	"github.com/modelcontextprotocol/registry/pkg/model"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var GenerateCmd = &cobra.Command{
@@ -28,7 +34,9 @@ var GenerateCmd = &cobra.Command{
 		"can either be a semver-formatted string or the keyword 'latest'. Using the keyword 'latest' will attempt to find the latest non-deprecated, " +
 		"non-deleted version. If a non-deprecated, non-deleted version can be found for the given MCP Server Version " +
 		"the Nomad Pack will be generated. If there is no matching MCP Server Version found, or if there is a match but the matching " +
-		"MCP Server Version has been declared deprecated or deleted the command will error and indicate the reason.",
+		"MCP Server Version has been declared deprecated or deleted the command will error and indicate the reason.\n\n" +
+		"When several registries are configured they are searched in order, and the pack is generated from the first " +
+		"registry that has the MCP Server Version. Use --registry to generate from a specific registry.",
 	Example: `  # Generate a pack for a specific version of an MCP server
   nomad-mcp-pack generate io.github.datastax/astra-db-mcp@0.0.1-seed
   
@@ -48,7 +56,16 @@ var GenerateCmd = &cobra.Command{
   nomad-mcp-pack generate io.github.datastax/astra-db-mcp@latest --package-type npm
   
   # Specify transport type (default 'http')
-  nomad-mcp-pack generate io.github.datastax/astra-db-mcp@latest --transport-type sse`,
+  nomad-mcp-pack generate io.github.datastax/astra-db-mcp@latest --transport-type sse
+
+  # Generate from a specific configured registry
+  nomad-mcp-pack generate io.github.example/internal-mcp@latest --registry internal
+
+  # Render a standalone Nomad jobspec instead of a pack
+  nomad-mcp-pack generate io.github.datastax/astra-db-mcp@latest --output-type jobspec --var count=2
+
+  # Render the job as Nomad API JSON for nomad job run -json
+  nomad-mcp-pack generate io.github.datastax/astra-db-mcp@latest --output-type jobspec-json --var-file prod.hcl`,
 	Args:    cobra.ExactArgs(1),
 	PreRunE: runValidate,
 	RunE:    runGenerate,
@@ -57,9 +74,13 @@ var GenerateCmd = &cobra.Command{
 func init() {
 	GenerateCmd.Flags().String("package-type", config.DefaultConfig.GeneratePackageType, "Package type {npm|pypi|oci|nuget}")
 	GenerateCmd.Flags().String("transport-type", config.DefaultConfig.GenerateTransportType, "Transport type {stdio|http|sse}")
+	GenerateCmd.Flags().String("registry", "", "Name of the configured registry to generate from (default: search registries in order)")
+	GenerateCmd.Flags().StringSlice("var-file", config.DefaultConfig.GenerateVarFiles, "Var file setting pack variables for jobspec output types, applied in order (can be repeated)")
+	GenerateCmd.Flags().StringArray("var", []string{}, "Pack variable as name=value for jobspec output types, applied after var files (can be repeated)")
 
 	viper.BindPFlag("generate.package_type", GenerateCmd.Flags().Lookup("package-type"))
 	viper.BindPFlag("generate.transport_type", GenerateCmd.Flags().Lookup("transport-type"))
+	viper.BindPFlag("generate.var_files", GenerateCmd.Flags().Lookup("var-file"))
 
 	GenerateCmd.Flags().SortFlags = false
 }
@@ -77,6 +98,8 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	slog.Debug("validating generate command inputs with configuration",
 		slog.Group("common_config",
 			"registry_url", cfg.RegistryURL,
+			"registries", len(cfg.Registries),
+			"overrides", len(cfg.Overrides),
 			"log_level", cfg.LogLevel,
 			"env", cfg.Env,
 			"output_dir", cfg.OutputDir,
@@ -84,10 +107,12 @@ func runValidate(cmd *cobra.Command, args []string) error {
 			"allow_deprecated", cfg.AllowDeprecated,
 			"dry_run", cfg.DryRun,
 			"force_overwrite", cfg.ForceOverwrite,
+			"output_lock_timeout", cfg.OutputLockTimeout,
 		),
 		slog.Group("generate_config",
 			"package_type", cfg.Generate.PackageType,
 			"transport_type", cfg.Generate.TransportType,
+			"var_files", cfg.Generate.VarFiles,
 		),
 	)
 
@@ -106,6 +131,41 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not validate transport type; %w", err)
 	}
 
+	if err := validate.Hooks(cfg.Hooks); err != nil {
+		return fmt.Errorf("could not validate hooks; %w", err)
+	}
+
+	if err := validate.Registries(cfg.Registries); err != nil {
+		return fmt.Errorf("could not validate registries; %w", err)
+	}
+
+	if err := validate.Overrides(cfg.Overrides); err != nil {
+		return fmt.Errorf("could not validate overrides; %w", err)
+	}
+
+	vars, _ := cmd.Flags().GetStringArray("var")
+	jobspec := cfg.OutputType == config.OutputTypeJobspec || cfg.OutputType == config.OutputTypeJobspecJSON
+	if !jobspec && (len(cfg.Generate.VarFiles) > 0 || len(vars) > 0) {
+		return fmt.Errorf("could not validate variables; --var-file and --var require a jobspec output type")
+	}
+
+	if err := validate.VarFiles(cfg.Generate.VarFiles); err != nil {
+		return fmt.Errorf("could not validate var files; %w", err)
+	}
+
+	for _, v := range vars {
+		if name, _, ok := strings.Cut(v, "="); !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("could not validate variable %q; expected name=value", v)
+		}
+	}
+
+	registryName, _ := cmd.Flags().GetString("registry")
+	if registryName != "" && !slices.ContainsFunc(cfg.RegistrySources(), func(r config.RegistrySourceConfig) bool {
+		return r.Name == registryName
+	}) {
+		return fmt.Errorf("could not validate registry; no registry named %q is configured", registryName)
+	}
+
 	slog.Info("generate command input validation completed successfully")
 
 	// Any errors after this point are runtime errors, not usage-related errors
@@ -114,10 +174,14 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runGenerate(cmd *cobra.Command, args []string) error {
+func runGenerate(cmd *cobra.Command, args []string) (err error) {
 	slog.Info("starting generate command run")
 
-	ctx := cmd.Context()
+	// Registry lookups, generation and hooks share the command's correlation ID and trace
+	ctx := utils.WithRequestID(cmd.Context(), tracing.NewRequestID())
+	ctx, span := tracing.Start(ctx, "generate", attribute.String("server", args[0]))
+	defer func() { tracing.End(span, err) }()
+
 	serverSearchSpecArg := args[0]
 
 	cfg, err := config.GetConfig()
@@ -128,6 +192,8 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	slog.Debug("running generate command with configuration",
 		slog.Group("common_config",
 			"registry_url", cfg.RegistryURL,
+			"registries", len(cfg.Registries),
+			"overrides", len(cfg.Overrides),
 			"log_level", cfg.LogLevel,
 			"env", cfg.Env,
 			"output_dir", cfg.OutputDir,
@@ -135,36 +201,32 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 			"allow_deprecated", cfg.AllowDeprecated,
 			"dry_run", cfg.DryRun,
 			"force_overwrite", cfg.ForceOverwrite,
+			"output_lock_timeout", cfg.OutputLockTimeout,
 		),
 		slog.Group("generate_config",
 			"package_type", cfg.Generate.PackageType,
 			"transport_type", cfg.Generate.TransportType,
+			"var_files", cfg.Generate.VarFiles,
 		),
 	)
 
 	packageType := cfg.Generate.PackageType
 	transportType := cfg.Generate.TransportType
 
-	registryURL := cfg.RegistryURL
+	registryName, _ := cmd.Flags().GetString("registry")
+	vars, _ := cmd.Flags().GetStringArray("var")
 	outputDir := cfg.OutputDir
 	outputType := cfg.OutputType
 	allowDeprecated := cfg.AllowDeprecated
 	dryRun := cfg.DryRun
 	forceOverwrite := cfg.ForceOverwrite
 
-	client := mcp.NewClient(nil)
-	registryURLParsed, err := url.Parse(registryURL)
-	if err != nil {
-		return fmt.Errorf("could not parse registry URL; %w", err)
-	}
-	client.BaseURL = registryURLParsed
-
 	serverSearchSpec, err := server.ParseSearchSpec(serverSearchSpecArg)
 	if err != nil {
 		return fmt.Errorf("could not parse server argument; %w", err)
 	}
 
-	serverSpec, err := server.Find(ctx, serverSearchSpec, client)
+	source, serverSpec, err := server.FindInRegistries(ctx, cfg.RegistrySources(), cfg.Registry, registryName, serverSearchSpec)
 	if err != nil {
 		return fmt.Errorf("could not retrieve server %q from registry; %w", serverSearchSpec, err)
 	}
@@ -177,31 +239,113 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	}
 	if serverSpec.IsDeprecated() && allowDeprecated {
 		output.Warning("Server %s@%s is deprecated", serverSpec.Name(), serverSpec.Version())
-		slog.Warn("generating pack for deprecated server", "server", serverSpec.Name(), "version", serverSpec.Version())
+		slog.WarnContext(ctx, "generating pack for deprecated server", "server", serverSpec.Name(), "version", serverSpec.Version())
 	}
 
 	var srv *v0.ServerJSON
 	var pkg *model.Package
 
 	srv = serverSpec.JSON
-	pkg, err = server.FindPackageWithTransport(srv, packageType, transportType)
+
+	// Overridden package and transport preferences apply unless the types are given on the command line
+	overrides := cfg.OverridesFor(srv.Name)
+	packageTypes := []string{packageType}
+	if len(overrides.PackageTypes) > 0 && !cmd.Flags().Changed("package-type") {
+		packageTypes = overrides.PackageTypes
+	}
+	transportTypes := []string{transportType}
+	if len(overrides.TransportTypes) > 0 && !cmd.Flags().Changed("transport-type") {
+		transportTypes = overrides.TransportTypes
+	}
+	if len(overrides.Servers) > 0 {
+		slog.InfoContext(ctx, "applying generation overrides", "server", srv.Name, "patterns", overrides.Servers)
+	}
+
+	pkg, err = server.FindPreferredPackage(srv, packageTypes, transportTypes)
 	if err != nil {
 		return fmt.Errorf("unable to generate pack for server %q, version %s: %w", serverSpec.Name(), serverSpec.Version(), err)
 	}
+	transportType = utils.MapFromRegistryTransportType(pkg.Transport.Type)
 
 	opts := generator.Options{
 		OutputDir:      outputDir,
 		OutputType:     string(outputType),
 		DryRun:         dryRun,
 		ForceOverwrite: forceOverwrite,
+		RegistryName:   source.Name,
+		RegistryURL:    source.URL,
+		Overrides:      overrides,
+		VarFiles:       cfg.Generate.VarFiles,
+		Vars:           vars,
+	}
+
+	// Hooks are skipped in dry run mode as no pack is written
+	runner := hooks.NewRunner(cfg.Hooks)
+	payload := hooks.Payload{
+		PackPath: generator.PackPath(srv, pkg, opts),
+		Server: &hooks.ServerInfo{
+			Registry:          source.Name,
+			Name:              srv.Name,
+			Version:           srv.Version,
+			Description:       srv.Description,
+			PackageType:       pkg.RegistryType,
+			TransportType:     transportType,
+			PackageIdentifier: pkg.Identifier,
+			PackageVersion:    pkg.Version,
+		},
+	}
+
+	// A watch process or another generate writing into the output directory is waited for
+	if !dryRun {
+		lock, lockErr := generator.LockOutputDir(ctx, outputDir, time.Duration(cfg.OutputLockTimeout)*time.Second)
+		if lockErr != nil {
+			return lockErr
+		}
+		defer lock.Release()
+
+		err = runner.Run(ctx, hooks.EventPreGenerate, payload)
+	}
+
+	if err == nil {
+		err = generator.Run(ctx, srv, pkg, opts)
+	}
+
+	if err == nil && !dryRun {
+		err = runner.Run(ctx, hooks.EventPostGenerate, payload)
 	}
 
-	err = generator.Run(ctx, srv, pkg, opts)
 	if err != nil {
+		if !dryRun {
+			payload.Error = err.Error()
+			if hookErr := runner.Run(ctx, hooks.EventOnFailure, payload); hookErr != nil {
+				slog.WarnContext(ctx, "on_failure hook failed", "error", hookErr)
+			}
+		}
 		output.Failure("Pack generation failed: %v", err)
 		return fmt.Errorf("failed to generate pack; %w", err)
 	}
 
+	if cfg.Git.Enabled && !dryRun {
+		repo, err := gitrepo.Open(ctx, outputDir, cfg.Git)
+		if err != nil {
+			return fmt.Errorf("failed to open git repository for output directory; %w", err)
+		}
+
+		entry := gitrepo.Entry{
+			ServerName:    srv.Name,
+			ServerVersion: srv.Version,
+			PackageType:   pkg.RegistryType,
+			TransportType: transportType,
+			PackName:      generator.PackName(srv, pkg),
+			PackPath:      payload.PackPath,
+		}
+		if err := repo.Commit(ctx, []gitrepo.Entry{entry}); err != nil {
+			return fmt.Errorf("failed to commit pack; %w", err)
+		}
+
+		output.Success("Pack committed to git: %s", entry.PackName)
+	}
+
 	slog.Info("generate command run completed successfully")
 
 	return nil
@@ -1,14 +1,25 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
+	cmddeploy "github.com/leefowlercu/nomad-mcp-pack/cmd/deploy"
 	cmdgenerate "github.com/leefowlercu/nomad-mcp-pack/cmd/generate"
+	cmdquarantine "github.com/leefowlercu/nomad-mcp-pack/cmd/quarantine"
+	cmdsecrets "github.com/leefowlercu/nomad-mcp-pack/cmd/secrets"
 	cmdserver "github.com/leefowlercu/nomad-mcp-pack/cmd/server"
+	cmdstate "github.com/leefowlercu/nomad-mcp-pack/cmd/state"
+	cmdstatus "github.com/leefowlercu/nomad-mcp-pack/cmd/status"
 	cmdwatch "github.com/leefowlercu/nomad-mcp-pack/cmd/watch"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/exitcode"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/validate"
 	"github.com/spf13/cobra"
@@ -19,6 +30,12 @@ var (
 	version string
 )
 
+// tracingShutdownTimeout bounds how long exiting waits for buffered spans to be exported
+const tracingShutdownTimeout = 5 * time.Second
+
+// shutdownTracing flushes the spans of the command run, once tracing has been set up
+var shutdownTracing func(context.Context) error
+
 var nomadMcpPackCmd = &cobra.Command{
 	Use:   "nomad-mcp-pack",
 	Short: "A generator for HashiCorp Nomad MCP Server Packs",
@@ -31,23 +48,41 @@ var nomadMcpPackCmd = &cobra.Command{
 func init() {
 	nomadMcpPackCmd.PersistentFlags().String("registry-url", config.DefaultConfig.RegistryURL, "Registry URL")
 	nomadMcpPackCmd.PersistentFlags().String("output-dir", config.DefaultConfig.OutputDir, "Output directory for generated packs")
-	nomadMcpPackCmd.PersistentFlags().String("output-type", config.DefaultConfig.OutputType, "Output type {packdir|archive}")
+	nomadMcpPackCmd.PersistentFlags().String("output-type", config.DefaultConfig.OutputType, "Output type {packdir|archive|jobspec|jobspec-json}")
 	nomadMcpPackCmd.PersistentFlags().Bool("dry-run", config.DefaultConfig.DryRun, "Show what would be generated without writing files")
 	nomadMcpPackCmd.PersistentFlags().Bool("force-overwrite", config.DefaultConfig.ForceOverwrite, "Overwrite existing pack or archive if it exists")
+	nomadMcpPackCmd.PersistentFlags().Int("output-lock-timeout", config.DefaultConfig.OutputLockTimeout, "Seconds to wait for another process generating packs into the output directory (0 to fail immediately)")
 	nomadMcpPackCmd.PersistentFlags().Bool("allow-deprecated", config.DefaultConfig.AllowDeprecated, "Allow generation of packs for deprecated servers")
 	nomadMcpPackCmd.PersistentFlags().BoolP("silent", "s", config.DefaultConfig.Silent, "Suppress user-facing output (errors still shown)")
+	nomadMcpPackCmd.PersistentFlags().Bool("git-commit", config.DefaultConfig.GitEnabled, "Commit generated packs to the git repository rooted at the output directory")
+	nomadMcpPackCmd.PersistentFlags().Bool("git-tag", config.DefaultConfig.GitTag, "Tag each committed pack version (requires --git-commit)")
+	nomadMcpPackCmd.PersistentFlags().Bool("git-push", config.DefaultConfig.GitPush, "Push commits and tags to the configured git remote (requires --git-commit)")
 
 	viper.BindPFlag("registry_url", nomadMcpPackCmd.PersistentFlags().Lookup("registry-url"))
 	viper.BindPFlag("output_dir", nomadMcpPackCmd.PersistentFlags().Lookup("output-dir"))
 	viper.BindPFlag("output_type", nomadMcpPackCmd.PersistentFlags().Lookup("output-type"))
 	viper.BindPFlag("dry_run", nomadMcpPackCmd.PersistentFlags().Lookup("dry-run"))
 	viper.BindPFlag("force_overwrite", nomadMcpPackCmd.PersistentFlags().Lookup("force-overwrite"))
+	viper.BindPFlag("output_lock_timeout", nomadMcpPackCmd.PersistentFlags().Lookup("output-lock-timeout"))
 	viper.BindPFlag("allow_deprecated", nomadMcpPackCmd.PersistentFlags().Lookup("allow-deprecated"))
 	viper.BindPFlag("silent", nomadMcpPackCmd.PersistentFlags().Lookup("silent"))
+	viper.BindPFlag("git.enabled", nomadMcpPackCmd.PersistentFlags().Lookup("git-commit"))
+	viper.BindPFlag("git.tag", nomadMcpPackCmd.PersistentFlags().Lookup("git-tag"))
+	viper.BindPFlag("git.push", nomadMcpPackCmd.PersistentFlags().Lookup("git-push"))
+
+	// Generated jobs record the version of nomad-mcp-pack that generated them
+	if version != "" {
+		generator.Version = version
+	}
 
 	nomadMcpPackCmd.AddCommand(cmdgenerate.GenerateCmd)
 	nomadMcpPackCmd.AddCommand(cmdserver.ServerCmd)
 	nomadMcpPackCmd.AddCommand(cmdwatch.WatchCmd)
+	nomadMcpPackCmd.AddCommand(cmdquarantine.QuarantineCmd)
+	nomadMcpPackCmd.AddCommand(cmdstate.StateCmd)
+	nomadMcpPackCmd.AddCommand(cmddeploy.DeployCmd)
+	nomadMcpPackCmd.AddCommand(cmdstatus.StatusCmd)
+	nomadMcpPackCmd.AddCommand(cmdsecrets.SecretsCmd)
 }
 
 func Execute() error {
@@ -59,7 +94,21 @@ func Execute() error {
 
 	err := nomadMcpPackCmd.Execute()
 
+	if shutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Warn("failed to flush traces", "error", err)
+		}
+		cancel()
+	}
+
 	if err != nil {
+		// Commands requesting a specific exit code have already reported their outcome
+		var exitErr *exitcode.Error
+		if errors.As(err, &exitErr) {
+			return err
+		}
+
 		cmd, _, _ := nomadMcpPackCmd.Find(os.Args[1:])
 
 		// Command will either be a leaf command or nil
@@ -105,7 +154,31 @@ func runInitAndValidate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not validate output type; %w", err)
 	}
 
+	if err := validate.LockTimeout(cfg.OutputLockTimeout); err != nil {
+		return fmt.Errorf("could not validate output lock timeout; %w", err)
+	}
+
+	if err := validate.RegistryClient(cfg.Registry); err != nil {
+		return fmt.Errorf("could not validate registry client configuration; %w", err)
+	}
+
+	if cfg.Git.Enabled {
+		if err := validate.GitCommitMode(cfg.Git.CommitMode); err != nil {
+			return fmt.Errorf("could not validate git commit mode; %w", err)
+		}
+	}
+
+	if err := validate.Tracing(cfg.Tracing); err != nil {
+		return fmt.Errorf("could not validate tracing configuration; %w", err)
+	}
+
 	slog.Info("root command input validation completed successfully")
 
+	shutdown, err := tracing.Setup(cmd.Context(), cfg.Tracing, version)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing; %w", err)
+	}
+	shutdownTracing = shutdown
+
 	return nil
 }
@@ -0,0 +1,166 @@
+package cmdquarantine
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/validate"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/watcher"
+	"github.com/spf13/cobra"
+)
+
+var QuarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "List and clear packs quarantined by the watch command",
+	Long: "\nList and clear packs quarantined by the watch command.\n\n" +
+		"The watch command retries failed pack generations with exponential backoff and quarantines a pack after " +
+		"the configured number of consecutive failures (watch.quarantine_after). Quarantined packs are not retried " +
+		"until their quarantine is cleared. Stop the watch command before clearing, as a running watcher overwrites the state file.",
+	Example: `  # List quarantined packs
+  nomad-mcp-pack quarantine list
+
+  # Clear a single quarantined pack so it is retried on the next poll
+  nomad-mcp-pack quarantine clear "default:io.github.example/weather-mcp@1.0.0:npm:stdio"
+
+  # Clear all quarantined packs in a specific state file
+  nomad-mcp-pack quarantine clear --all --state-file ./my-watch-state.json`,
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List quarantined packs",
+	Args:    cobra.NoArgs,
+	PreRunE: runValidate,
+	RunE:    runList,
+}
+
+var clearCmd = &cobra.Command{
+	Use:   "clear [key...]",
+	Short: "Clear quarantined packs so they are retried on the next poll",
+	Long: "\nClear quarantined packs so they are retried on the next poll.\n\n" +
+		"Keys have the form <namespace>/<name>@<version>:<package-type>:<transport-type> as shown by 'quarantine list'.",
+	PreRunE: runValidateClear,
+	RunE:    runClear,
+}
+
+func init() {
+	QuarantineCmd.PersistentFlags().String("state-file", "", "Path or URL of the state store (default: watch.state_file)")
+	clearCmd.Flags().Bool("all", false, "Clear every quarantined pack")
+
+	QuarantineCmd.AddCommand(listCmd)
+	QuarantineCmd.AddCommand(clearCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	slog.Info("starting quarantine command input validation")
+
+	if err := validate.StateFile(stateFile(cmd)); err != nil {
+		return fmt.Errorf("could not validate state file; %w", err)
+	}
+
+	slog.Info("quarantine command input validation completed successfully")
+
+	// Any errors after this point are runtime errors, not usage-related errors
+	cmd.SilenceUsage = true
+
+	return nil
+}
+
+func runValidateClear(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	if all && len(args) > 0 {
+		return fmt.Errorf("specify either keys or --all, not both")
+	}
+	if !all && len(args) == 0 {
+		return fmt.Errorf("specify the keys of the packs to clear or --all")
+	}
+
+	return runValidate(cmd, args)
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	path := stateFile(cmd)
+
+	state, err := readState(cmd, path)
+	if err != nil {
+		return err
+	}
+
+	quarantined := state.Quarantined()
+	if len(quarantined) == 0 {
+		output.Info("No quarantined packs in %s", statestore.Redact(path))
+		return nil
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tATTEMPTS\tLAST ATTEMPT\tLAST ERROR")
+	for _, s := range quarantined {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", s.Key(), s.Attempts, s.UpdatedAt.Format(time.RFC3339), s.LastError)
+	}
+	tw.Flush()
+
+	output.Print("%s", buf.String())
+	output.Info("\n%d quarantined packs", len(quarantined))
+
+	return nil
+}
+
+func runClear(cmd *cobra.Command, args []string) error {
+	path := stateFile(cmd)
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	var cleared []string
+	err = watcher.EditState(cmd.Context(), path, cfg.LegacyRegistry(), time.Duration(cfg.Watch.LockTimeout)*time.Second, func(state *watcher.WatchState) bool {
+		cleared = state.ClearQuarantine(args)
+		for _, key := range args {
+			if !slices.Contains(cleared, key) {
+				output.Warning("%s is not quarantined", key)
+			}
+		}
+
+		return len(cleared) > 0
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(cleared) == 0 {
+		output.Info("No quarantined packs cleared")
+		return nil
+	}
+
+	for _, key := range cleared {
+		output.Success("Cleared %s", key)
+	}
+	slog.Info("cleared quarantined packs", "count", len(cleared), "state_file", statestore.Redact(path))
+
+	return nil
+}
+
+// stateFile returns the --state-file flag if set, otherwise the watch command's configured state file
+func stateFile(cmd *cobra.Command) string {
+	return config.WatchStateFile(cmd.Flag("state-file").Value.String())
+}
+
+// readState reads the state in the store at path, migrating state saved by earlier releases
+// with the configured registries
+func readState(cmd *cobra.Command, path string) (*watcher.WatchState, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	return watcher.ReadState(cmd.Context(), path, cfg.LegacyRegistry())
+}
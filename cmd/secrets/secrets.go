@@ -0,0 +1,339 @@
+package cmdsecrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/deploy"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/secrets"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/server"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/validate"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var SecretsCmd = &cobra.Command{
+	Use:   "secrets <pack-or-mcp-server@version>",
+	Short: "Write the secrets of a generated pack to Nomad Variables",
+	Long: "\nWrite the secret environment variables of a generated pack's MCP Server to the Nomad Variable its job reads them from.\n\n" +
+		"Generated jobs read the environment variables the registry marks as secret from the Nomad Variable at " +
+		"nomad/jobs/<job>, which the job's tasks can read without an ACL policy, rather than from pack variables. " +
+		"The pack argument is the same as for deploy. The pack is rendered to find its job name, so pass the var files " +
+		"and --var flags it is deployed with, and the secrets are read from the MCP Server version the pack was " +
+		"generated from, looked up in the registry recorded in the job meta.\n\n" +
+		"Secret values are read from env files of NAME=value lines, applied in order, then from environment variables " +
+		"of the same name with --from-env. Values already in the variable are kept unless a new value is supplied. " +
+		"When stdin is a terminal, secrets that still have no value are prompted for without echoing them; use " +
+		"--prompt=false to turn prompting off. Secrets that are left unset take the package's default, if any.\n\n" +
+		"The command lists each secret and whether it was set. When required secrets are still missing it fails without " +
+		"writing the variable, as jobs with required secrets wait for the variable to exist before starting their tasks. " +
+		"The Nomad API is configured with the NOMAD_ADDR, NOMAD_TOKEN, NOMAD_NAMESPACE and NOMAD_REGION environment " +
+		"variables; the variable is written to the namespace the job is deployed to.",
+	Example: `  # Prompt for the secrets of the pack generated for a server version
+  nomad-mcp-pack secrets io.github.datastax/astra-db-mcp@0.0.1-seed
+
+  # Read the secrets from an env file, for a job deployed with a var file
+  nomad-mcp-pack secrets io.github.datastax/astra-db-mcp@0.0.1-seed --env-file astra.env --var-file prod.hcl
+
+  # Read the secrets from the environment without prompting, as in CI
+  nomad-mcp-pack secrets io-github-datastax-astra-db-mcp-0-0-1-seed-oci-http --from-env --prompt=false
+
+  # Report which secrets are missing without writing them
+  nomad-mcp-pack secrets io.github.datastax/astra-db-mcp@0.0.1-seed --prompt=false --dry-run`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: runValidate,
+	RunE:    runSecrets,
+}
+
+func init() {
+	SecretsCmd.Flags().StringSlice("env-file", config.DefaultConfig.SecretsEnvFiles, "Env file of NAME=value lines setting secrets, applied in order (can be repeated)")
+	SecretsCmd.Flags().Bool("from-env", config.DefaultConfig.SecretsFromEnv, "Read secrets from environment variables of the same name")
+	SecretsCmd.Flags().Bool("prompt", config.DefaultConfig.SecretsPrompt, "Prompt for secrets without a value when stdin is a terminal")
+	SecretsCmd.Flags().StringSlice("var-file", []string{}, "Var file setting pack variables to render the job with, applied in order (can be repeated)")
+	SecretsCmd.Flags().StringArray("var", []string{}, "Pack variable as name=value to render the job with, applied after var files (can be repeated)")
+
+	viper.BindPFlag("secrets.env_files", SecretsCmd.Flags().Lookup("env-file"))
+	viper.BindPFlag("secrets.from_env", SecretsCmd.Flags().Lookup("from-env"))
+	viper.BindPFlag("secrets.prompt", SecretsCmd.Flags().Lookup("prompt"))
+
+	SecretsCmd.Flags().SortFlags = false
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	slog.Info("starting secrets command input validation")
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	varFiles, _ := cmd.Flags().GetStringSlice("var-file")
+	vars, _ := cmd.Flags().GetStringArray("var")
+
+	slog.Debug("validating secrets command inputs with configuration",
+		slog.Group("common_config",
+			"registries", len(cfg.Registries),
+			"log_level", cfg.LogLevel,
+			"env", cfg.Env,
+			"output_dir", cfg.OutputDir,
+			"dry_run", cfg.DryRun,
+		),
+		slog.Group("secrets_config",
+			"env_files", cfg.Secrets.EnvFiles,
+			"from_env", cfg.Secrets.FromEnv,
+			"prompt", cfg.Secrets.Prompt,
+			"var_files", varFiles,
+			"vars", len(vars),
+		),
+	)
+
+	if err := validate.Registries(cfg.Registries); err != nil {
+		return fmt.Errorf("could not validate registries; %w", err)
+	}
+
+	if err := validate.VarFiles(cfg.Secrets.EnvFiles); err != nil {
+		return fmt.Errorf("could not validate env files; %w", err)
+	}
+
+	if err := validate.VarFiles(varFiles); err != nil {
+		return fmt.Errorf("could not validate var files; %w", err)
+	}
+
+	for _, v := range vars {
+		if name, _, ok := strings.Cut(v, "="); !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("could not validate variable %q; expected name=value", v)
+		}
+	}
+
+	if nomad.DefaultConfig().Namespace == "*" {
+		return fmt.Errorf("could not validate namespace; secrets are written to a single namespace, not %q", "*")
+	}
+
+	slog.Info("secrets command input validation completed successfully")
+
+	// Any errors after this point are runtime errors, not usage-related errors
+	cmd.SilenceUsage = true
+
+	return nil
+}
+
+func runSecrets(cmd *cobra.Command, args []string) (err error) {
+	slog.Info("starting secrets command run")
+
+	// Nomad and registry requests share the command's correlation ID and trace
+	ctx := utils.WithRequestID(cmd.Context(), tracing.NewRequestID())
+	ctx, span := tracing.Start(ctx, "secrets", attribute.String("pack", args[0]))
+	defer func() { tracing.End(span, err) }()
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	varFiles, _ := cmd.Flags().GetStringSlice("var-file")
+	vars, _ := cmd.Flags().GetStringArray("var")
+
+	rendered, name, err := deploy.RenderPack(cfg.OutputDir, args[0], varFiles, vars)
+	if err != nil {
+		return err
+	}
+
+	supplied, err := suppliedValues(cfg.Secrets)
+	if err != nil {
+		return err
+	}
+
+	var prompt secrets.Prompter
+	if cfg.Secrets.Prompt && !cfg.DryRun && term.IsTerminal(os.Stdin.Fd()) {
+		prompt = promptSecret
+	}
+
+	client, err := nomad.NewClient(nomad.DefaultConfig())
+	if err != nil {
+		return fmt.Errorf("could not create nomad client; %w", err)
+	}
+
+	var missing []string
+	for _, job := range rendered.Jobs {
+		jobID, meta, err := job.Identity()
+		if err != nil {
+			return err
+		}
+
+		secretList, err := packSecrets(ctx, cfg, meta)
+		if err != nil {
+			return fmt.Errorf("could not find the secrets of pack %s; %w", name, err)
+		}
+		if len(secretList) == 0 {
+			output.Info("Job %s has no secrets", jobID)
+			continue
+		}
+
+		jobMissing, err := writeSecrets(ctx, client, jobID, secretList, supplied, prompt, cfg.DryRun)
+		if err != nil {
+			return err
+		}
+		missing = append(missing, jobMissing...)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%d required secrets are missing: %s", len(missing), strings.Join(missing, ", "))
+	}
+
+	slog.Info("secrets command run completed successfully")
+
+	return nil
+}
+
+// packSecrets looks up the MCP server version recorded in a job's meta and returns the
+// secrets of the package the pack was generated for
+func packSecrets(ctx context.Context, cfg *config.Config, meta map[string]string) ([]secrets.Secret, error) {
+	serverName, version := meta[generator.JobMetaServer], meta[generator.JobMetaServerVersion]
+	if serverName == "" || version == "" {
+		return nil, errors.New("the job meta does not record the MCP server version; regenerate the pack")
+	}
+
+	searchSpec, err := server.ParseSearchSpec(serverName + "@" + version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fall back to every registry when the one the pack was generated from is no longer configured
+	sources := cfg.RegistrySources()
+	registryName := meta[generator.JobMetaRegistry]
+	if !slices.ContainsFunc(sources, func(r config.RegistrySourceConfig) bool { return r.Name == registryName }) {
+		registryName = ""
+	}
+
+	_, spec, err := server.FindInRegistries(ctx, sources, cfg.Registry, registryName, searchSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := server.FindPackageWithTransport(spec.JSON, meta[generator.JobMetaPackageType], meta[generator.JobMetaTransportType])
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets.FromPackage(pkg), nil
+}
+
+// writeSecrets resolves a job's secrets against its secrets variable and writes the
+// variable when a secret was set, returning the required secrets that are still missing
+// instead when there are any
+func writeSecrets(ctx context.Context, client *nomad.Client, jobID string, secretList []secrets.Secret, supplied map[string]string, prompt secrets.Prompter, dryRun bool) ([]string, error) {
+	path := generator.SecretsPath(jobID)
+
+	current, err := client.GetVariable(ctx, path)
+	if errors.Is(err, nomad.ErrNotFound) {
+		current = &nomad.Variable{Path: path}
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read variable %s; %w", path, err)
+	}
+
+	items, results, err := secrets.Resolve(secretList, current.Items, supplied, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	printResults(jobID, results)
+
+	// Jobs with required secrets wait for the variable to exist, so it isn't written until they are all set
+	missing := secrets.MissingRequired(results)
+	if len(missing) > 0 {
+		output.Warning("Job %s is missing required secrets, so variable %s was not written: %s", jobID, path, strings.Join(missing, ", "))
+		return missing, nil
+	}
+
+	if secrets.Changed(results) {
+		if dryRun {
+			output.Info("Dry run - would write %d secrets to variable %s", len(items), path)
+		} else {
+			v := &nomad.Variable{Path: path, Items: items, ModifyIndex: current.ModifyIndex}
+			if _, err := client.PutVariable(ctx, v, true); err != nil {
+				return nil, fmt.Errorf("could not write variable %s; %w", path, err)
+			}
+			output.Success("Wrote %d secrets to variable %s", len(items), path)
+			slog.InfoContext(ctx, "wrote secrets variable", "job", jobID, "path", path, "items", slices.Sorted(maps.Keys(items)), "nomad_addr", client.Address())
+		}
+	}
+
+	return nil, nil
+}
+
+// suppliedValues reads secret values from the env files, in order, then the environment
+func suppliedValues(cfg config.SecretsConfig) (map[string]string, error) {
+	supplied := make(map[string]string)
+	for _, path := range cfg.EnvFiles {
+		values, err := secrets.ReadEnvFile(path)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(supplied, values)
+	}
+
+	if cfg.FromEnv {
+		for _, kv := range os.Environ() {
+			if name, value, ok := strings.Cut(kv, "="); ok && value != "" {
+				supplied[name] = value
+			}
+		}
+	}
+
+	return supplied, nil
+}
+
+// promptSecret reads a secret from the terminal without echoing it
+func promptSecret(s secrets.Secret) (string, error) {
+	label := s.Name
+	if s.Description != "" {
+		label += " (" + s.Description + ")"
+	}
+	switch {
+	case s.Default != "":
+		label += " [leave empty for the default]"
+	case !s.Required:
+		label += " [optional]"
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", label)
+	value, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(value)), nil
+}
+
+func printResults(jobID string, results []secrets.Result) {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tSECRET\tREQUIRED\tSTATUS")
+	for _, r := range results {
+		required := "no"
+		if r.Required {
+			required = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", jobID, r.Name, required, r.Status)
+	}
+	tw.Flush()
+
+	output.Print("%s", buf.String())
+}
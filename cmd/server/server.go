@@ -1,39 +1,147 @@
 package cmdserver
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/packregistry"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/validate"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// shutdownTimeout bounds how long in-flight requests are given to finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
 var ServerCmd = &cobra.Command{
 	Use:   "server",
-	Short: "Start a Nomad MCP Pack server",
-	Long: "Start a Nomad MCP Pack server that provides remote access to pack generation.\n\n" +
-		"The server exposes a REST API endpoint for generating Nomad MCP Server Packs.",
-	Example: `  # Start server with default settings
+	Short: "Serve generated packs as a browsable nomad-pack registry",
+	Long: "Serve the packs in the output directory as a read-only nomad-pack registry over HTTP.\n\n" +
+		"The server lists the generated packs with their metadata and, when a watch state file is\n" +
+		"available, the server, package and generation details that produced them. Packs can be\n" +
+		"downloaded as ZIP archives or gzipped tarballs, and individual pack files can be fetched.\n\n" +
+		"Endpoints:\n" +
+		"  GET /                                  HTML catalog of the generated packs\n" +
+		"  GET /healthz                           Liveness check\n" +
+		"  GET /openapi.json                      OpenAPI document describing the endpoints\n" +
+		"  GET /v1/packs                          List packs\n" +
+		"  GET /v1/packs/{name}                   Pack details and files\n" +
+		"  GET /v1/packs/{name}/download          Download a pack (?format=zip|tar.gz, default zip)\n" +
+		"  GET /v1/packs/{name}/files/{path}      Fetch a single pack file\n\n" +
+		"The state file defaults to the watch command's state file.\n\n" +
+		"Access is open unless API tokens (server.tokens) or client certificate rules\n" +
+		"(server.client_certs) are configured. Tokens are sent as a bearer token or a basic auth\n" +
+		"password, and client certificates require TLS with a client CA. Every endpoint but\n" +
+		"/healthz and /openapi.json requires the read scope. The generate scope is reserved for\n" +
+		"pack generation endpoints and currently grants nothing.",
+	Example: `  # Serve packs from the default output directory
   nomad-mcp-pack server
-  
-  # Start on custom port
-  nomad-mcp-pack server --addr ":9090"
-	
+
+  # Serve packs from a custom output directory on a custom port
+  nomad-mcp-pack server --output-dir ./packs --addr ":9090"
+
+  # Serve packs with generation details from a specific state file
+  nomad-mcp-pack server --state-file ./watch.json
+
   # Start with custom timeouts
-  nomad-mcp-pack server --read-timeout 30 --write-timeout 30`,
-	RunE: runServer,
+  nomad-mcp-pack server --read-timeout 30 --write-timeout 30
+
+  # Serve over TLS, accepting client certificates signed by a CA
+  nomad-mcp-pack server --tls-cert-file server.crt --tls-key-file server.key --tls-client-ca-file ca.crt`,
+	PreRunE: runValidate,
+	RunE:    runServer,
 }
 
 func init() {
 	ServerCmd.Flags().String("addr", config.DefaultConfig.ServerAddr, "Server address")
 	ServerCmd.Flags().Int("read-timeout", config.DefaultConfig.ServerReadTimeout, "Read timeout in seconds")
 	ServerCmd.Flags().Int("write-timeout", config.DefaultConfig.ServerWriteTimeout, "Write timeout in seconds")
+	ServerCmd.Flags().String("state-file", config.DefaultConfig.ServerStateFile, "Watch state file or store URL to read generation details from (default: the watch state file)")
+	ServerCmd.Flags().String("tls-cert-file", config.DefaultConfig.ServerTLSCertFile, "TLS certificate file (default: serve plain HTTP)")
+	ServerCmd.Flags().String("tls-key-file", config.DefaultConfig.ServerTLSKeyFile, "TLS private key file")
+	ServerCmd.Flags().String("tls-client-ca-file", config.DefaultConfig.ServerTLSClientCAFile, "CA certificate file used to verify client certificates")
 
 	viper.BindPFlag("server.addr", ServerCmd.Flags().Lookup("addr"))
 	viper.BindPFlag("server.read_timeout", ServerCmd.Flags().Lookup("read-timeout"))
 	viper.BindPFlag("server.write_timeout", ServerCmd.Flags().Lookup("write-timeout"))
+	viper.BindPFlag("server.state_file", ServerCmd.Flags().Lookup("state-file"))
+	viper.BindPFlag("server.tls_cert_file", ServerCmd.Flags().Lookup("tls-cert-file"))
+	viper.BindPFlag("server.tls_key_file", ServerCmd.Flags().Lookup("tls-key-file"))
+	viper.BindPFlag("server.tls_client_ca_file", ServerCmd.Flags().Lookup("tls-client-ca-file"))
+
+	ServerCmd.Flags().SortFlags = false
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	slog.Info("starting server command input validation")
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	slog.Debug("validating server command inputs with configuration",
+		slog.Group("common_config",
+			"log_level", cfg.LogLevel,
+			"env", cfg.Env,
+			"output_dir", cfg.OutputDir,
+		),
+		slog.Group("server_config",
+			"addr", cfg.Server.Addr,
+			"read_timeout", cfg.Server.ReadTimeout,
+			"write_timeout", cfg.Server.WriteTimeout,
+			"state_file", statestore.Redact(cfg.Server.StateFile),
+			"tls_cert_file", cfg.Server.TLSCertFile,
+			"tls_key_file", cfg.Server.TLSKeyFile,
+			"tls_client_ca_file", cfg.Server.TLSClientCAFile,
+			"tokens", len(cfg.Server.Tokens),
+			"client_certs", len(cfg.Server.ClientCerts),
+		),
+	)
+
+	if err := validate.OutputDir(cfg.OutputDir); err != nil {
+		return fmt.Errorf("could not validate output directory; %w", err)
+	}
+
+	if cfg.Server.Addr == "" {
+		return fmt.Errorf("could not validate server address; address cannot be empty")
+	}
+	if err := validate.ListenAddr(cfg.Server.Addr); err != nil {
+		return fmt.Errorf("could not validate server address; %w", err)
+	}
+
+	if cfg.Server.ReadTimeout < 1 || cfg.Server.WriteTimeout < 1 {
+		return fmt.Errorf("could not validate server timeouts; timeouts must be at least 1 second")
+	}
+
+	if err := validate.StateFile(stateFile(cfg)); err != nil {
+		return fmt.Errorf("could not validate state file; %w", err)
+	}
+
+	if err := validate.ServerAuth(cfg.Server); err != nil {
+		return fmt.Errorf("could not validate server authentication; %w", err)
+	}
+
+	slog.Info("server command input validation completed successfully")
+
+	// Any errors after this point are runtime errors, not usage-related errors
+	cmd.SilenceUsage = true
+
+	return nil
 }
 
 func runServer(cmd *cobra.Command, args []string) error {
@@ -41,15 +149,152 @@ func runServer(cmd *cobra.Command, args []string) error {
 
 	cfg, err := config.GetConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return fmt.Errorf("failed to load configuration; %w", err)
 	}
 
-	output.Info("Starting server on %s...", cfg.Server.Addr)
+	store, err := statestore.Open(stateFile(cfg))
+	if err != nil {
+		return fmt.Errorf("failed to open state store; %w", err)
+	}
+	defer store.Close()
+
+	catalog := packregistry.NewCatalog(cfg.OutputDir, store, cfg.LegacyRegistry())
+
+	auth, err := newAuthenticator(cfg.Server)
+	if err != nil {
+		return err
+	}
 
-	// TODO: Implement actual server functionality
-	output.Warning("Server command is not yet implemented")
+	tlsConfig, err := newTLSConfig(cfg.Server)
+	if err != nil {
+		return err
+	}
+
+	if auth == nil {
+		output.Warning("Serving without authentication; configure server.tokens or server.client_certs to restrict access")
+	} else if tlsConfig == nil {
+		output.Warning("Serving API tokens over plain HTTP; configure TLS unless a proxy terminates it")
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", cfg.Server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s; %w", cfg.Server.Addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           tracing.Middleware(packregistry.NewHandler(catalog, auth)),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout) * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			serveErr <- srv.ServeTLS(ln, cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+			return
+		}
+		serveErr <- srv.Serve(ln)
+	}()
+
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+
+	output.Info("Serving packs from %s on %s://%s", cfg.OutputDir, scheme, ln.Addr().String())
+	slog.Info("server started", "addr", ln.Addr().String(), "tls", tlsConfig != nil, "authentication", auth != nil,
+		"output_dir", cfg.OutputDir, "state_store", store.String())
+
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed; %w", err)
+		}
+	case <-ctx.Done():
+		output.Info("Received shutdown signal, stopping server...")
+		slog.Info("received shutdown signal, stopping server...")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("failed to shut down server", "error", err)
+		}
+	}
 
 	slog.Info("server command run completed successfully")
 
 	return nil
 }
+
+// newAuthenticator returns the authenticator for the configured tokens and client certificate
+// rules, or nil when neither is configured and access is open
+func newAuthenticator(cfg config.ServerConfig) (*packregistry.Authenticator, error) {
+	if len(cfg.Tokens) == 0 && len(cfg.ClientCerts) == 0 {
+		return nil, nil
+	}
+
+	var tokens []packregistry.Token
+	for _, t := range cfg.Tokens {
+		token := t.ResolveToken()
+		if token == "" {
+			return nil, fmt.Errorf("token %q is empty; environment variable %s is not set", t.Name, t.TokenEnv)
+		}
+		tokens = append(tokens, packregistry.Token{Name: t.Name, Token: token, Scopes: scopes(t.Scopes)})
+	}
+
+	var clientCerts []packregistry.ClientCert
+	for _, c := range cfg.ClientCerts {
+		clientCerts = append(clientCerts, packregistry.ClientCert{CommonName: c.CommonName, Scopes: scopes(c.Scopes)})
+	}
+
+	return packregistry.NewAuthenticator(tokens, clientCerts), nil
+}
+
+// newTLSConfig returns the listener's TLS configuration, or nil to serve plain HTTP. Client
+// certificates are verified when given, so token clients don't need one.
+func newTLSConfig(cfg config.ServerConfig) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file; %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to parse client CA file %s; no PEM certificates found", cfg.TLSClientCAFile)
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
+}
+
+func scopes(names []string) []config.ServerScope {
+	scopes := make([]config.ServerScope, 0, len(names))
+	for _, name := range names {
+		scopes = append(scopes, config.ServerScope(name))
+	}
+	return scopes
+}
+
+// stateFile returns the state file to read generation details from, which defaults to the watch state file
+func stateFile(cfg *config.Config) string {
+	if cfg.Server.StateFile != "" {
+		return cfg.Server.StateFile
+	}
+	return cfg.Watch.StateFile
+}
@@ -0,0 +1,478 @@
+package cmdstate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/validate"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/watcher"
+	"github.com/spf13/cobra"
+)
+
+var StateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Inspect and edit the state kept by the watch command",
+	Long: "\nInspect and edit the state kept by the watch command.\n\n" +
+		"The watch command records each pack it generates in its state so unchanged packs are not regenerated. " +
+		"Forgetting an entry makes the watch command generate its pack again on the next poll. " +
+		"Edits take the same lock as the watch command, so stop any watch process using the state before editing it.",
+	Example: `  # List the state entries for npm packages
+  nomad-mcp-pack state list --filter-package-types npm
+
+  # Show a single state entry
+  nomad-mcp-pack state show "default:io.github.example/weather-mcp@1.0.0:npm:stdio"
+
+  # Regenerate every pack of a server on the next poll
+  nomad-mcp-pack state forget --filter-server-names io.github.example/weather-mcp
+
+  # Remove entries not updated in the last 90 days
+  nomad-mcp-pack state prune --older-than 2160h
+
+  # Copy the state from a file to a Nomad Variable
+  nomad-mcp-pack state export --state-file ./watch.json --output ./watch-backup.json
+  nomad-mcp-pack state import ./watch-backup.json --state-file "nomad:///nomad/jobs/nomad-mcp-pack"`,
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List state entries",
+	Args:    cobra.NoArgs,
+	PreRunE: runValidate,
+	RunE:    runList,
+}
+
+var showCmd = &cobra.Command{
+	Use:     "show <key>",
+	Short:   "Show a single state entry",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: runValidate,
+	RunE:    runShow,
+}
+
+var forgetCmd = &cobra.Command{
+	Use:   "forget [key...]",
+	Short: "Remove state entries so their packs are generated again on the next poll",
+	Long: "\nRemove state entries so their packs are generated again on the next poll.\n\n" +
+		"Entries are selected by key, as shown by 'state list', or by the filter flags. " +
+		"Use --all to forget every entry.",
+	PreRunE: runValidateForget,
+	RunE:    runForget,
+}
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove state entries that have not been updated recently",
+	Long: "\nRemove state entries that have not been updated within --older-than, such as packs of servers removed from the registry.\n\n" +
+		"A pruned entry whose server is still in the registry is generated again on the next poll.",
+	Args:    cobra.NoArgs,
+	PreRunE: runValidatePrune,
+	RunE:    runPrune,
+}
+
+var exportCmd = &cobra.Command{
+	Use:     "export",
+	Short:   "Write the state as JSON",
+	Args:    cobra.NoArgs,
+	PreRunE: runValidate,
+	RunE:    runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Replace the state with a JSON export",
+	Long: "\nReplace the state with a JSON export, as written by 'state export' or found in a watch state file.\n\n" +
+		"Use - to read the export from stdin, and --merge to add the exported entries to the existing state instead of replacing it.",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: runValidate,
+	RunE:    runImport,
+}
+
+func init() {
+	StateCmd.PersistentFlags().String("state-file", "", "Path or URL of the state store (default: watch.state_file)")
+
+	for _, cmd := range []*cobra.Command{listCmd, forgetCmd, pruneCmd} {
+		cmd.Flags().StringSlice("filter-registries", nil, "Filter by registry names (comma-separated values)")
+		cmd.Flags().StringSlice("filter-server-names", nil, "Filter by MCP Server names (comma-separated values)")
+		cmd.Flags().StringSlice("filter-package-types", nil, "Filter by package types (comma-separated values)")
+		cmd.Flags().StringSlice("filter-transport-types", nil, "Filter by transport types (comma-separated values)")
+	}
+	forgetCmd.Flags().Bool("all", false, "Forget every state entry")
+	pruneCmd.Flags().Duration("older-than", 0, "Remove entries not updated within this duration (e.g. 720h)")
+	exportCmd.Flags().StringP("output", "o", "-", "File to write the export to, or - for stdout")
+	importCmd.Flags().Bool("merge", false, "Add the exported entries to the existing state instead of replacing it")
+
+	StateCmd.AddCommand(listCmd)
+	StateCmd.AddCommand(showCmd)
+	StateCmd.AddCommand(forgetCmd)
+	StateCmd.AddCommand(pruneCmd)
+	StateCmd.AddCommand(exportCmd)
+	StateCmd.AddCommand(importCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	slog.Info("starting state command input validation")
+
+	if err := validate.StateFile(stateFile(cmd)); err != nil {
+		return fmt.Errorf("could not validate state file; %w", err)
+	}
+
+	if cmd.Flags().Lookup("filter-server-names") != nil {
+		filter := stateFilter(cmd)
+
+		if err := validate.ServerNames(filter.ServerNames); err != nil {
+			return fmt.Errorf("could not validate names filter; %w", err)
+		}
+
+		if err := validate.PackageTypes(filter.PackageTypes, false); err != nil {
+			return fmt.Errorf("could not validate package types filter; %w", err)
+		}
+
+		if err := validate.TransportTypes(filter.TransportTypes, false); err != nil {
+			return fmt.Errorf("could not validate transport types filter; %w", err)
+		}
+	}
+
+	slog.Info("state command input validation completed successfully")
+
+	// Any errors after this point are runtime errors, not usage-related errors
+	cmd.SilenceUsage = true
+
+	return nil
+}
+
+func runValidateForget(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	filtered := hasFilter(cmd)
+
+	if all && (len(args) > 0 || filtered) {
+		return fmt.Errorf("specify either keys, filters or --all, not a combination")
+	}
+	if len(args) > 0 && filtered {
+		return fmt.Errorf("specify either keys or filters, not both")
+	}
+	if !all && len(args) == 0 && !filtered {
+		return fmt.Errorf("specify the keys of the entries to forget, filters or --all")
+	}
+
+	return runValidate(cmd, args)
+}
+
+func runValidatePrune(cmd *cobra.Command, args []string) error {
+	olderThan, _ := cmd.Flags().GetDuration("older-than")
+	if olderThan <= 0 {
+		return fmt.Errorf("--older-than must be a positive duration")
+	}
+
+	return runValidate(cmd, args)
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	path := stateFile(cmd)
+
+	state, err := readState(cmd, path)
+	if err != nil {
+		return err
+	}
+
+	servers := state.Select(stateFilter(cmd))
+	if len(servers) == 0 {
+		output.Info("No matching state entries in %s", statestore.Redact(path))
+		return nil
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tSTATUS\tGENERATED\tUPDATED\tCHECKSUM")
+	for _, s := range servers {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Key(), status(s), formatTime(s.GeneratedAt), formatTime(s.UpdatedAt), valueOrDash(s.Checksum))
+	}
+	tw.Flush()
+
+	output.Print("%s", buf.String())
+	output.Info("\n%d state entries", len(servers))
+
+	return nil
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	path := stateFile(cmd)
+
+	state, err := readState(cmd, path)
+	if err != nil {
+		return err
+	}
+
+	s, exists := state.GetServer(args[0])
+	if !exists {
+		return fmt.Errorf("%s is not in %s", args[0], statestore.Redact(path))
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Key:\t%s\n", s.Key())
+	fmt.Fprintf(tw, "Server:\t%s/%s\n", s.Namespace, s.Name)
+	fmt.Fprintf(tw, "Version:\t%s\n", s.Version)
+	fmt.Fprintf(tw, "Package Type:\t%s\n", s.PackageType)
+	fmt.Fprintf(tw, "Transport Type:\t%s\n", utils.MapFromRegistryTransportType(s.TransportType))
+	fmt.Fprintf(tw, "Status:\t%s\n", status(s))
+	fmt.Fprintf(tw, "Generated At:\t%s\n", formatTime(s.GeneratedAt))
+	fmt.Fprintf(tw, "Updated At:\t%s\n", formatTime(s.UpdatedAt))
+	fmt.Fprintf(tw, "Checksum:\t%s\n", valueOrDash(s.Checksum))
+	if s.Failed {
+		fmt.Fprintf(tw, "Attempts:\t%d\n", s.Attempts)
+		fmt.Fprintf(tw, "Next Retry At:\t%s\n", formatTime(s.NextRetryAt))
+		fmt.Fprintf(tw, "Last Error:\t%s\n", s.LastError)
+	}
+	tw.Flush()
+
+	output.Print("%s", buf.String())
+
+	return nil
+}
+
+func runForget(cmd *cobra.Command, args []string) error {
+	path := stateFile(cmd)
+	all, _ := cmd.Flags().GetBool("all")
+
+	var removed []string
+	err := editState(cmd, path, func(state *watcher.WatchState) bool {
+		keys := args
+		if len(keys) == 0 {
+			// --all selects every entry as the filter is empty
+			keys = keysOf(state.Select(stateFilter(cmd)))
+		}
+
+		removed = state.Forget(keys)
+		for _, key := range args {
+			if !slices.Contains(removed, key) {
+				output.Warning("%s is not in %s", key, statestore.Redact(path))
+			}
+		}
+
+		return len(removed) > 0
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(removed) == 0 {
+		output.Info("No state entries forgotten")
+		return nil
+	}
+
+	for _, key := range removed {
+		output.Success("Forgot %s", key)
+	}
+	slog.Info("forgot state entries", "count", len(removed), "all", all, "state_file", statestore.Redact(path))
+
+	return nil
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	path := stateFile(cmd)
+	olderThan, _ := cmd.Flags().GetDuration("older-than")
+	cutoff := time.Now().Add(-olderThan)
+
+	var removed []string
+	err := editState(cmd, path, func(state *watcher.WatchState) bool {
+		var keys []string
+		for _, s := range state.Select(stateFilter(cmd)) {
+			if s.UpdatedAt.Before(cutoff) {
+				keys = append(keys, s.Key())
+			}
+		}
+
+		removed = state.Forget(keys)
+
+		return len(removed) > 0
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(removed) == 0 {
+		output.Info("No state entries older than %s", olderThan)
+		return nil
+	}
+
+	for _, key := range removed {
+		output.Success("Pruned %s", key)
+	}
+	slog.Info("pruned state entries", "count", len(removed), "older_than", olderThan, "state_file", statestore.Redact(path))
+
+	return nil
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	path := stateFile(cmd)
+	dest, _ := cmd.Flags().GetString("output")
+
+	state, err := readState(cmd, path)
+	if err != nil {
+		return err
+	}
+
+	data, err := state.Marshal()
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if dest == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("failed to write export; %w", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(dest, data, 0644); err != nil {
+		return fmt.Errorf("failed to write export; %w", err)
+	}
+
+	total, _, _ := state.Summary()
+	output.Success("Exported %d state entries to %s", total, dest)
+
+	return nil
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	path := stateFile(cmd)
+	merge, _ := cmd.Flags().GetBool("merge")
+
+	var data []byte
+	var err error
+	if args[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read export; %w", err)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	imported, err := watcher.ParseState(data, cfg.LegacyRegistry())
+	if err != nil {
+		return fmt.Errorf("could not parse export %s; %w", args[0], err)
+	}
+
+	var count int
+	err = editState(cmd, path, func(state *watcher.WatchState) bool {
+		if !merge {
+			state.Forget(keysOf(state.Select(watcher.StateFilter{})))
+			state.UpdateLastPoll(imported.GetLastPoll())
+		}
+		count = state.Merge(imported)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if merge {
+		output.Success("Merged %d state entries into %s", count, statestore.Redact(path))
+	} else {
+		output.Success("Imported %d state entries into %s", count, statestore.Redact(path))
+	}
+	slog.Info("imported state entries", "count", count, "merge", merge, "state_file", statestore.Redact(path))
+
+	return nil
+}
+
+// editState edits the state in the store at path, waiting up to watch.lock_timeout for its lock
+func editState(cmd *cobra.Command, path string, edit func(state *watcher.WatchState) bool) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	return watcher.EditState(cmd.Context(), path, cfg.LegacyRegistry(), time.Duration(cfg.Watch.LockTimeout)*time.Second, edit)
+}
+
+// stateFilter returns the filter selected by the command's filter flags
+func stateFilter(cmd *cobra.Command) watcher.StateFilter {
+	registries, _ := cmd.Flags().GetStringSlice("filter-registries")
+	names, _ := cmd.Flags().GetStringSlice("filter-server-names")
+	packageTypes, _ := cmd.Flags().GetStringSlice("filter-package-types")
+	transportTypes, _ := cmd.Flags().GetStringSlice("filter-transport-types")
+
+	return watcher.StateFilter{
+		Registries:     utils.NormalizeAndDeduplicateStrings(registries),
+		ServerNames:    utils.NormalizeAndDeduplicateStrings(names),
+		PackageTypes:   utils.NormalizeAndDeduplicateStrings(packageTypes),
+		TransportTypes: utils.NormalizeAndDeduplicateStrings(transportTypes),
+	}
+}
+
+// hasFilter reports whether any filter flag was given
+func hasFilter(cmd *cobra.Command) bool {
+	for _, name := range []string{"filter-registries", "filter-server-names", "filter-package-types", "filter-transport-types"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			return true
+		}
+	}
+	return false
+}
+
+// stateFile returns the --state-file flag if set, otherwise the watch command's configured state file
+func stateFile(cmd *cobra.Command) string {
+	return config.WatchStateFile(cmd.Flag("state-file").Value.String())
+}
+
+// readState reads the state in the store at path, migrating state saved by earlier releases
+// with the configured registries
+func readState(cmd *cobra.Command, path string) (*watcher.WatchState, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	return watcher.ReadState(cmd.Context(), path, cfg.LegacyRegistry())
+}
+
+func keysOf(servers []*watcher.ServerState) []string {
+	keys := make([]string, 0, len(servers))
+	for _, s := range servers {
+		keys = append(keys, s.Key())
+	}
+	return keys
+}
+
+func status(s *watcher.ServerState) string {
+	switch {
+	case s.Quarantined:
+		return "quarantined"
+	case s.Failed:
+		return "failed"
+	default:
+		return "generated"
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func valueOrDash(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
@@ -0,0 +1,347 @@
+package cmdstatus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/deploy"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/drift"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/server"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/validate"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var StatusCmd = &cobra.Command{
+	Use:     "status [job...]",
+	Aliases: []string{"outdated"},
+	Short:   "Compare the MCP servers deployed to Nomad with the registry",
+	Long: "\nCompare the MCP servers deployed to Nomad with the registry.\n\n" +
+		"Jobs generated by nomad-mcp-pack carry job meta identifying the MCP Server, version, package type, transport " +
+		"type and registry they were generated from. This command lists the running jobs with that meta, or only the " +
+		"named jobs, and looks up each version and the server's latest version in the registry. A deployment is " +
+		"current when it runs the latest version or a newer one, outdated when a newer version is available, deprecated or deleted " +
+		"when its version is, and unknown when its version cannot be found. Invoked as 'outdated', only deployments " +
+		"that are not current are listed.\n\n" +
+		"With --upgrade, the job named as the only argument is upgraded if the registry's latest version is newer than " +
+		"its version: the pack for the latest version is generated with the deployment's package and transport type, " +
+		"unless it already exists in the output directory, and deployed as the same job. Pack variables start from " +
+		"their defaults, as the values a job was deployed with cannot be read back, so pass the var files and --var " +
+		"flags it was deployed with. Jobs are upgraded one at a time as each needs its own variables.\n\n" +
+		"The Nomad API is configured with the NOMAD_ADDR, NOMAD_TOKEN, NOMAD_NAMESPACE and NOMAD_REGION environment " +
+		"variables; set NOMAD_NAMESPACE=* to check every namespace.",
+	Example: `  # List the deployed MCP servers and how they compare with the registry
+  nomad-mcp-pack status
+
+  # List only the deployments that are not current, across every namespace
+  NOMAD_NAMESPACE='*' nomad-mcp-pack outdated
+
+  # Show what upgrading a job would do
+  nomad-mcp-pack status weather-mcp --upgrade --dry-run
+
+  # Upgrade a job, deploying it with the var file it was deployed with
+  nomad-mcp-pack status weather-mcp --upgrade --var-file weather.hcl`,
+	PreRunE: runValidate,
+	RunE:    runStatus,
+}
+
+func init() {
+	StatusCmd.Flags().Bool("upgrade", false, "Generate and deploy the latest version of the named job if it is not running it")
+	StatusCmd.Flags().StringSlice("var-file", []string{}, "Var file setting pack variables when upgrading, applied in order (can be repeated)")
+	StatusCmd.Flags().StringArray("var", []string{}, "Pack variable as name=value when upgrading, applied after var files (can be repeated)")
+
+	StatusCmd.Flags().SortFlags = false
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	slog.Info("starting status command input validation")
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	upgrade, _ := cmd.Flags().GetBool("upgrade")
+	varFiles, _ := cmd.Flags().GetStringSlice("var-file")
+	vars, _ := cmd.Flags().GetStringArray("var")
+
+	slog.Debug("validating status command inputs with configuration",
+		slog.Group("common_config",
+			"registries", len(cfg.Registries),
+			"log_level", cfg.LogLevel,
+			"env", cfg.Env,
+			"output_dir", cfg.OutputDir,
+			"output_type", cfg.OutputType,
+			"dry_run", cfg.DryRun,
+		),
+		slog.Group("status_config",
+			"jobs", args,
+			"upgrade", upgrade,
+			"var_files", varFiles,
+			"vars", len(vars),
+		),
+		slog.Group("deploy_config",
+			"detach", cfg.Deploy.Detach,
+			"wait_timeout", cfg.Deploy.WaitTimeout,
+		),
+	)
+
+	if err := validate.Registries(cfg.Registries); err != nil {
+		return fmt.Errorf("could not validate registries; %w", err)
+	}
+
+	if upgrade && (cfg.OutputType == config.OutputTypeJobspec || cfg.OutputType == config.OutputTypeJobspecJSON) {
+		return fmt.Errorf("could not validate output type; --upgrade deploys a pack and cannot use output type %s", cfg.OutputType)
+	}
+
+	// Each job is deployed with its own variables, which can't be read back to apply to several jobs
+	if upgrade && len(args) != 1 {
+		return fmt.Errorf("could not validate jobs; --upgrade requires exactly one job argument, got %d", len(args))
+	}
+
+	if (len(varFiles) > 0 || len(vars) > 0) && !upgrade {
+		return fmt.Errorf("could not validate variables; --var-file and --var require --upgrade")
+	}
+
+	if err := validate.VarFiles(varFiles); err != nil {
+		return fmt.Errorf("could not validate var files; %w", err)
+	}
+
+	for _, v := range vars {
+		if name, _, ok := strings.Cut(v, "="); !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("could not validate variable %q; expected name=value", v)
+		}
+	}
+
+	if cfg.Deploy.WaitTimeout < 1 {
+		return fmt.Errorf("could not validate wait timeout; timeout must be at least 1 second")
+	}
+
+	slog.Info("status command input validation completed successfully")
+
+	// Any errors after this point are runtime errors, not usage-related errors
+	cmd.SilenceUsage = true
+
+	return nil
+}
+
+func runStatus(cmd *cobra.Command, args []string) (err error) {
+	slog.Info("starting status command run")
+
+	// Nomad and registry requests share the command's correlation ID and trace
+	ctx := utils.WithRequestID(cmd.Context(), tracing.NewRequestID())
+	ctx, span := tracing.Start(ctx, "status", attribute.StringSlice("jobs", args))
+	defer func() { tracing.End(span, err) }()
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	upgrade, _ := cmd.Flags().GetBool("upgrade")
+	varFiles, _ := cmd.Flags().GetStringSlice("var-file")
+	vars, _ := cmd.Flags().GetStringArray("var")
+
+	client, err := nomad.NewClient(nomad.DefaultConfig())
+	if err != nil {
+		return fmt.Errorf("could not create nomad client; %w", err)
+	}
+
+	jobs, err := client.ListJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	deployments := drift.Deployments(jobs)
+	if len(args) > 0 {
+		deployments = slices.DeleteFunc(deployments, func(d *drift.Deployment) bool {
+			return !slices.Contains(args, d.JobID)
+		})
+	}
+	if upgrade && len(deployments) == 0 {
+		return fmt.Errorf("job %s is not an MCP server job generated by nomad-mcp-pack in %s", args[0], client.Address())
+	}
+	if len(deployments) == 0 {
+		output.Info("No MCP server jobs generated by nomad-mcp-pack found in %s", client.Address())
+		return nil
+	}
+
+	if err := drift.Check(ctx, deployments, finder(cfg)); err != nil {
+		return err
+	}
+
+	// The outdated alias lists only the deployments that need attention
+	listed := deployments
+	if cmd.CalledAs() == "outdated" {
+		listed = slices.DeleteFunc(slices.Clone(deployments), func(d *drift.Deployment) bool {
+			return d.Status == drift.StatusCurrent
+		})
+	}
+
+	if len(listed) == 0 {
+		output.Success("All %d deployments run the latest version", len(deployments))
+	} else {
+		printDeployments(listed)
+	}
+
+	slog.InfoContext(ctx, "checked deployments", "deployments", len(deployments), "not_current", countNotCurrent(deployments))
+
+	if !upgrade {
+		slog.Info("status command run completed successfully")
+		return nil
+	}
+
+	// A job with the same ID in several namespaces is ambiguous when every namespace is listed
+	if len(deployments) > 1 {
+		return fmt.Errorf("job %s runs in %d namespaces, set NOMAD_NAMESPACE to upgrade one of them", args[0], len(deployments))
+	}
+
+	d := deployments[0]
+	if !d.Upgradable() {
+		output.Info("Job %s does not need upgrading", d.JobID)
+		slog.Info("status command run completed successfully")
+		return nil
+	}
+
+	if err := upgradeDeployment(ctx, cfg, client, d, varFiles, vars); err != nil {
+		slog.ErrorContext(ctx, "failed to upgrade deployment", "job", d.JobID, "server", d.Server, "version", d.LatestVersion, "error", err)
+		return fmt.Errorf("failed to upgrade job %s; %w", d.JobID, err)
+	}
+
+	slog.Info("status command run completed successfully")
+
+	return nil
+}
+
+// finder looks up server versions in the registry a deployment was generated from, or in
+// every registry when that registry is no longer configured
+func finder(cfg *config.Config) drift.Finder {
+	sources := cfg.RegistrySources()
+	return func(ctx context.Context, registryName string, searchSpec *server.SearchSpec) (config.RegistrySourceConfig, *server.Spec, error) {
+		if !slices.ContainsFunc(sources, func(r config.RegistrySourceConfig) bool { return r.Name == registryName }) {
+			registryName = ""
+		}
+		return server.FindInRegistries(ctx, sources, cfg.Registry, registryName, searchSpec)
+	}
+}
+
+// upgradeDeployment generates the pack for the latest version of a deployment's server and
+// deploys it as the deployment's job
+func upgradeDeployment(ctx context.Context, cfg *config.Config, client *nomad.Client, d *drift.Deployment, varFiles, vars []string) error {
+	srv := d.Latest.JSON
+	pkg, err := server.FindPackageWithTransport(srv, d.PackageType, d.TransportType)
+	if err != nil {
+		return fmt.Errorf("version %s has no %s package with %s transport; %w", d.LatestVersion, d.PackageType, d.TransportType, err)
+	}
+
+	opts := generator.Options{
+		OutputDir:      cfg.OutputDir,
+		OutputType:     string(cfg.OutputType),
+		DryRun:         cfg.DryRun,
+		ForceOverwrite: cfg.ForceOverwrite,
+		RegistryName:   d.Source.Name,
+		RegistryURL:    d.Source.URL,
+		Overrides:      cfg.OverridesFor(srv.Name),
+	}
+	packName := generator.PackName(srv, pkg)
+
+	if cfg.DryRun {
+		output.Info("Would upgrade job %s from %s to %s with pack %s", d.JobID, d.Version, d.LatestVersion, packName)
+		return nil
+	}
+
+	output.Progress("Upgrading job %s from %s to %s", d.JobID, d.Version, d.LatestVersion)
+
+	err = generator.Run(ctx, srv, pkg, opts)
+	if errors.Is(err, generator.ErrPackDirectoryExists) || errors.Is(err, generator.ErrPackArchiveExists) {
+		output.Info("Using existing pack %s", packName)
+	} else if err != nil {
+		return fmt.Errorf("failed to generate pack; %w", err)
+	}
+
+	// The job keeps its name, which otherwise defaults to the versioned pack name
+	overrides := append([]string{"job_name=" + d.JobID}, vars...)
+	rendered, _, err := deploy.RenderPack(cfg.OutputDir, packName, varFiles, overrides)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := deploy.ParseJobs(ctx, client, rendered)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.ID != d.JobID {
+			return fmt.Errorf("pack %s renders job %s instead of %s", packName, job.ID, d.JobID)
+		}
+		if job.Namespace == "" {
+			job.Namespace = d.Namespace
+		}
+	}
+
+	if err := deploy.Register(ctx, client, jobs); err != nil {
+		return err
+	}
+
+	if !cfg.Deploy.Detach {
+		for _, job := range jobs {
+			if err := deploy.Await(ctx, client, job, time.Duration(cfg.Deploy.WaitTimeout)*time.Second); err != nil {
+				return err
+			}
+		}
+	}
+
+	output.Success("Upgraded job %s to %s@%s", d.JobID, d.Server, d.LatestVersion)
+	slog.InfoContext(ctx, "upgraded deployment", "job", d.JobID, "server", d.Server, "from", d.Version, "to", d.LatestVersion)
+
+	return nil
+}
+
+func printDeployments(deployments []*drift.Deployment) {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tNAMESPACE\tSERVER\tVERSION\tLATEST\tPACKAGE\tTRANSPORT\tSTATUS")
+	for _, d := range deployments {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", d.JobID, d.Namespace, d.Server, d.Version,
+			valueOrDash(d.LatestVersion), valueOrDash(d.PackageType), valueOrDash(d.TransportType), d.Status)
+	}
+	tw.Flush()
+
+	output.Print("%s", buf.String())
+
+	for _, d := range deployments {
+		if d.Reason != "" {
+			output.Warning("%s: %s", d.JobID, d.Reason)
+		}
+	}
+}
+
+func countNotCurrent(deployments []*drift.Deployment) int {
+	var n int
+	for _, d := range deployments {
+		if d.Status != drift.StatusCurrent {
+			n++
+		}
+	}
+	return n
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -2,18 +2,34 @@ package cmdwatch
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"net/url"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"syscall"
+	"time"
 
-	"github.com/leefowlercu/go-mcp-registry/mcp"
+	"github.com/fsnotify/fsnotify"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/exitcode"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/gitrepo"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/health"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/hooks"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/metrics"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/registry"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/schedule"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tui"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/validate"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/watcher"
@@ -21,12 +37,25 @@ import (
 	"github.com/spf13/viper"
 )
 
+// Exit codes reported by a one-shot watch run. A run where nothing changed exits
+// with 0, and configuration or usage errors exit with 1.
+const (
+	exitGenerated   = 2
+	exitNonCritical = 3
+	exitCritical    = 4
+)
+
+// listenerShutdownTimeout bounds how long in-flight metrics and health requests may take on shutdown
+const listenerShutdownTimeout = 5 * time.Second
+
 var WatchCmd = &cobra.Command{
 	Use:   "watch",
 	Short: "Continuously poll the configured MCP Registry and generate Nomad Packs for new/updated MCP Servers",
 	Long: "\nWatch the configured MCP Registry (default: https://registry.modelcontextprotocol.io) for new or updated MCP Servers and automatically generate Nomad Packs.\n\n" +
 		"This command continuously polls the configured MCP Registry at the specified interval, tracks state to avoid regenerating unchanged packs, " +
-		"and supports filtering options.",
+		"and supports filtering options.\n\n" +
+		"With --once a single poll is performed, state is saved and the command exits with a code describing the result: " +
+		"0 when nothing changed, 2 when packs were generated, 3 on non-critical errors and 4 on critical errors.",
 	Example: `  # Watch all servers with default settings
   nomad-mcp-pack watch
 
@@ -36,11 +65,23 @@ var WatchCmd = &cobra.Command{
   # Custom poll interval (in seconds)
   nomad-mcp-pack watch --poll-interval 300
 
+  # Poll every 15 minutes during working hours, skipping the nightly maintenance window
+  nomad-mcp-pack watch --schedule "*/15 8-18 * * mon-fri" --poll-jitter 60 --quiet-windows "12:00-13:00"
+
   # Filter by specific server names
   nomad-mcp-pack watch --filter-server-names "io.github.containers/kubernetes-mcp-server,ai.waystation/gmail"
 
   # Dry run to see what would be generated
-  nomad-mcp-pack watch --dry-run`,
+  nomad-mcp-pack watch --dry-run
+
+  # Expose Prometheus metrics on port 9090
+  nomad-mcp-pack watch --listen-addr :9090
+
+  # Show the Terminal UI dashboard, writing logs to a file
+  nomad-mcp-pack watch --enable-tui --tui-log-file ./watch.log
+
+  # Poll once from a scheduled pipeline, writing a JSON summary to stdout
+  nomad-mcp-pack watch --once --summary-file -`,
 	PreRunE: runValidate,
 	RunE:    runWatch,
 }
@@ -50,17 +91,43 @@ func init() {
 	WatchCmd.Flags().StringSlice("filter-package-types", config.DefaultConfig.WatchFilterPackageTypes, "Filter by supported package types (comma-separated values)")
 	WatchCmd.Flags().StringSlice("filter-transport-types", config.DefaultConfig.WatchFilterTransportTypes, "Filter by transport types (comma-separated values)")
 	WatchCmd.Flags().Int("poll-interval", config.DefaultConfig.WatchPollInterval, "Polling interval in seconds")
-	WatchCmd.Flags().String("state-file", config.DefaultConfig.WatchStateFile, "Path to state file")
+	WatchCmd.Flags().String("schedule", config.DefaultConfig.WatchSchedule, "Cron expression to poll on instead of the poll interval, e.g. \"*/15 * * * *\" or \"@hourly\"")
+	WatchCmd.Flags().Int("poll-jitter", config.DefaultConfig.WatchPollJitter, "Maximum random delay in seconds added to each poll (0 to disable)")
+	WatchCmd.Flags().StringSlice("quiet-windows", config.DefaultConfig.WatchQuietWindows, "Local time windows in which polls are skipped, e.g. \"22:00-06:00\" or \"sat,sun 00:00-24:00\" (comma-separated values)")
+	WatchCmd.Flags().String("state-file", config.DefaultConfig.WatchStateFile, "Path or URL of the state store {path|file://|bolt://|consul://|nomad://}")
 	WatchCmd.Flags().Int("max-concurrent", config.DefaultConfig.WatchMaxConcurrent, "Maximum concurrent pack generations")
+	WatchCmd.Flags().Int("lock-timeout", config.DefaultConfig.WatchLockTimeout, "Seconds to wait for another process to release the state file lock (0 to fail immediately)")
+	WatchCmd.Flags().Int("retry-initial-backoff", config.DefaultConfig.WatchRetryInitialBackoff, "Seconds to wait before retrying a failed pack generation, doubled after each consecutive failure")
+	WatchCmd.Flags().Int("retry-max-backoff", config.DefaultConfig.WatchRetryMaxBackoff, "Maximum seconds to wait before retrying a failed pack generation")
+	WatchCmd.Flags().Int("quarantine-after", config.DefaultConfig.WatchQuarantineAfter, "Consecutive failures before a pack is quarantined and no longer retried (0 to never quarantine)")
 	WatchCmd.Flags().Bool("enable-tui", config.DefaultConfig.WatchEnableTUI, "Show a Terminal UI instead of a log stream")
+	WatchCmd.Flags().String("tui-log-file", config.DefaultConfig.WatchTUILogFile, "Write logs to this file while the Terminal UI is shown (default: discard logs)")
+	WatchCmd.Flags().String("listen-addr", config.DefaultConfig.WatchListenAddr, "Address to serve /metrics, /healthz and /readyz on (default: disabled)")
+	WatchCmd.Flags().Int("ready-max-missed-polls", config.DefaultConfig.WatchReadyMaxMissedPolls, "Scheduled polls without a successful poll before /readyz reports unavailable")
+	WatchCmd.Flags().Bool("reload-on-change", config.DefaultConfig.WatchReloadOnChange, "Reload the configuration when the config file changes, as well as on SIGHUP")
+	WatchCmd.Flags().Bool("once", config.DefaultConfig.WatchOnce, "Perform a single poll, save state and exit")
+	WatchCmd.Flags().String("summary-file", config.DefaultConfig.WatchSummaryFile, "Write a JSON summary of the poll to this file, or - for stdout (requires --once)")
 
 	viper.BindPFlag("watch.filter_server_names", WatchCmd.Flags().Lookup("filter-server-names"))
 	viper.BindPFlag("watch.filter_package_types", WatchCmd.Flags().Lookup("filter-package-types"))
 	viper.BindPFlag("watch.filter_transport_types", WatchCmd.Flags().Lookup("filter-transport-types"))
 	viper.BindPFlag("watch.poll_interval", WatchCmd.Flags().Lookup("poll-interval"))
+	viper.BindPFlag("watch.schedule", WatchCmd.Flags().Lookup("schedule"))
+	viper.BindPFlag("watch.poll_jitter", WatchCmd.Flags().Lookup("poll-jitter"))
+	viper.BindPFlag("watch.quiet_windows", WatchCmd.Flags().Lookup("quiet-windows"))
 	viper.BindPFlag("watch.state_file", WatchCmd.Flags().Lookup("state-file"))
 	viper.BindPFlag("watch.max_concurrent", WatchCmd.Flags().Lookup("max-concurrent"))
+	viper.BindPFlag("watch.lock_timeout", WatchCmd.Flags().Lookup("lock-timeout"))
+	viper.BindPFlag("watch.retry_initial_backoff", WatchCmd.Flags().Lookup("retry-initial-backoff"))
+	viper.BindPFlag("watch.retry_max_backoff", WatchCmd.Flags().Lookup("retry-max-backoff"))
+	viper.BindPFlag("watch.quarantine_after", WatchCmd.Flags().Lookup("quarantine-after"))
 	viper.BindPFlag("watch.enable_tui", WatchCmd.Flags().Lookup("enable-tui"))
+	viper.BindPFlag("watch.tui_log_file", WatchCmd.Flags().Lookup("tui-log-file"))
+	viper.BindPFlag("watch.listen_addr", WatchCmd.Flags().Lookup("listen-addr"))
+	viper.BindPFlag("watch.ready_max_missed_polls", WatchCmd.Flags().Lookup("ready-max-missed-polls"))
+	viper.BindPFlag("watch.reload_on_change", WatchCmd.Flags().Lookup("reload-on-change"))
+	viper.BindPFlag("watch.once", WatchCmd.Flags().Lookup("once"))
+	viper.BindPFlag("watch.summary_file", WatchCmd.Flags().Lookup("summary-file"))
 
 	WatchCmd.Flags().SortFlags = false
 }
@@ -76,6 +143,8 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	slog.Debug("validating watch command inputs with configuration",
 		slog.Group("common_config",
 			"registry_url", cfg.RegistryURL,
+			"registries", len(cfg.Registries),
+			"overrides", len(cfg.Overrides),
 			"log_level", cfg.LogLevel,
 			"env", cfg.Env,
 			"output_dir", cfg.OutputDir,
@@ -83,53 +152,105 @@ func runValidate(cmd *cobra.Command, args []string) error {
 			"allow_deprecated", cfg.AllowDeprecated,
 			"dry_run", cfg.DryRun,
 			"force_overwrite", cfg.ForceOverwrite,
+			"output_lock_timeout", cfg.OutputLockTimeout,
 		),
 		slog.Group("watch_config",
 			"filter_server_names", cfg.Watch.FilterServerNames,
 			"filter_package_types", cfg.Watch.FilterPackageTypes,
 			"filter_transport_types", cfg.Watch.FilterTransportTypes,
 			"poll_interval", cfg.Watch.PollInterval,
-			"state_file", cfg.Watch.StateFile,
+			"schedule", cfg.Watch.Schedule,
+			"poll_jitter", cfg.Watch.PollJitter,
+			"quiet_windows", cfg.Watch.QuietWindows,
+			"state_file", statestore.Redact(cfg.Watch.StateFile),
 			"max_concurrent", cfg.Watch.MaxConcurrent,
+			"lock_timeout", cfg.Watch.LockTimeout,
+			"retry_initial_backoff", cfg.Watch.RetryInitialBackoff,
+			"retry_max_backoff", cfg.Watch.RetryMaxBackoff,
+			"quarantine_after", cfg.Watch.QuarantineAfter,
 			"enable_tui", cfg.Watch.EnableTUI,
+			"tui_log_file", cfg.Watch.TUILogFile,
+			"listen_addr", cfg.Watch.ListenAddr,
+			"ready_max_missed_polls", cfg.Watch.ReadyMaxMissedPolls,
+			"once", cfg.Watch.Once,
+			"summary_file", cfg.Watch.SummaryFile,
+			"reload_on_change", cfg.Watch.ReloadOnChange,
 		),
 	)
 
-	filterServerNames := cfg.Watch.FilterServerNames
-	filterPackageTypes := cfg.Watch.FilterPackageTypes
-	filterTransportTypes := cfg.Watch.FilterTransportTypes
-	pollInterval := cfg.Watch.PollInterval
-	stateFile := cfg.Watch.StateFile
-	maxConcurrent := cfg.Watch.MaxConcurrent
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+
+	slog.Info("watch command input validation completed successfully")
+
+	// Any errors after this point are runtime errors, not usage-related errors
+	cmd.SilenceUsage = true
+
+	return nil
+}
+
+// validateConfig validates the watch command's configuration, both at startup and when it is reloaded
+func validateConfig(cfg *config.Config) error {
+	if err := validate.Registries(cfg.Registries); err != nil {
+		return fmt.Errorf("could not validate registries; %w", err)
+	}
+
+	if err := validate.Overrides(cfg.Overrides); err != nil {
+		return fmt.Errorf("could not validate overrides; %w", err)
+	}
 
-	if err := validate.ServerNames(filterServerNames); err != nil {
+	if err := validate.ServerNames(cfg.Watch.FilterServerNames); err != nil {
 		return fmt.Errorf("could not validate names filter; %w", err)
 	}
 
-	if err := validate.PackageTypes(filterPackageTypes, true); err != nil {
+	if err := validate.PackageTypes(cfg.Watch.FilterPackageTypes, true); err != nil {
 		return fmt.Errorf("could not validate package types filter; %w", err)
 	}
 
-	if err := validate.TransportTypes(filterTransportTypes, true); err != nil {
+	if err := validate.TransportTypes(cfg.Watch.FilterTransportTypes, true); err != nil {
 		return fmt.Errorf("could not validate transport types filter; %w", err)
 	}
 
-	if err := validate.PollInterval(pollInterval); err != nil {
+	if err := validate.PollInterval(cfg.Watch.PollInterval); err != nil {
 		return fmt.Errorf("could not validate poll interval; %w", err)
 	}
 
-	if err := validate.StateFile(stateFile); err != nil {
+	if err := validate.Schedule(cfg.Watch.Schedule, cfg.Watch.PollJitter, cfg.Watch.QuietWindows); err != nil {
+		return fmt.Errorf("could not validate schedule; %w", err)
+	}
+
+	if err := validate.StateFile(cfg.Watch.StateFile); err != nil {
 		return fmt.Errorf("could not validate state file; %w", err)
 	}
 
-	if err := validate.MaxConcurrent(maxConcurrent); err != nil {
+	if err := validate.MaxConcurrent(cfg.Watch.MaxConcurrent); err != nil {
 		return fmt.Errorf("could not validate max concurrent; %w", err)
 	}
 
-	slog.Info("watch command input validation completed successfully")
+	if err := validate.LockTimeout(cfg.Watch.LockTimeout); err != nil {
+		return fmt.Errorf("could not validate lock timeout; %w", err)
+	}
 
-	// Any errors after this point are runtime errors, not usage-related errors
-	cmd.SilenceUsage = true
+	if err := validate.RetryPolicy(cfg.Watch.RetryInitialBackoff, cfg.Watch.RetryMaxBackoff, cfg.Watch.QuarantineAfter); err != nil {
+		return fmt.Errorf("could not validate retry policy; %w", err)
+	}
+
+	if err := validate.ListenAddr(cfg.Watch.ListenAddr); err != nil {
+		return fmt.Errorf("could not validate listen address; %w", err)
+	}
+
+	if err := validate.ReadyMaxMissedPolls(cfg.Watch.ReadyMaxMissedPolls); err != nil {
+		return fmt.Errorf("could not validate ready max missed polls; %w", err)
+	}
+
+	if err := validate.OnceMode(cfg.Watch.Once, cfg.Watch.EnableTUI, cfg.Watch.SummaryFile); err != nil {
+		return fmt.Errorf("could not validate once mode; %w", err)
+	}
+
+	if err := validate.Hooks(cfg.Hooks); err != nil {
+		return fmt.Errorf("could not validate hooks; %w", err)
+	}
 
 	return nil
 }
@@ -147,6 +268,8 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	slog.Debug("running watch command with configuration",
 		slog.Group("common_config",
 			"registry_url", cfg.RegistryURL,
+			"registries", len(cfg.Registries),
+			"overrides", len(cfg.Overrides),
 			"log_level", cfg.LogLevel,
 			"env", cfg.Env,
 			"output_dir", cfg.OutputDir,
@@ -154,39 +277,54 @@ func runWatch(cmd *cobra.Command, args []string) error {
 			"allow_deprecated", cfg.AllowDeprecated,
 			"dry_run", cfg.DryRun,
 			"force_overwrite", cfg.ForceOverwrite,
+			"output_lock_timeout", cfg.OutputLockTimeout,
 		),
 		slog.Group("watch_config",
 			"filter_server_names", cfg.Watch.FilterServerNames,
 			"filter_package_types", cfg.Watch.FilterPackageTypes,
 			"filter_transport_types", cfg.Watch.FilterTransportTypes,
 			"poll_interval", cfg.Watch.PollInterval,
-			"state_file", cfg.Watch.StateFile,
+			"schedule", cfg.Watch.Schedule,
+			"poll_jitter", cfg.Watch.PollJitter,
+			"quiet_windows", cfg.Watch.QuietWindows,
+			"state_file", statestore.Redact(cfg.Watch.StateFile),
 			"max_concurrent", cfg.Watch.MaxConcurrent,
+			"lock_timeout", cfg.Watch.LockTimeout,
+			"retry_initial_backoff", cfg.Watch.RetryInitialBackoff,
+			"retry_max_backoff", cfg.Watch.RetryMaxBackoff,
+			"quarantine_after", cfg.Watch.QuarantineAfter,
 			"enable_tui", cfg.Watch.EnableTUI,
+			"tui_log_file", cfg.Watch.TUILogFile,
+			"listen_addr", cfg.Watch.ListenAddr,
+			"ready_max_missed_polls", cfg.Watch.ReadyMaxMissedPolls,
+			"once", cfg.Watch.Once,
+			"summary_file", cfg.Watch.SummaryFile,
+			"reload_on_change", cfg.Watch.ReloadOnChange,
 		),
 	)
 
-	filterNames := cfg.Watch.FilterServerNames
-	filterPackageTypes := cfg.Watch.FilterPackageTypes
-	filterTransportTypes := cfg.Watch.FilterTransportTypes
 	pollInterval := cfg.Watch.PollInterval
 	stateFile := cfg.Watch.StateFile
 	maxConcurrent := cfg.Watch.MaxConcurrent
-	// enableTUI := cfg.Watch.EnableTUI
+	enableTUI := cfg.Watch.EnableTUI
+	once := cfg.Watch.Once
+	summaryFile := cfg.Watch.SummaryFile
 
-	registryURL := cfg.RegistryURL
 	outputDir := cfg.OutputDir
 	outputType := cfg.OutputType
-	allowDeprecated := cfg.AllowDeprecated
 	dryRun := cfg.DryRun
 	forceOverwrite := cfg.ForceOverwrite
 
-	client := mcp.NewClient(nil)
-	registryURLParsed, err := url.Parse(registryURL)
+	registries, err := newRegistrySources(cfg)
 	if err != nil {
-		return fmt.Errorf("could not parse registry URL; %w", err)
+		return err
 	}
-	client.BaseURL = registryURLParsed
+
+	store, err := statestore.Open(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state store; %w", err)
+	}
+	defer store.Close()
 
 	generateOpts := generator.Options{
 		OutputDir:      outputDir,
@@ -195,20 +333,39 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		ForceOverwrite: forceOverwrite,
 	}
 
-	watcherConfig := &watcher.WatcherConfig{
-		PollInterval:    pollInterval,
-		StateFilePath:   stateFile,
-		MaxConcurrent:   maxConcurrent,
-		AllowDeprecated: allowDeprecated,
-		NameFilter: &watcher.ServerNameFilter{
-			Names: utils.NormalizeAndDeduplicateStrings(filterNames),
-		},
-		PackageFilter: &watcher.PackageTypeFilter{
-			Types: utils.NormalizeAndDeduplicateStrings(filterPackageTypes),
-		},
-		TransportFilter: &watcher.TransportTypeFilter{
-			Types: utils.NormalizeAndDeduplicateStrings(filterTransportTypes),
-		},
+	watcherConfig, err := newWatcherConfig(cfg, store, registries)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Git.Enabled && !dryRun {
+		repo, err := gitrepo.Open(ctx, outputDir, cfg.Git)
+		if err != nil {
+			return fmt.Errorf("failed to open git repository for output directory; %w", err)
+		}
+		watcherConfig.Git = repo
+	}
+
+	if once && summaryFile == "-" {
+		// The summary owns stdout, so user-facing output is suppressed
+		config.SetSilent(true)
+		defer config.SetSilent(cfg.Silent)
+	}
+
+	if enableTUI {
+		// The dashboard owns the terminal, so user-facing output is suppressed and logs redirected
+		config.SetSilent(true)
+
+		logWriter := io.Discard
+		if cfg.Watch.TUILogFile != "" {
+			logFile, err := os.OpenFile(cfg.Watch.TUILogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to open TUI log file; %w", err)
+			}
+			defer logFile.Close()
+			logWriter = logFile
+		}
+		slog.SetDefault(utils.SetupLoggerWithWriter(cfg.LogLevel, cfg.Env, logWriter))
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -225,14 +382,54 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
-	output.Info("Starting watch mode (polling every %d seconds)...", pollInterval)
+	// Hold the state file lock for the whole run so other watch processes can't clobber the state
+	unlock, err := statestore.Lock(ctx, store, time.Duration(cfg.Watch.LockTimeout)*time.Second)
+	if err != nil {
+		return fmt.Errorf("could not lock state file; %w", err)
+	}
+	defer unlock()
 
-	w, err := watcher.NewWatcher(client, watcherConfig, generateOpts)
+	w, err := watcher.NewWatcher(ctx, watcherConfig, generateOpts)
 	if err != nil {
 		return fmt.Errorf("failed to create watcher: %w", err)
 	}
 
-	err = w.Run(ctx)
+	if once {
+		return runOnce(ctx, w, summaryFile)
+	}
+
+	if cfg.Watch.Schedule != "" {
+		output.Info("Starting watch mode (polling on schedule %q)...", cfg.Watch.Schedule)
+	} else {
+		output.Info("Starting watch mode (polling every %d seconds)...", pollInterval)
+	}
+
+	if listenAddr := cfg.Watch.ListenAddr; listenAddr != "" {
+		srv, err := startListener(listenAddr, health.NewChecker(w, cfg.Watch.ReadyMaxMissedPolls))
+		if err != nil {
+			return err
+		}
+		defer shutdownListener(srv)
+		output.Info("Serving metrics and health checks on %s", listenAddr)
+	}
+
+	watchReloads(ctx, w, store, registries, cfg)
+
+	if enableTUI {
+		var registryURLs []string
+		for _, source := range registries {
+			registryURLs = append(registryURLs, source.URL)
+		}
+
+		err = runWithTUI(ctx, cancel, w, tui.Options{
+			RegistryURL:   strings.Join(registryURLs, ", "),
+			OutputDir:     outputDir,
+			MaxConcurrent: maxConcurrent,
+		})
+		config.SetSilent(cfg.Silent)
+	} else {
+		err = w.Run(ctx)
+	}
 	if err != nil {
 		if errors.Is(err, watcher.ErrGracefulShutdown) {
 			output.Info("Watch mode stopped")
@@ -246,3 +443,369 @@ func runWatch(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// registrySource is a configured registry with its client. Registries are set up once at
+// startup, so their filters are taken from the configuration the watcher started with.
+type registrySource struct {
+	config.RegistrySourceConfig
+	client *registry.Client
+}
+
+// newRegistrySources creates a client for each configured registry, in precedence order
+func newRegistrySources(cfg *config.Config) ([]registrySource, error) {
+	var sources []registrySource
+
+	for _, reg := range cfg.RegistrySources() {
+		client, err := registry.New(reg.URL, reg.ResolveToken(), cfg.Registry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for registry %s; %w", reg.Name, err)
+		}
+		sources = append(sources, registrySource{RegistrySourceConfig: reg, client: client})
+	}
+
+	return sources, nil
+}
+
+// newWatcherConfig builds the watcher configuration from the watch command's configuration.
+// Registries without their own filters use the watch filters.
+func newWatcherConfig(cfg *config.Config, store statestore.Store, registries []registrySource) (*watcher.WatcherConfig, error) {
+	var pollSchedule schedule.Schedule
+	if cfg.Watch.Schedule != "" {
+		s, err := schedule.ParseCron(cfg.Watch.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schedule; %w", err)
+		}
+		pollSchedule = s
+	}
+
+	var quietWindows []schedule.QuietWindow
+	for _, spec := range cfg.Watch.QuietWindows {
+		window, err := schedule.ParseQuietWindow(spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse quiet window; %w", err)
+		}
+		quietWindows = append(quietWindows, window)
+	}
+
+	var sources []*watcher.RegistrySource
+	for _, reg := range registries {
+		sources = append(sources, &watcher.RegistrySource{
+			Name:   reg.Name,
+			URL:    reg.URL,
+			Client: reg.client,
+			NameFilter: &watcher.ServerNameFilter{
+				Names: utils.NormalizeAndDeduplicateStrings(orDefault(reg.FilterServerNames, cfg.Watch.FilterServerNames)),
+			},
+			PackageFilter: &watcher.PackageTypeFilter{
+				Types: utils.NormalizeAndDeduplicateStrings(orDefault(reg.FilterPackageTypes, cfg.Watch.FilterPackageTypes)),
+			},
+			TransportFilter: &watcher.TransportTypeFilter{
+				Types: utils.NormalizeAndDeduplicateStrings(orDefault(reg.FilterTransportTypes, cfg.Watch.FilterTransportTypes)),
+			},
+		})
+	}
+
+	return &watcher.WatcherConfig{
+		PollInterval:    cfg.Watch.PollInterval,
+		Schedule:        pollSchedule,
+		PollJitter:      time.Duration(cfg.Watch.PollJitter) * time.Second,
+		QuietWindows:    quietWindows,
+		Store:           store,
+		LegacyRegistry:  cfg.LegacyRegistry(),
+		MaxConcurrent:   cfg.Watch.MaxConcurrent,
+		AllowDeprecated: cfg.AllowDeprecated,
+		Registries:      sources,
+		Overrides:       cfg.Overrides,
+		Hooks:           hooks.NewRunner(cfg.Hooks),
+		GitCommitMode:   config.GitCommitMode(cfg.Git.CommitMode),
+		Retry: watcher.RetryPolicy{
+			InitialBackoff:  time.Duration(cfg.Watch.RetryInitialBackoff) * time.Second,
+			MaxBackoff:      time.Duration(cfg.Watch.RetryMaxBackoff) * time.Second,
+			QuarantineAfter: cfg.Watch.QuarantineAfter,
+		},
+		OutputLockTimeout: time.Duration(cfg.OutputLockTimeout) * time.Second,
+	}, nil
+}
+
+// orDefault returns values, or defaults when values is empty
+func orDefault(values, defaults []string) []string {
+	if len(values) > 0 {
+		return values
+	}
+	return defaults
+}
+
+// watchReloads reloads the configuration on SIGHUP, and when the config file changes if
+// watch.reload_on_change is set, until ctx is done. A configuration that fails validation
+// is rejected and the watcher keeps running with its current configuration.
+func watchReloads(ctx context.Context, w *watcher.Watcher, store statestore.Store, registries []registrySource, current *config.Config) {
+	// Registered before returning so a SIGHUP can't terminate the process once the watcher is running
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	changed := make(chan struct{}, 1)
+	if current.Watch.ReloadOnChange {
+		if configFile := viper.ConfigFileUsed(); configFile == "" {
+			slog.Warn("no config file in use, configuration will only be reloaded on SIGHUP")
+		} else if err := watchConfigFile(ctx, configFile, changed); err != nil {
+			slog.Warn("failed to watch config file, configuration will only be reloaded on SIGHUP", "config_file", configFile, "error", err)
+		} else {
+			slog.Info("watching config file for changes", "config_file", configFile)
+		}
+	}
+
+	go func() {
+		defer signal.Stop(sighup)
+
+		for {
+			var trigger string
+			select {
+			case <-ctx.Done():
+				return
+			case <-sighup:
+				trigger = "sighup"
+			case <-changed:
+				trigger = "config_file_change"
+			}
+
+			slog.Info("reloading configuration", "trigger", trigger)
+
+			cfg, err := reloadConfig(w, store, registries, current)
+			metrics.ObserveConfigReload(err)
+			if err != nil {
+				output.Warning("Configuration reload rejected, keeping the current configuration: %v", err)
+				slog.Error("configuration reload rejected", "trigger", trigger, "error", err)
+				continue
+			}
+			current = cfg
+
+			output.Info("Configuration reloaded, changes apply from the next poll")
+		}
+	}()
+}
+
+// watchConfigFile signals changed when configFile is written or replaced, until ctx is done.
+// It only signals, so the config file is re-read by the reload goroutine alone rather than
+// by viper's own watcher racing the rest of the command's viper access. The directory is
+// watched as editors often replace the file rather than write to it.
+func watchConfigFile(ctx context.Context, configFile string, changed chan<- struct{}) error {
+	fileWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config file watcher; %w", err)
+	}
+
+	configFile = filepath.Clean(configFile)
+	if err := fileWatcher.Add(filepath.Dir(configFile)); err != nil {
+		fileWatcher.Close()
+		return fmt.Errorf("failed to watch config directory; %w", err)
+	}
+
+	go func() {
+		defer fileWatcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-fileWatcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != configFile || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			case err, ok := <-fileWatcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("config file watcher error", "config_file", configFile, "error", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// reloadConfig re-reads and validates the configuration, passing the settings that can
+// change while running to the watcher. Settings that need a restart are logged and ignored.
+func reloadConfig(w *watcher.Watcher, store statestore.Store, registries []registrySource, current *config.Config) (*config.Config, error) {
+	cfg, err := config.ReloadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration; %w", err)
+	}
+
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	if settings := restartSettings(current, cfg); len(settings) > 0 {
+		output.Warning("Restart the watch command to apply changes to: %s", strings.Join(settings, ", "))
+		slog.Warn("reloaded configuration changes settings that require a restart", "settings", settings)
+	}
+
+	watcherConfig, err := newWatcherConfig(cfg, store, registries)
+	if err != nil {
+		return nil, err
+	}
+	w.Reload(watcherConfig)
+
+	return cfg, nil
+}
+
+// restartSettings returns the settings changed between prev and next that only take effect on restart
+func restartSettings(prev, next *config.Config) []string {
+	settings := []struct {
+		name    string
+		changed bool
+	}{
+		{"registry_url", prev.RegistryURL != next.RegistryURL},
+		{"registries", !reflect.DeepEqual(prev.Registries, next.Registries)},
+		{"registry", prev.Registry != next.Registry},
+		{"log_level", prev.LogLevel != next.LogLevel},
+		{"env", prev.Env != next.Env},
+		{"output_dir", prev.OutputDir != next.OutputDir},
+		{"output_type", prev.OutputType != next.OutputType},
+		{"dry_run", prev.DryRun != next.DryRun},
+		{"force_overwrite", prev.ForceOverwrite != next.ForceOverwrite},
+		{"git", prev.Git != next.Git},
+		{"watch.state_file", prev.Watch.StateFile != next.Watch.StateFile},
+		{"watch.lock_timeout", prev.Watch.LockTimeout != next.Watch.LockTimeout},
+		{"watch.enable_tui", prev.Watch.EnableTUI != next.Watch.EnableTUI},
+		{"watch.tui_log_file", prev.Watch.TUILogFile != next.Watch.TUILogFile},
+		{"watch.listen_addr", prev.Watch.ListenAddr != next.Watch.ListenAddr},
+		{"watch.ready_max_missed_polls", prev.Watch.ReadyMaxMissedPolls != next.Watch.ReadyMaxMissedPolls},
+		{"watch.reload_on_change", prev.Watch.ReloadOnChange != next.Watch.ReloadOnChange},
+	}
+
+	var changed []string
+	for _, s := range settings {
+		if s.changed {
+			changed = append(changed, s.name)
+		}
+	}
+
+	return changed
+}
+
+// runOnce performs a single poll, writes its summary and reports the outcome as the exit code
+func runOnce(ctx context.Context, w *watcher.Watcher, summaryFile string) error {
+	output.Info("Running a single poll...")
+
+	summary, pollErr := w.RunOnce(ctx)
+	if pollErr != nil {
+		slog.Error("poll failed", "error", pollErr)
+	}
+
+	if summaryFile != "" {
+		if err := writeSummary(summaryFile, summary); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("one-shot poll completed",
+		"outcome", summary.Outcome,
+		"generated", len(summary.Generated),
+		"skipped", len(summary.Skipped),
+		"failed", len(summary.Failed),
+	)
+
+	switch summary.Outcome {
+	case watcher.PollOutcomeGenerated:
+		output.Success("Generated %d packs", len(summary.Generated))
+		return exitcode.New(exitGenerated)
+	case watcher.PollOutcomeNonCritical:
+		output.Warning("Poll completed with non-critical errors (%d generated, %d skipped)", len(summary.Generated), len(summary.Skipped))
+		return exitcode.New(exitNonCritical)
+	case watcher.PollOutcomeCritical:
+		output.Failure("Poll failed: %v", pollErr)
+		return exitcode.New(exitCritical)
+	}
+
+	output.Info("No packs needed generation")
+
+	return nil
+}
+
+// writeSummary writes the poll summary as JSON to path, or to stdout if path is -
+func writeSummary(path string, summary *watcher.PollSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal poll summary; %w", err)
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("failed to write poll summary; %w", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write poll summary; %w", err)
+	}
+
+	return nil
+}
+
+// runWithTUI runs the watcher in the background while the dashboard is shown,
+// stopping the watcher when the user quits the dashboard
+func runWithTUI(ctx context.Context, cancel context.CancelFunc, w *watcher.Watcher, opts tui.Options) error {
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- w.Run(ctx)
+	}()
+
+	tuiErr := tui.Run(ctx, w, opts)
+	cancel()
+
+	if err := <-watchErr; err != nil && !errors.Is(err, watcher.ErrGracefulShutdown) {
+		return err
+	}
+	if tuiErr != nil {
+		return tuiErr
+	}
+
+	return watcher.ErrGracefulShutdown
+}
+
+// startListener serves the watch daemon's metrics and health endpoints on addr
+func startListener(addr string, checker *health.Checker) (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", checker.Healthz)
+	mux.HandleFunc("GET /readyz", checker.Readyz)
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s; %w", addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("listener failed", "addr", addr, "error", err)
+		}
+	}()
+
+	slog.Info("listener started", "addr", ln.Addr().String())
+
+	return srv, nil
+}
+
+func shutdownListener(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), listenerShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("failed to shut down listener", "error", err)
+	}
+}
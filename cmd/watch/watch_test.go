@@ -0,0 +1,149 @@
+package cmdwatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/watcher"
+	"github.com/spf13/viper"
+)
+
+func TestRestartSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(cfg *config.Config)
+		expected []string
+	}{
+		{
+			name:   "unchanged",
+			change: func(cfg *config.Config) {},
+		},
+		{
+			name: "reloadable settings",
+			change: func(cfg *config.Config) {
+				cfg.Watch.PollInterval = 60
+				cfg.Watch.FilterServerNames = []string{"io.github.example/*"}
+				cfg.Watch.MaxConcurrent = 10
+				cfg.OutputLockTimeout = 30
+			},
+		},
+		{
+			name: "restart settings",
+			change: func(cfg *config.Config) {
+				cfg.OutputDir = "./other"
+				cfg.Registries = []config.RegistrySourceConfig{{Name: "internal", URL: "https://registry.example.com"}}
+				cfg.Watch.StateFile = "./other.json"
+				cfg.Watch.EnableTUI = true
+			},
+			expected: []string{"registries", "output_dir", "watch.state_file", "watch.enable_tui"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := testConfig(t)
+			next := testConfig(t)
+			tt.change(next)
+
+			if settings := restartSettings(prev, next); !slices.Equal(settings, tt.expected) {
+				t.Errorf("restartSettings() = %v, expected %v", settings, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, configFile, "watch:\n  poll_interval: 300\n")
+
+	config.InitConfig()
+	viper.SetConfigFile(configFile)
+	t.Cleanup(viper.Reset)
+
+	current, err := config.ReloadConfig()
+	if err != nil {
+		t.Fatalf("ReloadConfig() unexpected error = %v", err)
+	}
+
+	store := statestore.NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	w, err := watcher.NewWatcher(context.Background(), &watcher.WatcherConfig{Store: store}, generator.Options{})
+	if err != nil {
+		t.Fatalf("NewWatcher() unexpected error = %v", err)
+	}
+
+	writeConfig(t, configFile, "watch:\n  poll_interval: 60\n  filter_server_names: [\"io.github.example/*\"]\n")
+
+	cfg, err := reloadConfig(w, store, nil, current)
+	if err != nil {
+		t.Fatalf("reloadConfig() unexpected error = %v", err)
+	}
+	if cfg.Watch.PollInterval != 60 || !slices.Equal(cfg.Watch.FilterServerNames, []string{"io.github.example/*"}) {
+		t.Errorf("reloadConfig() poll interval, names filter = %d, %v, expected 60, [io.github.example/*]", cfg.Watch.PollInterval, cfg.Watch.FilterServerNames)
+	}
+
+	// A configuration that fails validation is rejected
+	writeConfig(t, configFile, "watch:\n  poll_interval: 5\n")
+
+	if _, err := reloadConfig(w, store, nil, cfg); err == nil {
+		t.Error("reloadConfig() expected error for invalid poll interval")
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	writeConfig(t, configFile, "watch:\n  poll_interval: 300\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+	if err := watchConfigFile(ctx, configFile, changed); err != nil {
+		t.Fatalf("watchConfigFile() unexpected error = %v", err)
+	}
+
+	// Other files in the directory don't signal a change
+	writeConfig(t, filepath.Join(dir, "other.yaml"), "")
+	select {
+	case <-changed:
+		t.Fatal("watchConfigFile() signalled a change for another file")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	writeConfig(t, configFile, "watch:\n  poll_interval: 60\n")
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchConfigFile() did not signal a change to the config file")
+	}
+}
+
+// testConfig returns the default configuration, as loaded with no config file
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	config.InitConfig()
+	t.Cleanup(viper.Reset)
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig() unexpected error = %v", err)
+	}
+
+	return cfg
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/spf13/viper"
 )
@@ -20,13 +24,25 @@ func InitConfig() {
 	viper.SetDefault("output_type", DefaultConfig.OutputType)
 	viper.SetDefault("dry_run", DefaultConfig.DryRun)
 	viper.SetDefault("force_overwrite", DefaultConfig.ForceOverwrite)
+	viper.SetDefault("output_lock_timeout", DefaultConfig.OutputLockTimeout)
 	viper.SetDefault("allow_deprecated", DefaultConfig.AllowDeprecated)
 	viper.SetDefault("silent", DefaultConfig.Silent)
 	viper.SetDefault("generate.package_type", DefaultConfig.GeneratePackageType)
 	viper.SetDefault("generate.transport_type", DefaultConfig.GenerateTransportType)
+	viper.SetDefault("generate.var_files", DefaultConfig.GenerateVarFiles)
 	viper.SetDefault("server.addr", DefaultConfig.ServerAddr)
 	viper.SetDefault("server.read_timeout", DefaultConfig.ServerReadTimeout)
 	viper.SetDefault("server.write_timeout", DefaultConfig.ServerWriteTimeout)
+	viper.SetDefault("server.state_file", DefaultConfig.ServerStateFile)
+	viper.SetDefault("server.tls_cert_file", DefaultConfig.ServerTLSCertFile)
+	viper.SetDefault("server.tls_key_file", DefaultConfig.ServerTLSKeyFile)
+	viper.SetDefault("server.tls_client_ca_file", DefaultConfig.ServerTLSClientCAFile)
+	viper.SetDefault("registry.timeout", DefaultConfig.RegistryTimeout)
+	viper.SetDefault("registry.max_retries", DefaultConfig.RegistryMaxRetries)
+	viper.SetDefault("registry.initial_backoff", DefaultConfig.RegistryInitialBackoff)
+	viper.SetDefault("registry.max_backoff", DefaultConfig.RegistryMaxBackoff)
+	viper.SetDefault("registry.rate_limit", DefaultConfig.RegistryRateLimit)
+	viper.SetDefault("registry.rate_burst", DefaultConfig.RegistryRateBurst)
 	viper.SetDefault("watch.poll_interval", DefaultConfig.WatchPollInterval)
 	viper.SetDefault("watch.filter_server_names", DefaultConfig.WatchFilterServerNames)
 	viper.SetDefault("watch.filter_package_types", DefaultConfig.WatchFilterPackageTypes)
@@ -34,6 +50,37 @@ func InitConfig() {
 	viper.SetDefault("watch.state_file", DefaultConfig.WatchStateFile)
 	viper.SetDefault("watch.max_concurrent", DefaultConfig.WatchMaxConcurrent)
 	viper.SetDefault("watch.enable_tui", DefaultConfig.WatchEnableTUI)
+	viper.SetDefault("watch.tui_log_file", DefaultConfig.WatchTUILogFile)
+	viper.SetDefault("watch.listen_addr", DefaultConfig.WatchListenAddr)
+	viper.SetDefault("watch.ready_max_missed_polls", DefaultConfig.WatchReadyMaxMissedPolls)
+	viper.SetDefault("watch.retry_initial_backoff", DefaultConfig.WatchRetryInitialBackoff)
+	viper.SetDefault("watch.retry_max_backoff", DefaultConfig.WatchRetryMaxBackoff)
+	viper.SetDefault("watch.quarantine_after", DefaultConfig.WatchQuarantineAfter)
+	viper.SetDefault("watch.once", DefaultConfig.WatchOnce)
+	viper.SetDefault("watch.summary_file", DefaultConfig.WatchSummaryFile)
+	viper.SetDefault("watch.lock_timeout", DefaultConfig.WatchLockTimeout)
+	viper.SetDefault("watch.reload_on_change", DefaultConfig.WatchReloadOnChange)
+	viper.SetDefault("watch.schedule", DefaultConfig.WatchSchedule)
+	viper.SetDefault("watch.poll_jitter", DefaultConfig.WatchPollJitter)
+	viper.SetDefault("watch.quiet_windows", DefaultConfig.WatchQuietWindows)
+	viper.SetDefault("git.enabled", DefaultConfig.GitEnabled)
+	viper.SetDefault("git.commit_mode", DefaultConfig.GitCommitMode)
+	viper.SetDefault("git.tag", DefaultConfig.GitTag)
+	viper.SetDefault("git.push", DefaultConfig.GitPush)
+	viper.SetDefault("git.remote", DefaultConfig.GitRemote)
+	viper.SetDefault("git.repo_dir", DefaultConfig.GitRepoDir)
+	viper.SetDefault("tracing.enabled", DefaultConfig.TracingEnabled)
+	viper.SetDefault("tracing.endpoint", DefaultConfig.TracingEndpoint)
+	viper.SetDefault("tracing.insecure", DefaultConfig.TracingInsecure)
+	viper.SetDefault("tracing.sample_ratio", DefaultConfig.TracingSampleRatio)
+	viper.SetDefault("tracing.service_name", DefaultConfig.TracingServiceName)
+	viper.SetDefault("deploy.var_files", DefaultConfig.DeployVarFiles)
+	viper.SetDefault("deploy.plan", DefaultConfig.DeployPlan)
+	viper.SetDefault("deploy.detach", DefaultConfig.DeployDetach)
+	viper.SetDefault("deploy.wait_timeout", DefaultConfig.DeployWaitTimeout)
+	viper.SetDefault("secrets.env_files", DefaultConfig.SecretsEnvFiles)
+	viper.SetDefault("secrets.from_env", DefaultConfig.SecretsFromEnv)
+	viper.SetDefault("secrets.prompt", DefaultConfig.SecretsPrompt)
 
 	viper.SetEnvPrefix("NOMAD_MCP_PACK")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -42,7 +89,42 @@ func InitConfig() {
 	_ = viper.ReadInConfig()
 }
 
+// mu guards viper, which isn't safe for concurrent use, once the configuration can be
+// re-read or changed while other goroutines read it
+var mu sync.RWMutex
+
 func GetConfig() (*Config, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return loadConfig()
+}
+
+// ReloadConfig re-reads the config file, if any, and returns the resulting configuration
+func ReloadConfig() (*Config, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	return loadConfig()
+}
+
+// SetSilent overrides the silent setting, suppressing user-facing output while set
+func SetSilent(silent bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	viper.Set("silent", silent)
+}
+
+// loadConfig unmarshals and validates the configuration, the caller must hold mu
+func loadConfig() (*Config, error) {
 	var cfg Config
 
 	if err := viper.Unmarshal(&cfg); err != nil {
@@ -62,14 +144,167 @@ func GetConfig() (*Config, error) {
 	}
 
 	switch cfg.OutputType {
-	case OutputTypePackdir, OutputTypeArchive:
+	case OutputTypePackdir, OutputTypeArchive, OutputTypeJobspec, OutputTypeJobspecJSON:
 	default:
-		return nil, fmt.Errorf("invalid output_type: %s (must be packdir or archive)", cfg.OutputType)
+		return nil, fmt.Errorf("invalid output_type: %s (must be packdir, archive, jobspec or jobspec-json)", cfg.OutputType)
 	}
 
 	return &cfg, nil
 }
 
+// DefaultRegistryName names the registry built from registry_url when no registries are configured
+const DefaultRegistryName = "default"
+
+// WatchStateFile returns location if set, otherwise the watch command's configured state file.
+// Commands reading the watch state take their own --state-file flag, which isn't bound to
+// watch.state_file as that key is already bound to the watch command's flag.
+func WatchStateFile(location string) string {
+	if location != "" {
+		return location
+	}
+
+	cfg, err := GetConfig()
+	if err != nil {
+		return DefaultConfig.WatchStateFile
+	}
+
+	return cfg.Watch.StateFile
+}
+
+// RegistrySources returns the configured registries in precedence order, or a single
+// registry named "default" at registry_url when none are configured
+func (c *Config) RegistrySources() []RegistrySourceConfig {
+	if len(c.Registries) > 0 {
+		return c.Registries
+	}
+
+	return []RegistrySourceConfig{{Name: DefaultRegistryName, URL: c.RegistryURL}}
+}
+
+// LegacyRegistry returns the name of the registry that servers tracked before multiple
+// registries were supported came from. Those servers were fetched from registry_url, so
+// it's the registry configured with that URL, or "" when none of the registries is.
+func (c *Config) LegacyRegistry() string {
+	for _, source := range c.RegistrySources() {
+		if strings.TrimSuffix(source.URL, "/") == strings.TrimSuffix(c.RegistryURL, "/") {
+			return source.Name
+		}
+	}
+
+	return ""
+}
+
+// ResolveToken returns the registry token, read from TokenEnv when Token is not set
+func (r RegistrySourceConfig) ResolveToken() string {
+	if r.Token != "" {
+		return r.Token
+	}
+	if r.TokenEnv != "" {
+		return os.Getenv(r.TokenEnv)
+	}
+
+	return ""
+}
+
+// ResolveToken returns the API token, read from TokenEnv when Token is not set
+func (t ServerTokenConfig) ResolveToken() string {
+	if t.Token != "" {
+		return t.Token
+	}
+	if t.TokenEnv != "" {
+		return os.Getenv(t.TokenEnv)
+	}
+
+	return ""
+}
+
+// OverridesFor returns the generation overrides for a server, see ResolveOverrides
+func (c *Config) OverridesFor(serverName string) OverrideConfig {
+	return ResolveOverrides(c.Overrides, serverName)
+}
+
+// ResolveOverrides merges the overrides with a pattern matching the server name, in order,
+// so later overrides take precedence. Environment variables are merged by name.
+func ResolveOverrides(overrides []OverrideConfig, serverName string) OverrideConfig {
+	var resolved OverrideConfig
+
+	for _, o := range overrides {
+		if !slices.ContainsFunc(o.Servers, func(pattern string) bool { return MatchServerPattern(pattern, serverName) }) {
+			continue
+		}
+
+		resolved.Servers = append(resolved.Servers, o.Servers...)
+		if len(o.PackageTypes) > 0 {
+			resolved.PackageTypes = o.PackageTypes
+		}
+		if len(o.TransportTypes) > 0 {
+			resolved.TransportTypes = o.TransportTypes
+		}
+		if o.Driver != "" {
+			resolved.Driver = o.Driver
+		}
+		if len(o.Datacenters) > 0 {
+			resolved.Datacenters = o.Datacenters
+		}
+		if o.Count > 0 {
+			resolved.Count = o.Count
+		}
+		if o.CPU > 0 {
+			resolved.CPU = o.CPU
+		}
+		if o.Memory > 0 {
+			resolved.Memory = o.Memory
+		}
+		if o.ContainerPort > 0 {
+			resolved.ContainerPort = o.ContainerPort
+		}
+		if len(o.ServiceTags) > 0 {
+			resolved.ServiceTags = o.ServiceTags
+		}
+		for _, env := range o.Env {
+			resolved.Env = slices.DeleteFunc(resolved.Env, func(e EnvVarConfig) bool { return e.Name == env.Name })
+			resolved.Env = append(resolved.Env, env)
+		}
+	}
+
+	return resolved
+}
+
+// MatchServerPattern reports whether a server name matches a pattern, where * matches any
+// characters, including /, and ? matches a single character
+func MatchServerPattern(pattern, serverName string) bool {
+	if pattern == "" {
+		return false
+	}
+	if pattern == "*" {
+		return true
+	}
+
+	p, s := 0, 0
+	star, match := -1, 0
+	for s < len(serverName) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == serverName[s]):
+			p++
+			s++
+		case p < len(pattern) && pattern[p] == '*':
+			star, match = p, s
+			p++
+		case star >= 0:
+			p = star + 1
+			match++
+			s = match
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
+
 func (c *Config) IsDev() bool {
 	return c.Env == EnvDev
 }
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMatchServerPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "io.github.example/weather-mcp", name: "io.github.example/weather-mcp", expected: true},
+		{pattern: "io.github.example/*", name: "io.github.example/weather-mcp", expected: true},
+		{pattern: "io.github.example/*", name: "io.github.other/weather-mcp", expected: false},
+		{pattern: "*", name: "io.github.example/weather-mcp", expected: true},
+		{pattern: "*/weather-?cp", name: "io.github.example/weather-mcp", expected: true},
+		{pattern: "io.github.*-mcp", name: "io.github.example/weather-mcp", expected: true},
+		{pattern: "io.github.*-mcp", name: "io.github.example/weather", expected: false},
+		{pattern: "", name: "io.github.example/weather-mcp", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if got := MatchServerPattern(tt.pattern, tt.name); got != tt.expected {
+				t.Errorf("MatchServerPattern(%q, %q) = %v, expected %v", tt.pattern, tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveOverrides(t *testing.T) {
+	overrides := []OverrideConfig{
+		{
+			Servers: []string{"io.github.example/*"},
+			CPU:     1000,
+			Memory:  1024,
+			Env:     []EnvVarConfig{{Name: "LOG_LEVEL", Value: "info"}, {Name: "REGION", Value: "eu"}},
+		},
+		{
+			Servers:      []string{"io.github.example/weather-mcp"},
+			PackageTypes: []string{"npm", "oci"},
+			Memory:       2048,
+			Env:          []EnvVarConfig{{Name: "LOG_LEVEL", Value: "debug"}},
+		},
+		{
+			Servers: []string{"io.github.other/*"},
+			Driver:  "podman",
+		},
+	}
+
+	resolved := ResolveOverrides(overrides, "io.github.example/weather-mcp")
+
+	if resolved.CPU != 1000 || resolved.Memory != 2048 {
+		t.Errorf("resolved cpu, memory = %d, %d, expected 1000, 2048", resolved.CPU, resolved.Memory)
+	}
+	if !slices.Equal(resolved.PackageTypes, []string{"npm", "oci"}) {
+		t.Errorf("resolved package types = %v, expected [npm oci]", resolved.PackageTypes)
+	}
+	if resolved.Driver != "" {
+		t.Errorf("resolved driver = %q, expected none from a non-matching override", resolved.Driver)
+	}
+
+	expectedEnv := []EnvVarConfig{{Name: "REGION", Value: "eu"}, {Name: "LOG_LEVEL", Value: "debug"}}
+	if !slices.Equal(resolved.Env, expectedEnv) {
+		t.Errorf("resolved env = %v, expected %v", resolved.Env, expectedEnv)
+	}
+
+	if resolved := ResolveOverrides(overrides, "io.github.unmatched/server"); len(resolved.Servers) != 0 {
+		t.Errorf("ResolveOverrides() matched %v, expected no overrides", resolved.Servers)
+	}
+}
+
+func TestLegacyRegistry(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{
+			name:     "no registries",
+			config:   Config{RegistryURL: "https://registry.modelcontextprotocol.io/"},
+			expected: DefaultRegistryName,
+		},
+		{
+			name: "registry at registry_url",
+			config: Config{
+				RegistryURL: "https://registry.modelcontextprotocol.io/",
+				Registries: []RegistrySourceConfig{
+					{Name: "internal", URL: "https://registry.example.com"},
+					{Name: "public", URL: "https://registry.modelcontextprotocol.io"},
+				},
+			},
+			expected: "public",
+		},
+		{
+			name: "no registry at registry_url",
+			config: Config{
+				RegistryURL: "https://registry.modelcontextprotocol.io/",
+				Registries:  []RegistrySourceConfig{{Name: "internal", URL: "https://registry.example.com"}},
+			},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.LegacyRegistry(); got != tt.expected {
+				t.Errorf("LegacyRegistry() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
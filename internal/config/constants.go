@@ -4,12 +4,22 @@ var ValidPackageTypes = []string{"npm", "pypi", "oci", "nuget"}
 
 var ValidTransportTypes = []string{"stdio", "http", "sse"}
 
-var ValidOutputTypes = []string{"packdir", "archive"}
+var ValidOutputTypes = []string{"packdir", "archive", "jobspec", "jobspec-json"}
+
+var ValidGitCommitModes = []string{"generation", "poll"}
+
+var ValidStateStoreSchemes = []string{"file", "bolt", "consul", "nomad"}
+
+var ValidServerScopes = []string{"read", "generate"}
 
 const MinPollInterval = 30
 
 const MinMaxConcurrent = 1
 
+const MinReadyMaxMissedPolls = 1
+
+const MaxHookTimeout = 3600
+
 var DefaultConfig = struct {
 	RegistryURL               string
 	LogLevel                  string
@@ -18,13 +28,25 @@ var DefaultConfig = struct {
 	OutputType                string
 	DryRun                    bool
 	ForceOverwrite            bool
+	OutputLockTimeout         int
 	AllowDeprecated           bool
 	Silent                    bool
 	GeneratePackageType       string
 	GenerateTransportType     string
+	GenerateVarFiles          []string
 	ServerAddr                string
 	ServerReadTimeout         int
 	ServerWriteTimeout        int
+	ServerStateFile           string
+	ServerTLSCertFile         string
+	ServerTLSKeyFile          string
+	ServerTLSClientCAFile     string
+	RegistryTimeout           int
+	RegistryMaxRetries        int
+	RegistryInitialBackoff    int
+	RegistryMaxBackoff        int
+	RegistryRateLimit         float64
+	RegistryRateBurst         int
 	WatchPollInterval         int
 	WatchFilterServerNames    []string
 	WatchFilterPackageTypes   []string
@@ -32,6 +54,38 @@ var DefaultConfig = struct {
 	WatchStateFile            string
 	WatchMaxConcurrent        int
 	WatchEnableTUI            bool
+	WatchTUILogFile           string
+	WatchListenAddr           string
+	WatchReadyMaxMissedPolls  int
+	WatchRetryInitialBackoff  int
+	WatchRetryMaxBackoff      int
+	WatchQuarantineAfter      int
+	WatchOnce                 bool
+	WatchSummaryFile          string
+	WatchLockTimeout          int
+	WatchReloadOnChange       bool
+	WatchSchedule             string
+	WatchPollJitter           int
+	WatchQuietWindows         []string
+	HookTimeout               int
+	GitEnabled                bool
+	GitCommitMode             string
+	GitTag                    bool
+	GitPush                   bool
+	GitRemote                 string
+	GitRepoDir                string
+	TracingEnabled            bool
+	TracingEndpoint           string
+	TracingInsecure           bool
+	TracingSampleRatio        float64
+	TracingServiceName        string
+	DeployVarFiles            []string
+	DeployPlan                bool
+	DeployDetach              bool
+	DeployWaitTimeout         int
+	SecretsEnvFiles           []string
+	SecretsFromEnv            bool
+	SecretsPrompt             bool
 }{
 	RegistryURL:               "https://registry.modelcontextprotocol.io/",
 	LogLevel:                  "info",
@@ -40,13 +94,25 @@ var DefaultConfig = struct {
 	OutputType:                "packdir",
 	DryRun:                    false,
 	ForceOverwrite:            false,
+	OutputLockTimeout:         60,
 	AllowDeprecated:           false,
 	Silent:                    false,
 	GeneratePackageType:       "oci",
 	GenerateTransportType:     "http",
+	GenerateVarFiles:          []string{},
 	ServerAddr:                ":8080",
 	ServerReadTimeout:         10,
 	ServerWriteTimeout:        10,
+	ServerStateFile:           "",
+	ServerTLSCertFile:         "",
+	ServerTLSKeyFile:          "",
+	ServerTLSClientCAFile:     "",
+	RegistryTimeout:           30,
+	RegistryMaxRetries:        3,
+	RegistryInitialBackoff:    1,
+	RegistryMaxBackoff:        30,
+	RegistryRateLimit:         5,
+	RegistryRateBurst:         5,
 	WatchPollInterval:         300,
 	WatchFilterServerNames:    []string{},
 	WatchFilterPackageTypes:   ValidPackageTypes,
@@ -54,4 +120,36 @@ var DefaultConfig = struct {
 	WatchStateFile:            "./watch.json",
 	WatchMaxConcurrent:        5,
 	WatchEnableTUI:            false,
+	WatchTUILogFile:           "",
+	WatchListenAddr:           "",
+	WatchReadyMaxMissedPolls:  3,
+	WatchRetryInitialBackoff:  60,
+	WatchRetryMaxBackoff:      3600,
+	WatchQuarantineAfter:      5,
+	WatchOnce:                 false,
+	WatchSummaryFile:          "",
+	WatchLockTimeout:          0,
+	WatchReloadOnChange:       false,
+	WatchSchedule:             "",
+	WatchPollJitter:           0,
+	WatchQuietWindows:         []string{},
+	HookTimeout:               60,
+	GitEnabled:                false,
+	GitCommitMode:             "poll",
+	GitTag:                    false,
+	GitPush:                   false,
+	GitRemote:                 "origin",
+	GitRepoDir:                "",
+	TracingEnabled:            false,
+	TracingEndpoint:           "",
+	TracingInsecure:           false,
+	TracingSampleRatio:        1.0,
+	TracingServiceName:        "nomad-mcp-pack",
+	DeployVarFiles:            []string{},
+	DeployPlan:                false,
+	DeployDetach:              false,
+	DeployWaitTimeout:         300,
+	SecretsEnvFiles:           []string{},
+	SecretsFromEnv:            false,
+	SecretsPrompt:             true,
 }
@@ -19,19 +19,95 @@ const (
 type OutputType string
 
 const (
-	OutputTypePackdir OutputType = "packdir"
-	OutputTypeArchive OutputType = "archive"
+	OutputTypePackdir     OutputType = "packdir"
+	OutputTypeArchive     OutputType = "archive"
+	OutputTypeJobspec     OutputType = "jobspec"
+	OutputTypeJobspecJSON OutputType = "jobspec-json"
+)
+
+type GitCommitMode string
+
+const (
+	GitCommitModeGeneration GitCommitMode = "generation"
+	GitCommitModePoll       GitCommitMode = "poll"
+)
+
+type ServerScope string
+
+const (
+	ServerScopeRead     ServerScope = "read"
+	ServerScopeGenerate ServerScope = "generate" // Reserved for pack generation endpoints, currently grants nothing
 )
 
 type GenerateConfig struct {
-	PackageType   string `mapstructure:"package_type"`
-	TransportType string `mapstructure:"transport_type"`
+	PackageType   string   `mapstructure:"package_type"`
+	TransportType string   `mapstructure:"transport_type"`
+	VarFiles      []string `mapstructure:"var_files"`
 }
 
 type ServerConfig struct {
-	Addr         string `mapstructure:"addr"`
-	ReadTimeout  int    `mapstructure:"read_timeout"`
-	WriteTimeout int    `mapstructure:"write_timeout"`
+	Addr            string                   `mapstructure:"addr"`
+	ReadTimeout     int                      `mapstructure:"read_timeout"`
+	WriteTimeout    int                      `mapstructure:"write_timeout"`
+	StateFile       string                   `mapstructure:"state_file"`
+	TLSCertFile     string                   `mapstructure:"tls_cert_file"`
+	TLSKeyFile      string                   `mapstructure:"tls_key_file"`
+	TLSClientCAFile string                   `mapstructure:"tls_client_ca_file"`
+	Tokens          []ServerTokenConfig      `mapstructure:"tokens"`
+	ClientCerts     []ServerClientCertConfig `mapstructure:"client_certs"`
+}
+
+// ServerTokenConfig is a static API token and the scopes it grants, named for the logs
+type ServerTokenConfig struct {
+	Name     string   `mapstructure:"name"`
+	Token    string   `mapstructure:"token"`
+	TokenEnv string   `mapstructure:"token_env"`
+	Scopes   []string `mapstructure:"scopes"`
+}
+
+// ServerClientCertConfig grants scopes to client certificates whose common name matches a pattern
+type ServerClientCertConfig struct {
+	CommonName string   `mapstructure:"common_name"`
+	Scopes     []string `mapstructure:"scopes"`
+}
+
+type RegistryConfig struct {
+	Timeout        int     `mapstructure:"timeout"`
+	MaxRetries     int     `mapstructure:"max_retries"`
+	InitialBackoff int     `mapstructure:"initial_backoff"`
+	MaxBackoff     int     `mapstructure:"max_backoff"`
+	RateLimit      float64 `mapstructure:"rate_limit"`
+	RateBurst      int     `mapstructure:"rate_burst"`
+}
+
+type RegistrySourceConfig struct {
+	Name                 string   `mapstructure:"name"`
+	URL                  string   `mapstructure:"url"`
+	Token                string   `mapstructure:"token"`
+	TokenEnv             string   `mapstructure:"token_env"`
+	FilterServerNames    []string `mapstructure:"filter_server_names"`
+	FilterPackageTypes   []string `mapstructure:"filter_package_types"`
+	FilterTransportTypes []string `mapstructure:"filter_transport_types"`
+}
+
+type EnvVarConfig struct {
+	Name  string `mapstructure:"name"`
+	Value string `mapstructure:"value"`
+}
+
+// OverrideConfig replaces generation defaults for the servers matching one of its patterns
+type OverrideConfig struct {
+	Servers        []string       `mapstructure:"servers"`
+	PackageTypes   []string       `mapstructure:"package_types"`
+	TransportTypes []string       `mapstructure:"transport_types"`
+	Driver         string         `mapstructure:"driver"`
+	Datacenters    []string       `mapstructure:"datacenters"`
+	Count          int            `mapstructure:"count"`
+	CPU            int            `mapstructure:"cpu"`
+	Memory         int            `mapstructure:"memory"`
+	ContainerPort  int            `mapstructure:"container_port"`
+	ServiceTags    []string       `mapstructure:"service_tags"`
+	Env            []EnvVarConfig `mapstructure:"env"`
 }
 
 type WatchConfig struct {
@@ -42,19 +118,92 @@ type WatchConfig struct {
 	StateFile            string   `mapstructure:"state_file"`
 	MaxConcurrent        int      `mapstructure:"max_concurrent"`
 	EnableTUI            bool     `mapstructure:"enable_tui"`
+	TUILogFile           string   `mapstructure:"tui_log_file"`
+	ListenAddr           string   `mapstructure:"listen_addr"`
+	ReadyMaxMissedPolls  int      `mapstructure:"ready_max_missed_polls"`
+	RetryInitialBackoff  int      `mapstructure:"retry_initial_backoff"`
+	RetryMaxBackoff      int      `mapstructure:"retry_max_backoff"`
+	QuarantineAfter      int      `mapstructure:"quarantine_after"`
+	Once                 bool     `mapstructure:"once"`
+	SummaryFile          string   `mapstructure:"summary_file"`
+	LockTimeout          int      `mapstructure:"lock_timeout"`
+	ReloadOnChange       bool     `mapstructure:"reload_on_change"`
+	Schedule             string   `mapstructure:"schedule"`
+	PollJitter           int      `mapstructure:"poll_jitter"`
+	QuietWindows         []string `mapstructure:"quiet_windows"`
+}
+
+type HookConfig struct {
+	Command        string `mapstructure:"command"`
+	Timeout        int    `mapstructure:"timeout"`
+	FailGeneration bool   `mapstructure:"fail_generation"`
+}
+
+type HooksConfig struct {
+	PreGenerate  []HookConfig `mapstructure:"pre_generate"`
+	PostGenerate []HookConfig `mapstructure:"post_generate"`
+	OnFailure    []HookConfig `mapstructure:"on_failure"`
+	PostPoll     []HookConfig `mapstructure:"post_poll"`
+}
+
+type GitConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	CommitMode  string `mapstructure:"commit_mode"`
+	Tag         bool   `mapstructure:"tag"`
+	Push        bool   `mapstructure:"push"`
+	Remote      string `mapstructure:"remote"`
+	Branch      string `mapstructure:"branch"`
+	AuthorName  string `mapstructure:"author_name"`
+	AuthorEmail string `mapstructure:"author_email"`
+	RepoDir     string `mapstructure:"repo_dir"`
+}
+
+// TracingConfig configures OpenTelemetry spans exported over OTLP/HTTP. The standard
+// OTEL_EXPORTER_OTLP_* environment variables apply when endpoint and headers aren't set.
+type TracingConfig struct {
+	Enabled     bool              `mapstructure:"enabled"`
+	Endpoint    string            `mapstructure:"endpoint"`
+	Insecure    bool              `mapstructure:"insecure"`
+	Headers     map[string]string `mapstructure:"headers"`
+	SampleRatio float64           `mapstructure:"sample_ratio"`
+	ServiceName string            `mapstructure:"service_name"`
+}
+
+// DeployConfig configures how the deploy command renders packs and waits for their deployments
+type DeployConfig struct {
+	VarFiles    []string `mapstructure:"var_files"`
+	Plan        bool     `mapstructure:"plan"`
+	Detach      bool     `mapstructure:"detach"`
+	WaitTimeout int      `mapstructure:"wait_timeout"`
+}
+
+// SecretsConfig configures where the secrets command reads secret values from
+type SecretsConfig struct {
+	EnvFiles []string `mapstructure:"env_files"`
+	FromEnv  bool     `mapstructure:"from_env"`
+	Prompt   bool     `mapstructure:"prompt"`
 }
 
 type Config struct {
-	RegistryURL     string         `mapstructure:"registry_url"`
-	LogLevel        LogLevel       `mapstructure:"log_level"`
-	Env             Env            `mapstructure:"env"`
-	OutputDir       string         `mapstructure:"output_dir"`
-	OutputType      OutputType     `mapstructure:"output_type"`
-	DryRun          bool           `mapstructure:"dry_run"`
-	ForceOverwrite  bool           `mapstructure:"force_overwrite"`
-	AllowDeprecated bool           `mapstructure:"allow_deprecated"`
-	Silent          bool           `mapstructure:"silent"`
-	Generate        GenerateConfig `mapstructure:"generate"`
-	Server          ServerConfig   `mapstructure:"server"`
-	Watch           WatchConfig    `mapstructure:"watch"`
+	RegistryURL       string                 `mapstructure:"registry_url"`
+	Registries        []RegistrySourceConfig `mapstructure:"registries"`
+	Overrides         []OverrideConfig       `mapstructure:"overrides"`
+	LogLevel          LogLevel               `mapstructure:"log_level"`
+	Env               Env                    `mapstructure:"env"`
+	OutputDir         string                 `mapstructure:"output_dir"`
+	OutputType        OutputType             `mapstructure:"output_type"`
+	DryRun            bool                   `mapstructure:"dry_run"`
+	ForceOverwrite    bool                   `mapstructure:"force_overwrite"`
+	OutputLockTimeout int                    `mapstructure:"output_lock_timeout"`
+	AllowDeprecated   bool                   `mapstructure:"allow_deprecated"`
+	Silent            bool                   `mapstructure:"silent"`
+	Registry          RegistryConfig         `mapstructure:"registry"`
+	Generate          GenerateConfig         `mapstructure:"generate"`
+	Server            ServerConfig           `mapstructure:"server"`
+	Watch             WatchConfig            `mapstructure:"watch"`
+	Hooks             HooksConfig            `mapstructure:"hooks"`
+	Git               GitConfig              `mapstructure:"git"`
+	Tracing           TracingConfig          `mapstructure:"tracing"`
+	Deploy            DeployConfig           `mapstructure:"deploy"`
+	Secrets           SecretsConfig          `mapstructure:"secrets"`
 }
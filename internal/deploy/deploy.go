@@ -0,0 +1,80 @@
+// Package deploy renders generated packs and deploys their jobs through the Nomad API
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+)
+
+// ErrDeploymentFailed is returned when a job's deployment fails or is cancelled
+var ErrDeploymentFailed = errors.New("deployment failed")
+
+// WaitForDeployment waits for the deployment of the registered version of a service job
+// to succeed, checking it every interval and calling progress whenever its status or
+// health changes. Other job types have no deployments, so a nil deployment is returned
+// for them straight away.
+func WaitForDeployment(ctx context.Context, client *nomad.Client, job *nomad.Job, interval time.Duration, progress func(*nomad.Deployment)) (*nomad.Deployment, error) {
+	registered, err := client.GetJob(ctx, job.Namespace, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registered job %s; %w", job.ID, err)
+	}
+	if registered.Type != "" && registered.Type != nomad.JobTypeService {
+		return nil, nil
+	}
+
+	var last string
+	for {
+		deployment, err := client.LatestDeployment(ctx, registered.Namespace, registered.ID)
+		if err != nil && ctx.Err() == nil {
+			return nil, fmt.Errorf("failed to read deployment of job %s; %w", job.ID, err)
+		}
+
+		// Until the scheduler creates it, the latest deployment is the previous version's
+		if deployment != nil && deployment.JobVersion == registered.Version {
+			if state := deploymentState(deployment); state != last {
+				last = state
+				if progress != nil {
+					progress(deployment)
+				}
+			}
+
+			switch deployment.Status {
+			case nomad.DeploymentStatusSuccessful:
+				return deployment, nil
+			case nomad.DeploymentStatusFailed, nomad.DeploymentStatusCancelled:
+				return deployment, fmt.Errorf("%w; %s", ErrDeploymentFailed, deployment.StatusDescription)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return deployment, fmt.Errorf("stopped waiting for the deployment of job %s version %d; %w", job.ID, registered.Version, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// HealthSummary describes the health of a deployment's task groups, like "mcp-server 1/2 healthy"
+func HealthSummary(d *nomad.Deployment) string {
+	var groups []string
+	for _, name := range slices.Sorted(maps.Keys(d.TaskGroups)) {
+		g := d.TaskGroups[name]
+		summary := fmt.Sprintf("%s %d/%d healthy", name, g.HealthyAllocs, g.DesiredTotal)
+		if g.UnhealthyAllocs > 0 {
+			summary += fmt.Sprintf(", %d unhealthy", g.UnhealthyAllocs)
+		}
+		groups = append(groups, summary)
+	}
+	return strings.Join(groups, "; ")
+}
+
+func deploymentState(d *nomad.Deployment) string {
+	return d.Status + " " + HealthSummary(d)
+}
@@ -0,0 +1,287 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/packrender"
+	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
+	"github.com/modelcontextprotocol/registry/pkg/model"
+)
+
+func TestRenderGeneratedPack(t *testing.T) {
+	outputDir := t.TempDir()
+
+	srv := &v0.ServerJSON{
+		Name:        "io.github.example/weather-mcp",
+		Description: "Weather MCP server",
+		Version:     "1.2.0",
+	}
+	pkg := &model.Package{
+		RegistryType: "oci",
+		Identifier:   "ghcr.io/example/weather-mcp",
+		Version:      "1.2.0",
+		Transport:    model.Transport{Type: "streamable-http", URL: "http://localhost:8080/mcp"},
+		EnvironmentVariables: []model.KeyValueInput{
+			{Name: "UNITS", InputWithVariables: model.InputWithVariables{Input: model.Input{Default: "metric"}}},
+			{Name: "API_KEY", InputWithVariables: model.InputWithVariables{Input: model.Input{IsRequired: true, IsSecret: true}}},
+		},
+	}
+	if err := generator.Run(context.Background(), srv, pkg, generator.Options{OutputDir: outputDir, OutputType: "packdir"}); err != nil {
+		t.Fatalf("generator.Run() error = %v", err)
+	}
+
+	files, name, closer, err := Open(outputDir, srv.Name+"@"+srv.Version)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer closer.Close()
+
+	if name != generator.PackName(srv, pkg) {
+		t.Errorf("Open() name = %q, expected %q", name, generator.PackName(srv, pkg))
+	}
+
+	pack, err := packrender.Load(files, name)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Secrets are read from Nomad Variables, not pack variables
+	if pack.Variable("units") == nil || pack.Variable("api_key") != nil {
+		t.Errorf("Load() variables should include units and not the api_key secret")
+	}
+
+	values, err := pack.Values(nil, nil)
+	if err != nil {
+		t.Fatalf("Values() error = %v", err)
+	}
+
+	rendered, err := pack.Render(values)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	for _, job := range rendered.Jobs {
+		if _, diags := hclsyntax.ParseConfig([]byte(job.Content), job.Name, hcl.InitialPos); diags.HasErrors() {
+			t.Errorf("rendered %s is not valid HCL; %v\n%s", job.Name, diags, job.Content)
+		}
+		if !strings.Contains(job.Content, `nomad_mcp_pack_server = "io.github.example/weather-mcp"`) {
+			t.Errorf("rendered %s does not identify the server in its meta:\n%s", job.Name, job.Content)
+		}
+		if !strings.Contains(job.Content, `nomadVar "`+generator.SecretsPath(name)+`"`) || strings.Contains(job.Content, "API_KEY =") {
+			t.Errorf("rendered %s does not read its secrets from Nomad Variables:\n%s", job.Name, job.Content)
+		}
+
+		id, meta, err := job.Identity()
+		if err != nil {
+			t.Fatalf("Identity() error = %v", err)
+		}
+		if id != name || meta[generator.JobMetaServer] != srv.Name || meta[generator.JobMetaPackageType] != "oci" {
+			t.Errorf("Identity() = %q, %v; expected job %q with the server meta", id, meta, name)
+		}
+
+		for _, key := range []string{generator.JobMetaPackageIdentifier, generator.JobMetaRegistryEntryID, generator.JobMetaGeneratedAt, generator.JobMetaGeneratorVersion} {
+			if !strings.Contains(job.Content, key+" = ") {
+				t.Errorf("rendered %s does not record %s in its meta", job.Name, key)
+			}
+		}
+
+		if _, err := job.JSON(); err != nil {
+			t.Errorf("JSON() error = %v", err)
+		}
+	}
+
+	if _, _, _, err := Open(outputDir, srv.Name+"@latest"); err == nil {
+		t.Error("Open() of the latest version expected an error")
+	}
+	if _, _, _, err := Open(outputDir, srv.Name+"@9.9.9"); err == nil {
+		t.Error("Open() of an ungenerated version expected an error")
+	}
+}
+
+// stubNomad is a Nomad API serving a registered service job and a sequence of its deployments
+type stubNomad struct {
+	mu          sync.Mutex
+	job         nomad.Job
+	deployments []*nomad.Deployment
+}
+
+func (s *stubNomad) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/job/{id}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(s.job)
+	})
+	mux.HandleFunc("GET /v1/job/{id}/deployment", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// Each request moves the deployment on, holding at the last state
+		d := s.deployments[0]
+		if len(s.deployments) > 1 {
+			s.deployments = s.deployments[1:]
+		}
+		json.NewEncoder(w).Encode(d)
+	})
+	return mux
+}
+
+func TestWaitForDeployment(t *testing.T) {
+	running := func(version uint64, healthy int) *nomad.Deployment {
+		return &nomad.Deployment{ID: "d-running", JobID: "weather-mcp", JobVersion: version, Status: nomad.DeploymentStatusRunning,
+			TaskGroups: map[string]nomad.DeploymentGroupState{"mcp": {DesiredTotal: 2, HealthyAllocs: healthy}}}
+	}
+
+	tests := []struct {
+		name         string
+		jobType      string
+		deployments  []*nomad.Deployment
+		timeout      time.Duration
+		expectStatus string
+		expectErr    error
+		errorSubstr  string
+		progress     []string
+	}{
+		{
+			name: "deployment succeeds",
+			deployments: []*nomad.Deployment{
+				running(2, 0), // The previous version's deployment is ignored
+				running(3, 0),
+				running(3, 1),
+				{ID: "d-done", JobVersion: 3, Status: nomad.DeploymentStatusSuccessful,
+					TaskGroups: map[string]nomad.DeploymentGroupState{"mcp": {DesiredTotal: 2, HealthyAllocs: 2}}},
+			},
+			timeout:      5 * time.Second,
+			expectStatus: nomad.DeploymentStatusSuccessful,
+			progress:     []string{"running mcp 0/2 healthy", "running mcp 1/2 healthy", "successful mcp 2/2 healthy"},
+		},
+		{
+			name: "deployment fails",
+			deployments: []*nomad.Deployment{
+				running(3, 0),
+				{ID: "d-failed", JobVersion: 3, Status: nomad.DeploymentStatusFailed, StatusDescription: "Failed due to unhealthy allocations",
+					TaskGroups: map[string]nomad.DeploymentGroupState{"mcp": {DesiredTotal: 2, UnhealthyAllocs: 2}}},
+			},
+			timeout:      5 * time.Second,
+			expectStatus: nomad.DeploymentStatusFailed,
+			expectErr:    ErrDeploymentFailed,
+			errorSubstr:  "Failed due to unhealthy allocations",
+		},
+		{
+			name:        "deployment times out",
+			deployments: []*nomad.Deployment{running(3, 1)},
+			timeout:     50 * time.Millisecond,
+			expectErr:   context.DeadlineExceeded,
+			errorSubstr: "stopped waiting for the deployment of job weather-mcp version 3",
+		},
+		{
+			name:    "batch job has no deployment",
+			jobType: nomad.JobTypeBatch,
+			timeout: 5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobType := tt.jobType
+			if jobType == "" {
+				jobType = nomad.JobTypeService
+			}
+			stub := &stubNomad{job: nomad.Job{ID: "weather-mcp", Type: jobType, Version: 3}, deployments: tt.deployments}
+			srv := httptest.NewServer(stub.handler())
+			defer srv.Close()
+
+			client, err := nomad.NewClient(nomad.Config{Address: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			var progress []string
+			d, err := WaitForDeployment(ctx, client, &nomad.Job{ID: "weather-mcp"}, time.Millisecond, func(d *nomad.Deployment) {
+				progress = append(progress, deploymentState(d))
+			})
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) || !strings.Contains(err.Error(), tt.errorSubstr) {
+					t.Fatalf("WaitForDeployment() error = %v, expected %v containing %q", err, tt.expectErr, tt.errorSubstr)
+				}
+			} else if err != nil {
+				t.Fatalf("WaitForDeployment() unexpected error = %v", err)
+			}
+
+			if tt.expectStatus == "" {
+				if tt.expectErr == nil && d != nil {
+					t.Errorf("WaitForDeployment() = %+v, expected no deployment", d)
+				}
+			} else if d == nil || d.Status != tt.expectStatus {
+				t.Errorf("WaitForDeployment() = %+v, expected status %s", d, tt.expectStatus)
+			}
+
+			if tt.progress != nil && strings.Join(progress, "|") != strings.Join(tt.progress, "|") {
+				t.Errorf("progress = %q, expected %q", progress, tt.progress)
+			}
+		})
+	}
+}
+
+func TestFormatPlan(t *testing.T) {
+	plan := &nomad.PlanResponse{
+		Diff: &nomad.JobDiff{
+			Type: nomad.DiffTypeEdited,
+			ID:   "weather-mcp",
+			TaskGroups: []nomad.TaskGroupDiff{{
+				Type: nomad.DiffTypeEdited,
+				Name: "mcp",
+				Fields: []nomad.FieldDiff{
+					{Type: nomad.DiffTypeEdited, Name: "Count", Old: "1", New: "2"},
+				},
+				Tasks: []nomad.TaskDiff{{
+					Type:        nomad.DiffTypeEdited,
+					Name:        "server",
+					Annotations: []string{"forces create/destroy update"},
+					Objects: []nomad.ObjectDiff{{
+						Type:   nomad.DiffTypeEdited,
+						Name:   "Config",
+						Fields: []nomad.FieldDiff{{Type: nomad.DiffTypeEdited, Name: "image", Old: "weather:1.1.0", New: "weather:1.2.0"}},
+					}},
+				}},
+			}},
+		},
+		Annotations: &nomad.PlanAnnotations{DesiredTGUpdates: map[string]nomad.DesiredUpdates{
+			"mcp": {Place: 1, DestructiveUpdate: 1},
+		}},
+		Warnings: "datacenter dc9 has no nodes\n",
+	}
+
+	expected := `+/- Job: "weather-mcp"
++/- Task Group: "mcp" (1 create, 1 create/destroy update)
+  +/- Count: "1" => "2"
+  +/- Task: "server" (forces create/destroy update)
+    +/- Config {
+      +/- image: "weather:1.1.0" => "weather:1.2.0"
+    }
+
+Scheduler dry-run:
+- All tasks successfully allocated.
+
+Job Warnings:
+datacenter dc9 has no nodes
+`
+
+	if got := FormatPlan(plan); got != expected {
+		t.Errorf("FormatPlan() =\n%s\nexpected\n%s", got, expected)
+	}
+}
@@ -0,0 +1,72 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/packregistry"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/server"
+)
+
+// Open finds a pack and returns its files. The pack is a path to a pack directory or
+// archive, a pack name in the output directory, or a server@version whose pack was
+// generated in the output directory. The caller must close the returned closer.
+func Open(outputDir, pack string) (fs.FS, string, io.Closer, error) {
+	// A path is opened as a pack in its parent directory
+	if info, err := os.Stat(pack); err == nil {
+		name := filepath.Base(filepath.Clean(pack))
+		if !info.IsDir() {
+			name = strings.TrimSuffix(name, ".zip")
+		}
+		return openPack(filepath.Dir(filepath.Clean(pack)), name)
+	}
+
+	if !strings.Contains(pack, "@") {
+		return openPack(outputDir, pack)
+	}
+
+	spec, err := server.ParseSearchSpec(pack)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if spec.IsLatest() {
+		return nil, "", nil, fmt.Errorf("cannot deploy the latest version of %s; specify a version or a pack name", spec.NameSpec)
+	}
+
+	// The server version's packs differ only by package and transport type
+	catalog := packregistry.NewCatalog(outputDir, nil, "")
+	var found []string
+	for _, name := range generator.PackNames(spec.FullName(), spec.VersionSpec) {
+		if _, _, closer, err := catalog.Open(name); err == nil {
+			closer.Close()
+			found = append(found, name)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, "", nil, fmt.Errorf("no pack for %s found in %s; generate it first", pack, outputDir)
+	case 1:
+		return openPack(outputDir, found[0])
+	default:
+		return nil, "", nil, fmt.Errorf("%d packs for %s found in %s; deploy one of them by name: %s", len(found), pack, outputDir, strings.Join(found, ", "))
+	}
+}
+
+func openPack(dir, name string) (fs.FS, string, io.Closer, error) {
+	files, _, closer, err := packregistry.NewCatalog(dir, nil, "").Open(name)
+	if errors.Is(err, packregistry.ErrPackNotFound) {
+		return nil, "", nil, fmt.Errorf("pack %s not found in %s", name, dir)
+	}
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return files, name, closer, nil
+}
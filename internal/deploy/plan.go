@@ -0,0 +1,122 @@
+package deploy
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+)
+
+// diffMarkers prefix changes in plan output, like nomad job plan
+var diffMarkers = map[string]string{
+	nomad.DiffTypeAdded:   "+",
+	nomad.DiffTypeDeleted: "-",
+	nomad.DiffTypeEdited:  "+/-",
+}
+
+// FormatPlan formats a plan like nomad job plan: the changes to the job, its task groups
+// and tasks, then whether the scheduler could place every allocation
+func FormatPlan(plan *nomad.PlanResponse) string {
+	var b strings.Builder
+
+	if d := plan.Diff; d != nil {
+		fmt.Fprintf(&b, "%s Job: %q\n", marker(d.Type), d.ID)
+		writeFields(&b, d.Fields, 1)
+		writeObjects(&b, d.Objects, 1)
+
+		for _, tg := range d.TaskGroups {
+			fmt.Fprintf(&b, "%s Task Group: %q%s\n", marker(tg.Type), tg.Name, groupUpdates(plan, tg))
+			writeFields(&b, tg.Fields, 1)
+			writeObjects(&b, tg.Objects, 1)
+
+			for _, task := range tg.Tasks {
+				annotations := ""
+				if len(task.Annotations) > 0 {
+					annotations = " (" + strings.Join(task.Annotations, ", ") + ")"
+				}
+				fmt.Fprintf(&b, "  %s Task: %q%s\n", marker(task.Type), task.Name, annotations)
+				writeFields(&b, task.Fields, 2)
+				writeObjects(&b, task.Objects, 2)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(plan.FailedTGAllocs) > 0 {
+		fmt.Fprintf(&b, "Scheduler dry-run:\n- WARNING: Failed to place all allocations for task groups: %s\n",
+			strings.Join(slices.Sorted(maps.Keys(plan.FailedTGAllocs)), ", "))
+	} else {
+		b.WriteString("Scheduler dry-run:\n- All tasks successfully allocated.\n")
+	}
+
+	if plan.Warnings != "" {
+		fmt.Fprintf(&b, "\nJob Warnings:\n%s\n", strings.TrimSpace(plan.Warnings))
+	}
+
+	return b.String()
+}
+
+// groupUpdates summarises the scheduler's changes to a task group, like " (1 create, 1 ignore)"
+func groupUpdates(plan *nomad.PlanResponse, tg nomad.TaskGroupDiff) string {
+	updates := tg.Updates
+	if plan.Annotations != nil {
+		if u, ok := plan.Annotations.DesiredTGUpdates[tg.Name]; ok {
+			updates = map[string]uint64{
+				"create":                u.Place,
+				"destroy":               u.Stop,
+				"migrate":               u.Migrate,
+				"in-place update":       u.InPlaceUpdate,
+				"create/destroy update": u.DestructiveUpdate,
+				"canary":                u.Canary,
+				"ignore":                u.Ignore,
+			}
+		}
+	}
+
+	var parts []string
+	for _, name := range slices.Sorted(maps.Keys(updates)) {
+		if updates[name] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", updates[name], name))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+func writeFields(b *strings.Builder, fields []nomad.FieldDiff, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, f := range fields {
+		switch f.Type {
+		case nomad.DiffTypeAdded:
+			fmt.Fprintf(b, "%s+ %s: %q\n", indent, f.Name, f.New)
+		case nomad.DiffTypeDeleted:
+			fmt.Fprintf(b, "%s- %s: %q\n", indent, f.Name, f.Old)
+		case nomad.DiffTypeEdited:
+			fmt.Fprintf(b, "%s+/- %s: %q => %q\n", indent, f.Name, f.Old, f.New)
+		}
+	}
+}
+
+func writeObjects(b *strings.Builder, objects []nomad.ObjectDiff, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, o := range objects {
+		if o.Type == nomad.DiffTypeNone {
+			continue
+		}
+		fmt.Fprintf(b, "%s%s %s {\n", indent, marker(o.Type), o.Name)
+		writeFields(b, o.Fields, depth+1)
+		writeObjects(b, o.Objects, depth+1)
+		fmt.Fprintf(b, "%s}\n", indent)
+	}
+}
+
+func marker(diffType string) string {
+	if m, ok := diffMarkers[diffType]; ok {
+		return m
+	}
+	return " "
+}
@@ -0,0 +1,110 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/packrender"
+)
+
+// deploymentPollInterval is how often a deployment is checked while waiting for it to become healthy
+const deploymentPollInterval = 2 * time.Second
+
+// RenderPack opens a pack, as described for Open, and renders it with the var files and
+// name=value overrides, returning the rendered pack and the pack's name
+func RenderPack(outputDir, pack string, varFiles, overrides []string) (*packrender.Rendered, string, error) {
+	files, name, closer, err := Open(outputDir, pack)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not open pack %q; %w", pack, err)
+	}
+	defer closer.Close()
+
+	p, err := packrender.Load(files, name)
+	if err != nil {
+		return nil, name, fmt.Errorf("could not load pack %s; %w", name, err)
+	}
+
+	values, err := p.Values(varFiles, overrides)
+	if err != nil {
+		return nil, name, fmt.Errorf("could not resolve variables of pack %s; %w", name, err)
+	}
+
+	rendered, err := p.Render(values)
+	if err != nil {
+		return nil, name, fmt.Errorf("could not render pack %s; %w", name, err)
+	}
+
+	return rendered, name, nil
+}
+
+// ParseJobs converts every rendered jobspec to a job, so a broken template is found before
+// any job is registered
+func ParseJobs(ctx context.Context, client *nomad.Client, rendered *packrender.Rendered) ([]*nomad.Job, error) {
+	jobs := make([]*nomad.Job, 0, len(rendered.Jobs))
+	for _, r := range rendered.Jobs {
+		job, err := client.ParseJob(ctx, r.Content)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s; %w", r.Name, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// Register registers the jobs, reporting each job's evaluation and warnings
+func Register(ctx context.Context, client *nomad.Client, jobs []*nomad.Job) error {
+	for _, job := range jobs {
+		resp, err := client.RegisterJob(ctx, job)
+		if err != nil {
+			return err
+		}
+		if resp.Warnings != "" {
+			output.Warning("Job %s: %s", job.ID, strings.TrimSpace(resp.Warnings))
+		}
+		output.Success("Registered job %s (evaluation %s)", job.ID, resp.EvalID)
+		slog.InfoContext(ctx, "registered job", "job", job.ID, "namespace", job.Namespace, "eval_id", resp.EvalID, "nomad_addr", client.Address())
+	}
+
+	return nil
+}
+
+// Await waits up to timeout for the deployment of a registered job to become healthy,
+// reporting its health as it changes
+func Await(ctx context.Context, client *nomad.Client, job *nomad.Job, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output.Progress("Waiting for the deployment of job %s...", job.ID)
+
+	d, err := WaitForDeployment(ctx, client, job, deploymentPollInterval, func(d *nomad.Deployment) {
+		output.Progress("Deployment %s %s: %s", shortID(d.ID), d.Status, HealthSummary(d))
+	})
+	if err != nil {
+		output.Failure("Deployment of job %s did not succeed", job.ID)
+		return err
+	}
+
+	if d == nil {
+		output.Info("Job %s has no deployment to wait for", job.ID)
+		return nil
+	}
+
+	output.Success("Deployment %s of job %s is healthy", shortID(d.ID), job.ID)
+	slog.InfoContext(ctx, "deployment succeeded", "job", job.ID, "deployment", d.ID, "version", d.JobVersion)
+
+	return nil
+}
+
+// shortID shortens a Nomad UUID like the nomad CLI does
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
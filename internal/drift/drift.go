@@ -0,0 +1,171 @@
+// Package drift compares the MCP server versions deployed to Nomad with the registry
+package drift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/server"
+)
+
+// Status is how a deployed server version compares with the registry
+type Status string
+
+const (
+	StatusCurrent    Status = "current"    // The latest version, or a newer one, is deployed
+	StatusOutdated   Status = "outdated"   // A newer version is in the registry
+	StatusDeprecated Status = "deprecated" // The deployed version is deprecated
+	StatusDeleted    Status = "deleted"    // The deployed version is deleted
+	StatusUnknown    Status = "unknown"    // The deployed version could not be found in the registry
+)
+
+// Finder finds a server version in the registries, searching only the named registry
+// when registryName is set, like server.FindInRegistries
+type Finder func(ctx context.Context, registryName string, searchSpec *server.SearchSpec) (config.RegistrySourceConfig, *server.Spec, error)
+
+// Deployment is a job generated by nomad-mcp-pack that is running on Nomad
+type Deployment struct {
+	JobID         string
+	Namespace     string
+	JobStatus     string
+	Server        string
+	Version       string
+	PackageType   string
+	TransportType string
+	Registry      string // Registry the pack was generated from, empty when not recorded
+
+	Status        Status
+	Reason        string                      // Why the status is unknown
+	LatestVersion string                      // Latest version in the registry, empty when unknown
+	Latest        *server.Spec                // Latest version in the registry, nil when unknown
+	Source        config.RegistrySourceConfig // Registry the latest version was found in
+}
+
+// Upgradable reports whether the registry's latest version is newer than the deployed
+// version. Versions that aren't semver are upgradable whenever they differ from the latest.
+func (d *Deployment) Upgradable() bool {
+	if d.Latest == nil || d.LatestVersion == d.Version {
+		return false
+	}
+
+	deployed, err := semver.NewVersion(d.Version)
+	if err != nil {
+		return true
+	}
+	latest, err := semver.NewVersion(d.LatestVersion)
+	if err != nil {
+		return true
+	}
+
+	return latest.GreaterThan(deployed)
+}
+
+// Deployments returns the running jobs whose meta identifies the MCP server version they
+// run, sorted by namespace and job ID. Stopped jobs are left out.
+func Deployments(jobs []nomad.JobStub) []*Deployment {
+	var deployments []*Deployment
+	for _, job := range jobs {
+		name, version := job.Meta[generator.JobMetaServer], job.Meta[generator.JobMetaServerVersion]
+		if name == "" || version == "" || job.Stop {
+			continue
+		}
+
+		deployments = append(deployments, &Deployment{
+			JobID:         job.ID,
+			Namespace:     job.Namespace,
+			JobStatus:     job.Status,
+			Server:        name,
+			Version:       version,
+			PackageType:   job.Meta[generator.JobMetaPackageType],
+			TransportType: job.Meta[generator.JobMetaTransportType],
+			Registry:      job.Meta[generator.JobMetaRegistry],
+		})
+	}
+
+	slices.SortFunc(deployments, func(a, b *Deployment) int {
+		return strings.Compare(a.Namespace+"/"+a.JobID, b.Namespace+"/"+b.JobID)
+	})
+
+	return deployments
+}
+
+// Check looks up each deployment's version and the latest version of its server in the
+// registry the pack was generated from, setting the deployment's status. Each version is
+// only looked up once. An error is returned only when ctx is done.
+func Check(ctx context.Context, deployments []*Deployment, find Finder) error {
+	type result struct {
+		source config.RegistrySourceConfig
+		spec   *server.Spec
+		err    error
+	}
+	cache := make(map[string]result)
+
+	lookup := func(registryName, serverName, version string) result {
+		key := registryName + ":" + serverName + "@" + version
+		if r, ok := cache[key]; ok {
+			return r
+		}
+
+		var r result
+		searchSpec, err := server.ParseSearchSpec(serverName + "@" + version)
+		if err != nil {
+			r.err = err
+		} else {
+			r.source, r.spec, r.err = find(ctx, registryName, searchSpec)
+		}
+		cache[key] = r
+
+		return r
+	}
+
+	for _, d := range deployments {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		deployed := lookup(d.Registry, d.Server, d.Version)
+		switch {
+		case isNotFound(deployed.err):
+			d.Status, d.Reason = StatusUnknown, fmt.Sprintf("version %s is not in the registry", d.Version)
+		case deployed.err != nil:
+			d.Status, d.Reason = StatusUnknown, deployed.err.Error()
+		case deployed.spec.IsDeleted():
+			d.Status = StatusDeleted
+		case deployed.spec.IsDeprecated():
+			d.Status = StatusDeprecated
+		}
+
+		latest := lookup(d.Registry, d.Server, "latest")
+		if latest.err != nil {
+			if d.Status == "" {
+				d.Status, d.Reason = StatusUnknown, fmt.Sprintf("could not find the latest version; %v", latest.err)
+			}
+			slog.WarnContext(ctx, "failed to find latest server version", "server", d.Server, "registry", d.Registry, "error", latest.err)
+			continue
+		}
+
+		d.Latest, d.LatestVersion, d.Source = latest.spec, latest.spec.Version(), latest.source
+
+		if d.Status == "" {
+			d.Status = StatusCurrent
+			if d.Upgradable() {
+				d.Status = StatusOutdated
+			}
+		}
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var notFound *server.ServerNotFoundError
+	return errors.As(err, &notFound)
+}
@@ -0,0 +1,172 @@
+package drift
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/server"
+	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
+	"github.com/modelcontextprotocol/registry/pkg/model"
+)
+
+func jobStub(id, serverName, version string) nomad.JobStub {
+	return nomad.JobStub{
+		ID:        id,
+		Namespace: "default",
+		Status:    "running",
+		Meta: map[string]string{
+			generator.JobMetaServer:        serverName,
+			generator.JobMetaServerVersion: version,
+			generator.JobMetaPackageType:   "oci",
+			generator.JobMetaTransportType: "http",
+			generator.JobMetaRegistry:      "default",
+		},
+	}
+}
+
+func TestDeployments(t *testing.T) {
+	stopped := jobStub("stopped", "io.github.example/weather-mcp", "1.0.0")
+	stopped.Stop = true
+
+	jobs := []nomad.JobStub{
+		jobStub("weather", "io.github.example/weather-mcp", "1.0.0"),
+		{ID: "unrelated", Namespace: "default", Meta: map[string]string{"owner": "ops"}},
+		{ID: "no-meta", Namespace: "default"},
+		stopped,
+		jobStub("alpha", "io.github.example/alpha-mcp", "0.1.0"),
+	}
+
+	deployments := Deployments(jobs)
+	if len(deployments) != 2 {
+		t.Fatalf("Deployments() returned %d deployments, expected 2", len(deployments))
+	}
+	if deployments[0].JobID != "alpha" || deployments[1].JobID != "weather" {
+		t.Errorf("Deployments() = [%s %s], expected [alpha weather]", deployments[0].JobID, deployments[1].JobID)
+	}
+
+	d := deployments[1]
+	if d.Server != "io.github.example/weather-mcp" || d.Version != "1.0.0" || d.PackageType != "oci" || d.TransportType != "http" || d.Registry != "default" {
+		t.Errorf("Deployments()[1] = %+v, expected the job meta", d)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	const weather = "io.github.example/weather-mcp"
+
+	// The registry has versions 1.0.0 (deprecated), 1.1.0 (deleted), 1.2.0, 2.0.0 (latest) and
+	// a 2.1.0 prerelease, which isn't downgraded to the latest version
+	statuses := map[string]model.Status{
+		"1.0.0":        model.StatusDeprecated,
+		"1.1.0":        model.StatusDeleted,
+		"1.2.0":        model.StatusActive,
+		"2.0.0":        model.StatusActive,
+		"2.1.0-beta.1": model.StatusActive,
+	}
+	source := config.RegistrySourceConfig{Name: "default", URL: "https://registry.example.com"}
+
+	var lookups int
+	find := func(ctx context.Context, registryName string, searchSpec *server.SearchSpec) (config.RegistrySourceConfig, *server.Spec, error) {
+		lookups++
+		if searchSpec.FullName() == "io.github.example/broken-mcp" {
+			return source, nil, errors.New("registry unavailable")
+		}
+		if searchSpec.FullName() != weather || registryName != "default" {
+			return config.RegistrySourceConfig{}, nil, &server.ServerNotFoundError{Name: searchSpec.FullName(), VersionSpec: searchSpec.VersionSpec}
+		}
+
+		version := searchSpec.VersionSpec
+		if searchSpec.IsLatest() {
+			version = "2.0.0"
+		}
+		status, ok := statuses[version]
+		if !ok {
+			return config.RegistrySourceConfig{}, nil, &server.ServerNotFoundError{Name: weather, VersionSpec: version}
+		}
+
+		return source, &server.Spec{
+			SearchSpec: searchSpec,
+			JSON:       &v0.ServerJSON{Name: weather, Version: version},
+			Response:   &v0.ServerResponse{Meta: v0.ResponseMeta{Official: &v0.RegistryExtensions{Status: status}}},
+		}, nil
+	}
+
+	deployments := Deployments([]nomad.JobStub{
+		jobStub("current", weather, "2.0.0"),
+		jobStub("newer", weather, "2.1.0-beta.1"),
+		jobStub("outdated", weather, "1.2.0"),
+		jobStub("outdated-2", weather, "1.2.0"),
+		jobStub("deprecated", weather, "1.0.0"),
+		jobStub("deleted", weather, "1.1.0"),
+		jobStub("unknown-version", weather, "0.9.0"),
+		jobStub("unknown-server", "io.github.example/gone-mcp", "1.0.0"),
+		jobStub("registry-error", "io.github.example/broken-mcp", "1.0.0"),
+	})
+
+	if err := Check(context.Background(), deployments, find); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	expected := map[string]struct {
+		status     Status
+		latest     string
+		upgradable bool
+	}{
+		"current":         {StatusCurrent, "2.0.0", false},
+		"newer":           {StatusCurrent, "2.0.0", false},
+		"outdated":        {StatusOutdated, "2.0.0", true},
+		"outdated-2":      {StatusOutdated, "2.0.0", true},
+		"deprecated":      {StatusDeprecated, "2.0.0", true},
+		"deleted":         {StatusDeleted, "2.0.0", true},
+		"unknown-version": {StatusUnknown, "2.0.0", true},
+		"unknown-server":  {StatusUnknown, "", false},
+		"registry-error":  {StatusUnknown, "", false},
+	}
+
+	for _, d := range deployments {
+		want := expected[d.JobID]
+		if d.Status != want.status || d.LatestVersion != want.latest || d.Upgradable() != want.upgradable {
+			t.Errorf("%s: status = %s, latest = %q, upgradable = %v; expected %s, %q, %v",
+				d.JobID, d.Status, d.LatestVersion, d.Upgradable(), want.status, want.latest, want.upgradable)
+		}
+		if d.Status == StatusUnknown && d.Reason == "" {
+			t.Errorf("%s: unknown status has no reason", d.JobID)
+		}
+		if d.Upgradable() && d.Source.Name != source.Name {
+			t.Errorf("%s: source = %q, expected %q", d.JobID, d.Source.Name, source.Name)
+		}
+	}
+
+	// Each server version is looked up once: 7 weather versions, plus two of each other server
+	if lookups != 11 {
+		t.Errorf("Check() made %d registry lookups, expected 11", lookups)
+	}
+}
+
+func TestUpgradable(t *testing.T) {
+	tests := []struct {
+		version  string
+		latest   string
+		expected bool
+	}{
+		{version: "1.2.0", latest: "2.0.0", expected: true},
+		{version: "1.10.0", latest: "1.9.0", expected: false},
+		{version: "2.0.0", latest: "2.0.0", expected: false},
+		{version: "2.0.0-rc.1", latest: "2.0.0", expected: true},
+		{version: "2.1.0-beta.1", latest: "2.0.0", expected: false},
+		{version: "v1.0.0", latest: "1.1.0", expected: true},
+		{version: "2024-10-01", latest: "2024-11-01", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.latest, func(t *testing.T) {
+			d := &Deployment{Version: tt.version, LatestVersion: tt.latest, Latest: &server.Spec{}}
+			if got := d.Upgradable(); got != tt.expected {
+				t.Errorf("Upgradable() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Failure is the exit code for errors that don't request a specific exit code
+const Failure = 1
+
+// Error requests a specific process exit code. The command has already reported
+// its outcome, so the error is not printed.
+type Error struct {
+	Code int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// New returns an error requesting the given exit code
+func New(code int) error {
+	return &Error{Code: code}
+}
+
+// FromError returns the process exit code for err
+func FromError(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *Error
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return Failure
+}
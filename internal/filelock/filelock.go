@@ -0,0 +1,183 @@
+package filelock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// retryInterval is how often a held lock is checked while waiting for it
+const retryInterval = 500 * time.Millisecond
+
+// Owner identifies the process holding a lock
+type Owner struct {
+	PID       int       `json:"pid"`
+	Hostname  string    `json:"hostname"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (o Owner) String() string {
+	return fmt.Sprintf("pid %d on %s since %s", o.PID, o.Hostname, o.CreatedAt.Format(time.RFC3339))
+}
+
+// LockedError is returned when a lock is held by another process
+type LockedError struct {
+	Path  string
+	Owner Owner
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s is held by %s", e.Path, e.Owner)
+}
+
+// Lock is an advisory lock held by this process
+type Lock struct {
+	path string
+}
+
+// Path returns the lock file path for the file at path
+func Path(path string) string {
+	return path + ".lock"
+}
+
+// Acquire takes the advisory lock guarding the file at path, waiting up to
+// timeout for another process to release it. A lock left behind by a process
+// on this host that is no longer running is removed.
+func Acquire(ctx context.Context, path string, timeout time.Duration) (*Lock, error) {
+	lockPath := Path(path)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err := tryAcquire(lockPath)
+		if err == nil {
+			slog.Debug("lock acquired", "path", lockPath)
+			return &Lock{path: lockPath}, nil
+		}
+
+		var lockedErr *LockedError
+		if !errors.As(err, &lockedErr) || !time.Now().Before(deadline) {
+			return nil, err
+		}
+
+		slog.Debug("waiting for lock", "path", lockPath, "owner", lockedErr.Owner)
+
+		timer := time.NewTimer(min(retryInterval, time.Until(deadline)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Release removes the lock file
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file; %w", err)
+	}
+
+	slog.Debug("lock released", "path", l.path)
+
+	return nil
+}
+
+func tryAcquire(lockPath string) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to get hostname; %w", err)
+	}
+
+	data, err := json.Marshal(Owner{
+		PID:       os.Getpid(),
+		Hostname:  hostname,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock owner; %w", err)
+	}
+
+	// The owner is written to a temporary file and linked into place, so the lock
+	// file is created atomically and never read half written
+	tmp, err := os.CreateTemp(filepath.Dir(lockPath), filepath.Base(lockPath)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create lock file; %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, writeErr := tmp.Write(data)
+	if err := errors.Join(writeErr, tmp.Close()); err != nil {
+		return fmt.Errorf("failed to write lock file; %w", err)
+	}
+
+	err = os.Link(tmp.Name(), lockPath)
+	if err == nil {
+		return nil
+	}
+	if !os.IsExist(err) {
+		return fmt.Errorf("failed to create lock file; %w", err)
+	}
+
+	data, err = os.ReadFile(lockPath)
+	if os.IsNotExist(err) {
+		// Released since the link failed
+		return tryAcquire(lockPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read lock file; %w", err)
+	}
+
+	var owner Owner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return fmt.Errorf("failed to parse lock file %s, remove it if no other process is running; %w", lockPath, err)
+	}
+
+	// Only processes on this host can be checked, so locks held elsewhere are never considered stale
+	if owner.Hostname != hostname || processAlive(owner.PID) {
+		return &LockedError{Path: lockPath, Owner: owner}
+	}
+
+	slog.Warn("removing stale lock left by a process that is no longer running", "path", lockPath, "pid", owner.PID)
+	if err := removeStale(lockPath, data); err != nil {
+		return err
+	}
+
+	return tryAcquire(lockPath)
+}
+
+// removeStale removes the lock file if it still holds the stale owner's data. The file is
+// renamed to a unique name first, so when several processes find the same stale lock only
+// one of them removes it, and a lock taken in the meantime by another process is put back
+// rather than removed.
+func removeStale(lockPath string, stale []byte) error {
+	moved := fmt.Sprintf("%s.stale-%d-%d", lockPath, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(lockPath, moved); err != nil {
+		if os.IsNotExist(err) {
+			// Another process removed the stale lock first
+			return nil
+		}
+		return fmt.Errorf("failed to remove stale lock file; %w", err)
+	}
+	defer os.Remove(moved)
+
+	data, err := os.ReadFile(moved)
+	if err != nil {
+		return fmt.Errorf("failed to read stale lock file; %w", err)
+	}
+	if bytes.Equal(data, stale) {
+		return nil
+	}
+
+	// The stale lock was replaced by a live one before it was moved
+	if err := os.Link(moved, lockPath); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to restore lock file; %w", err)
+	}
+
+	return nil
+}
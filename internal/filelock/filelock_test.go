@@ -0,0 +1,159 @@
+package filelock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeLock(t *testing.T, path string, owner Owner) {
+	t.Helper()
+
+	data, err := json.Marshal(owner)
+	if err != nil {
+		t.Fatalf("failed to marshal owner: %v", err)
+	}
+	if err := os.WriteFile(Path(path), data, 0644); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+}
+
+func TestAcquireFailsFastWhenHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+
+	lock, err := Acquire(context.Background(), path, 0)
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error = %v", err)
+	}
+	defer lock.Release()
+
+	_, err = Acquire(context.Background(), path, 0)
+
+	var lockedErr *LockedError
+	if !errors.As(err, &lockedErr) {
+		t.Fatalf("Acquire() error = %v, expected *LockedError", err)
+	}
+	if lockedErr.Owner.PID != os.Getpid() {
+		t.Errorf("Acquire() owner pid = %d, expected %d", lockedErr.Owner.PID, os.Getpid())
+	}
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+
+	lock, err := Acquire(context.Background(), path, 0)
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error = %v", err)
+	}
+
+	time.AfterFunc(100*time.Millisecond, func() { lock.Release() })
+
+	second, err := Acquire(context.Background(), path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error = %v", err)
+	}
+	second.Release()
+}
+
+func TestAcquireRemovesStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+	hostname, _ := os.Hostname()
+
+	// A process that has exited leaves a PID that is no longer running
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("could not run a child process: %v", err)
+	}
+	writeLock(t, path, Owner{PID: cmd.Process.Pid, Hostname: hostname, CreatedAt: time.Now()})
+
+	lock, err := Acquire(context.Background(), path, 0)
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error = %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireKeepsLockFromOtherHost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+	writeLock(t, path, Owner{PID: 1, Hostname: "other-host.example", CreatedAt: time.Now()})
+
+	_, err := Acquire(context.Background(), path, 0)
+
+	var lockedErr *LockedError
+	if !errors.As(err, &lockedErr) {
+		t.Fatalf("Acquire() error = %v, expected *LockedError", err)
+	}
+	if lockedErr.Owner.Hostname != "other-host.example" {
+		t.Errorf("Acquire() owner hostname = %q, expected other-host.example", lockedErr.Owner.Hostname)
+	}
+}
+
+func TestAcquireStaleLockConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+	hostname, _ := os.Hostname()
+
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("could not run a child process: %v", err)
+	}
+
+	for range 20 {
+		writeLock(t, path, Owner{PID: cmd.Process.Pid, Hostname: hostname, CreatedAt: time.Now()})
+
+		// Only one of the processes finding the stale lock may take it
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var acquired []*Lock
+		for range 8 {
+			wg.Go(func() {
+				lock, err := Acquire(context.Background(), path, 0)
+				var lockedErr *LockedError
+				if err != nil && !errors.As(err, &lockedErr) {
+					t.Errorf("Acquire() unexpected error = %v", err)
+				}
+				if lock != nil {
+					mu.Lock()
+					acquired = append(acquired, lock)
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+
+		if len(acquired) != 1 {
+			t.Fatalf("Acquire() of a stale lock succeeded %d times, expected once", len(acquired))
+		}
+		acquired[0].Release()
+	}
+}
+
+func TestRemoveStaleKeepsReplacedLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+	live := Owner{PID: os.Getpid(), Hostname: "localhost", CreatedAt: time.Now()}
+	writeLock(t, path, live)
+
+	// The stale owner read earlier has since been replaced by a live lock
+	if err := removeStale(Path(path), []byte(`{"pid":1}`)); err != nil {
+		t.Fatalf("removeStale() unexpected error = %v", err)
+	}
+
+	data, err := os.ReadFile(Path(path))
+	if err != nil {
+		t.Fatalf("removeStale() removed the live lock; %v", err)
+	}
+	var owner Owner
+	if err := json.Unmarshal(data, &owner); err != nil || owner.PID != live.PID {
+		t.Errorf("lock owner = %+v, expected %+v", owner, live)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("removeStale() left %d files, expected only the lock", len(entries))
+	}
+}
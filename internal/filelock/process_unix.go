@@ -0,0 +1,20 @@
+//go:build unix
+
+package filelock
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive reports whether a process with the given PID is running on this host
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	// Signal 0 checks for the process without signalling it; EPERM means it exists
+	// but belongs to another user
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -72,7 +72,15 @@ func (g *Generator) createArchive(ctx context.Context, generateDir string) error
 		return err
 	})
 
+	if err == nil {
+		err = zipWriter.Close()
+	}
+	if err == nil {
+		err = archiveFile.Close()
+	}
 	if err != nil {
+		archiveFile.Close()
+		g.removePartial(ctx, archivePath)
 		return fmt.Errorf("failed to create archive; %w", err)
 	}
 
@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrPackDirectoryExists = errors.New("pack directory already exists")
 	ErrPackArchiveExists   = errors.New("pack archive already exists")
+	ErrJobspecExists       = errors.New("jobspec already exists")
 )
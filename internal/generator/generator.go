@@ -4,13 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
 	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
 	"github.com/modelcontextprotocol/registry/pkg/model"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Options struct {
@@ -18,6 +24,11 @@ type Options struct {
 	OutputType     string
 	DryRun         bool
 	ForceOverwrite bool
+	RegistryName   string                // Name of the registry the server came from, recorded in the pack
+	RegistryURL    string                // URL of the registry the server came from, recorded in the pack
+	Overrides      config.OverrideConfig // Replaces the generic defaults of the pack's variables and job
+	VarFiles       []string              // Var files setting pack variables when rendering a jobspec
+	Vars           []string              // Pack variables as name=value when rendering a jobspec
 }
 
 type Generator struct {
@@ -27,7 +38,7 @@ type Generator struct {
 	options  Options
 }
 
-func Run(ctx context.Context, srv *v0.ServerJSON, pkg *model.Package, opts Options) error {
+func Run(ctx context.Context, srv *v0.ServerJSON, pkg *model.Package, opts Options) (err error) {
 	if srv == nil {
 		return errors.New("invalid server; server cannot be nil")
 	}
@@ -38,6 +49,21 @@ func Run(ctx context.Context, srv *v0.ServerJSON, pkg *model.Package, opts Optio
 
 	packName := computePackName(srv.Name, srv.Version, pkg.RegistryType, pkg.Transport.Type)
 
+	// Generations run outside a command or poll get their own correlation ID
+	ctx = tracing.EnsureRequestID(ctx)
+	ctx, span := tracing.Start(ctx, "generate pack",
+		attribute.String("pack", packName),
+		attribute.String("server", srv.Name),
+		attribute.String("version", srv.Version),
+		attribute.String("package_type", pkg.RegistryType),
+		attribute.String("transport_type", pkg.Transport.Type),
+		attribute.String("output_type", opts.OutputType),
+		attribute.Bool("dry_run", opts.DryRun),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	start := time.Now()
+
 	// Show progress for pack generation
 	if !opts.DryRun {
 		output.Progress("Generating pack: %s@%s (%s, %s)", srv.Name, srv.Version, pkg.RegistryType, pkg.Transport.Type)
@@ -50,11 +76,12 @@ func Run(ctx context.Context, srv *v0.ServerJSON, pkg *model.Package, opts Optio
 		options:  opts,
 	}
 
-	err := generator.Generate(ctx)
-	if err != nil {
+	if err := generator.Generate(ctx); err != nil {
 		return err
 	}
 
+	slog.InfoContext(ctx, "pack generated", "pack", packName, "dry_run", opts.DryRun, "duration", time.Since(start))
+
 	// Show success for pack generation
 	if !opts.DryRun {
 		output.Success("Pack generated: %s@%s (%s, %s)", srv.Name, srv.Version, pkg.RegistryType, pkg.Transport.Type)
@@ -63,6 +90,38 @@ func Run(ctx context.Context, srv *v0.ServerJSON, pkg *model.Package, opts Optio
 	return nil
 }
 
+// PackName returns the name of the pack generated for the given server and package
+func PackName(srv *v0.ServerJSON, pkg *model.Package) string {
+	return computePackName(srv.Name, srv.Version, pkg.RegistryType, pkg.Transport.Type)
+}
+
+// PackNames returns the name of every pack that can be generated for a server version,
+// one for each package and transport type
+func PackNames(serverName, version string) []string {
+	var names []string
+	for _, packageType := range config.ValidPackageTypes {
+		for _, transportType := range config.ValidTransportTypes {
+			names = append(names, computePackName(serverName, version, packageType, utils.MapToRegistryTransportType(transportType)))
+		}
+	}
+	return names
+}
+
+// PackPath returns the path the pack for the given server and package is written to
+func PackPath(srv *v0.ServerJSON, pkg *model.Package, opts Options) string {
+	packName := PackName(srv, pkg)
+
+	if opts.OutputType == "archive" {
+		return filepath.Join(opts.OutputDir, packName+".zip")
+	}
+
+	if isJobspec(opts.OutputType) {
+		return jobspecPath(opts.OutputDir, packName, opts.OutputType)
+	}
+
+	return filepath.Join(opts.OutputDir, packName)
+}
+
 func (g *Generator) Generate(ctx context.Context) error {
 	if g.options.DryRun {
 		return g.dryRunGenerate(ctx)
@@ -70,6 +129,8 @@ func (g *Generator) Generate(ctx context.Context) error {
 
 	if g.options.OutputType == "archive" {
 		return g.generateArchive(ctx)
+	} else if isJobspec(g.options.OutputType) {
+		return g.generateJobspec(ctx)
 	} else {
 		return g.generatePackdir(ctx)
 	}
@@ -83,11 +144,11 @@ func (g *Generator) dryRunGenerate(ctx context.Context) error {
 	}
 
 	if g.options.OutputType == "archive" {
-		archivePath := filepath.Join(g.options.OutputDir, g.packName+".zip")
-		output.Info("Would create pack archive: %s", archivePath)
+		output.Info("Would create pack archive: %s", PackPath(g.server, g.pkg, g.options))
+	} else if isJobspec(g.options.OutputType) {
+		output.Info("Would create jobspec: %s", PackPath(g.server, g.pkg, g.options))
 	} else {
-		packDir := filepath.Join(g.options.OutputDir, g.packName)
-		output.Info("Would create pack directory: %s", packDir)
+		output.Info("Would create pack directory: %s", PackPath(g.server, g.pkg, g.options))
 	}
 
 	return nil
@@ -112,16 +173,26 @@ func (g *Generator) generatePackdir(ctx context.Context) error {
 
 	templatesDir := filepath.Join(generateDir, "templates")
 	if err := os.MkdirAll(templatesDir, 0755); err != nil {
+		g.removePartial(ctx, generateDir)
 		return fmt.Errorf("failed to create templates directory: %w", err)
 	}
 
 	if err := g.generateFiles(ctx, generateDir); err != nil {
+		g.removePartial(ctx, generateDir)
 		return err
 	}
 
 	return nil
 }
 
+// removePartial removes the output of a failed generation, so a later run regenerates
+// the pack rather than skipping it as already existing
+func (g *Generator) removePartial(ctx context.Context, path string) {
+	if err := os.RemoveAll(path); err != nil {
+		slog.WarnContext(ctx, "failed to remove partially generated pack", "path", path, "error", err)
+	}
+}
+
 func (g *Generator) generateArchive(ctx context.Context) error {
 	select {
 	case <-ctx.Done():
@@ -191,7 +262,7 @@ func (g *Generator) generateFiles(ctx context.Context, generateDir string) error
 }
 
 func (g *Generator) generateMetadata(ctx context.Context, generateDir string) error {
-	content, err := renderMetadataTemplate(g.server, g.pkg)
+	content, err := renderMetadataTemplate(g.server, g.pkg, g.options)
 	if err != nil {
 		return err
 	}
@@ -200,7 +271,7 @@ func (g *Generator) generateMetadata(ctx context.Context, generateDir string) er
 }
 
 func (g *Generator) generateVariables(ctx context.Context, generateDir string) error {
-	content, err := renderVariablesTemplate(g.server, g.pkg)
+	content, err := renderVariablesTemplate(g.server, g.pkg, g.options)
 	if err != nil {
 		return err
 	}
@@ -218,7 +289,7 @@ func (g *Generator) generateOutputs(ctx context.Context, generateDir string) err
 }
 
 func (g *Generator) generateReadme(ctx context.Context, generateDir string) error {
-	content, err := renderReadmeTemplate(g.server, g.pkg)
+	content, err := renderReadmeTemplate(g.server, g.pkg, g.options)
 	if err != nil {
 		return err
 	}
@@ -227,7 +298,7 @@ func (g *Generator) generateReadme(ctx context.Context, generateDir string) erro
 }
 
 func (g *Generator) generateJobTemplate(ctx context.Context, generateDir string) error {
-	content, err := renderJobTemplate(g.server, g.pkg)
+	content, err := renderJobTemplate(ctx, g.server, g.pkg, g.options)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,177 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/filelock"
+	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
+	"github.com/modelcontextprotocol/registry/pkg/model"
+)
+
+// cancelAfterContext is cancelled once Done has been checked the given number of times,
+// stopping generation part way through writing a pack
+type cancelAfterContext struct {
+	context.Context
+	mu     sync.Mutex
+	checks int
+	done   chan struct{}
+}
+
+func newCancelAfterContext(checks int) *cancelAfterContext {
+	return &cancelAfterContext{Context: context.Background(), checks: checks, done: make(chan struct{})}
+}
+
+func (c *cancelAfterContext) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks--
+	if c.checks == 0 {
+		close(c.done)
+	}
+	return c.done
+}
+
+func (c *cancelAfterContext) Err() error {
+	select {
+	case <-c.done:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+func TestRunRemovesPartialPack(t *testing.T) {
+	srv := &v0.ServerJSON{Name: "io.github.example/weather-mcp", Version: "1.2.0"}
+	pkg := &model.Package{RegistryType: "oci", Identifier: "ghcr.io/example/weather-mcp", Version: "1.2.0", Transport: model.Transport{Type: "stdio"}}
+
+	for _, outputType := range []string{"packdir", "archive"} {
+		t.Run(outputType, func(t *testing.T) {
+			opts := Options{OutputDir: t.TempDir(), OutputType: outputType}
+			path := PackPath(srv, pkg, opts)
+
+			// Generation stops after metadata.hcl is written
+			ctx := newCancelAfterContext(4)
+			if err := Run(ctx, srv, pkg, opts); !errors.Is(err, context.Canceled) {
+				t.Fatalf("Run() error = %v, expected context.Canceled", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("Run() left a partial pack at %s", path)
+			}
+
+			// A retry generates the pack rather than skipping it as existing
+			if err := Run(context.Background(), srv, pkg, opts); err != nil {
+				t.Fatalf("Run() retry error = %v", err)
+			}
+			if outputType == "packdir" {
+				if _, err := os.Stat(filepath.Join(path, "templates", "_helpers.tpl")); err != nil {
+					t.Errorf("Run() retry did not write a complete pack; %v", err)
+				}
+			} else if _, err := os.Stat(path); err != nil {
+				t.Errorf("Run() retry did not write the archive; %v", err)
+			}
+		})
+	}
+}
+
+func TestLockOutputDir(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "packs")
+
+	lock, err := LockOutputDir(context.Background(), outputDir, 0)
+	if err != nil {
+		t.Fatalf("LockOutputDir() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, ".nomad-mcp-pack.lock")); err != nil {
+		t.Errorf("LockOutputDir() did not create the lock file in the output directory; %v", err)
+	}
+
+	var lockedErr *filelock.LockedError
+	if _, err := LockOutputDir(context.Background(), outputDir, 0); !errors.As(err, &lockedErr) {
+		t.Errorf("LockOutputDir() error = %v, expected *filelock.LockedError while held", err)
+	}
+
+	lock.Release()
+	second, err := LockOutputDir(context.Background(), outputDir, 0)
+	if err != nil {
+		t.Fatalf("LockOutputDir() after release error = %v", err)
+	}
+	second.Release()
+}
+
+func TestGenerateJobspec(t *testing.T) {
+	stubNPMRegistry(t)
+
+	srv := &v0.ServerJSON{Name: "io.github.example/weather-mcp", Version: "1.2.0"}
+
+	for _, packageType := range []string{"oci", "npm", "pypi", "nuget"} {
+		t.Run(packageType, func(t *testing.T) {
+			outputDir := t.TempDir()
+			pkg := &model.Package{
+				RegistryType: packageType,
+				Identifier:   "weather-mcp",
+				Version:      "1.2.0",
+				Transport:    model.Transport{Type: "stdio"},
+				EnvironmentVariables: []model.KeyValueInput{
+					{Name: "API_KEY", InputWithVariables: model.InputWithVariables{Input: model.Input{IsRequired: true, IsSecret: true}}},
+				},
+			}
+
+			opts := Options{OutputDir: outputDir, OutputType: "jobspec-json", Vars: []string{"count=2"}}
+			if err := Run(context.Background(), srv, pkg, opts); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			data, err := os.ReadFile(PackPath(srv, pkg, opts))
+			if err != nil {
+				t.Fatalf("jobspec was not written; %v", err)
+			}
+
+			var jobspec struct {
+				Job struct {
+					ID         string
+					Meta       map[string]string
+					TaskGroups []struct{ Count int }
+				}
+			}
+			if err := json.Unmarshal(data, &jobspec); err != nil {
+				t.Fatalf("jobspec is not valid JSON; %v", err)
+			}
+			if jobspec.Job.ID != PackName(srv, pkg) || jobspec.Job.Meta[JobMetaServer] != srv.Name {
+				t.Errorf("jobspec job = %q with meta %v", jobspec.Job.ID, jobspec.Job.Meta)
+			}
+			if len(jobspec.Job.TaskGroups) != 1 || jobspec.Job.TaskGroups[0].Count != 2 {
+				t.Errorf("jobspec task groups = %+v, expected one with count 2", jobspec.Job.TaskGroups)
+			}
+
+			if err := Run(context.Background(), srv, pkg, opts); !errors.Is(err, ErrJobspecExists) {
+				t.Errorf("Run() error = %v, expected ErrJobspecExists", err)
+			}
+		})
+	}
+}
+
+// stubNPMRegistry serves package metadata with a bin entry for every NPM package, so
+// generating NPM packs doesn't depend on the public registry
+func stubNPMRegistry(t *testing.T) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, version, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(NPMPackageInfo{Name: name, Version: version, Bin: map[string]any{name: "index.js"}})
+	}))
+	t.Cleanup(srv.Close)
+
+	previous := npmRegistryURL
+	npmRegistryURL = srv.URL
+	t.Cleanup(func() { npmRegistryURL = previous })
+}
@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/packrender"
+)
+
+// isJobspec reports whether the output type writes a rendered jobspec instead of a pack
+func isJobspec(outputType string) bool {
+	return outputType == string(config.OutputTypeJobspec) || outputType == string(config.OutputTypeJobspecJSON)
+}
+
+// jobspecPath returns the path of the jobspec rendered for a pack
+func jobspecPath(outputDir, packName, outputType string) string {
+	if outputType == string(config.OutputTypeJobspecJSON) {
+		return filepath.Join(outputDir, packName+".nomad.json")
+	}
+	return filepath.Join(outputDir, packName+".nomad.hcl")
+}
+
+// generateJobspec generates the pack in a temporary directory and renders its job template
+// with the pack's defaults and the supplied variables, writing a standalone jobspec
+func (g *Generator) generateJobspec(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	jobspecFile := jobspecPath(g.options.OutputDir, g.packName, g.options.OutputType)
+
+	if _, err := os.Stat(jobspecFile); err == nil && !g.options.ForceOverwrite {
+		return fmt.Errorf("jobspec %s already exists: %w", jobspecFile, ErrJobspecExists)
+	}
+
+	tempDir, err := os.MkdirTemp("", "nomad-mcp-pack-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	generateDir := filepath.Join(tempDir, g.packName)
+	if err := os.MkdirAll(filepath.Join(generateDir, "templates"), 0755); err != nil {
+		return fmt.Errorf("failed to create templates directory: %w", err)
+	}
+
+	if err := g.generateFiles(ctx, generateDir); err != nil {
+		return err
+	}
+
+	pack, err := packrender.Load(os.DirFS(generateDir), g.packName)
+	if err != nil {
+		return fmt.Errorf("failed to load pack; %w", err)
+	}
+
+	values, err := pack.Values(g.options.VarFiles, g.options.Vars)
+	if err != nil {
+		return fmt.Errorf("failed to resolve pack variables; %w", err)
+	}
+
+	rendered, err := pack.Render(values)
+	if err != nil {
+		return fmt.Errorf("failed to render pack; %w", err)
+	}
+	if len(rendered.Jobs) != 1 {
+		return fmt.Errorf("failed to render pack; expected one job template, found %d", len(rendered.Jobs))
+	}
+
+	content := []byte(rendered.Jobs[0].Content)
+	if g.options.OutputType == string(config.OutputTypeJobspecJSON) {
+		if content, err = rendered.Jobs[0].JSON(); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(g.options.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if err := os.WriteFile(jobspecFile, content, 0644); err != nil {
+		return fmt.Errorf("failed to write jobspec %s: %w", jobspecFile, err)
+	}
+
+	return nil
+}
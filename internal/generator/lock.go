@@ -0,0 +1,29 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/filelock"
+)
+
+// outputLockName is the file in the output directory whose lock guards generation into it
+const outputLockName = ".nomad-mcp-pack"
+
+// LockOutputDir takes the lock guarding generation into the output directory, waiting up
+// to timeout for another generate or watch process to finish writing packs to it
+func LockOutputDir(ctx context.Context, outputDir string, timeout time.Duration) (*filelock.Lock, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	lock, err := filelock.Acquire(ctx, filepath.Join(outputDir, outputLockName), timeout)
+	if err != nil {
+		return nil, fmt.Errorf("could not lock output directory %s: %w", outputDir, err)
+	}
+
+	return lock, nil
+}
@@ -1,12 +1,15 @@
 package generator
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/leefowlercu/nomad-mcp-pack/internal/metrics"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
 	"github.com/modelcontextprotocol/registry/pkg/model"
 )
 
@@ -35,15 +38,30 @@ type NPMExecutionData struct {
 	ScriptPath string // The script path (for node-direct pattern)
 }
 
+// npmRegistryURL is the NPM registry package metadata is fetched from, replaced in tests
+var npmRegistryURL = "https://registry.npmjs.org"
+
 // fetchNPMPackageInfo retrieves package metadata from the NPM registry
-func fetchNPMPackageInfo(packageID, version string) (*NPMPackageInfo, error) {
-	url := fmt.Sprintf("https://registry.npmjs.org/%s/%s", packageID, version)
+func fetchNPMPackageInfo(ctx context.Context, packageID, version string) (*NPMPackageInfo, error) {
+	url := fmt.Sprintf("%s/%s/%s", npmRegistryURL, packageID, version)
 
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout:   10 * time.Second,
+		Transport: tracing.Transport(nil),
+	}
+
+	start := time.Now()
+	result := metrics.ResultFailure
+	defer func() {
+		metrics.NPMLookupDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NPM package info request: %w", err)
 	}
 
-	resp, err := client.Get(url)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch NPM package info: %w", err)
 	}
@@ -58,6 +76,8 @@ func fetchNPMPackageInfo(packageID, version string) (*NPMPackageInfo, error) {
 		return nil, fmt.Errorf("failed to decode NPM package info: %w", err)
 	}
 
+	result = metrics.ResultSuccess
+
 	return &info, nil
 }
 
@@ -85,11 +105,11 @@ func parseBinField(bin any, packageName string) (string, bool) {
 }
 
 // resolveNPMExecutionPattern determines how to execute an NPM package
-func resolveNPMExecutionPattern(pkg *model.Package) (*NPMExecutionData, error) {
+func resolveNPMExecutionPattern(ctx context.Context, pkg *model.Package) (*NPMExecutionData, error) {
 	data := &NPMExecutionData{}
 
 	// Try to fetch package info from NPM registry
-	npmInfo, err := fetchNPMPackageInfo(pkg.Identifier, pkg.Version)
+	npmInfo, err := fetchNPMPackageInfo(ctx, pkg.Identifier, pkg.Version)
 
 	if err == nil && npmInfo != nil {
 		// Check if package has bin entry
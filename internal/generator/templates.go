@@ -2,13 +2,21 @@ package generator
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"embed"
 	"fmt"
 	"log/slog"
+	"maps"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
 	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
 	"github.com/modelcontextprotocol/registry/pkg/model"
 )
@@ -34,6 +42,7 @@ func init() {
 		"replace":   strings.ReplaceAll,
 		"base":      filepath.Base,
 		"hasSuffix": strings.HasSuffix,
+		"hclList":   hclList,
 	}
 
 	metadataTemplate, err = template.New("metadata.hcl.tmpl").Funcs(funcMap).ParseFS(templateFS, "templates/metadata.hcl.tmpl")
@@ -81,20 +90,67 @@ type MetadataData struct {
 	PackVersion     string
 	AppURL          string
 	ServerName      string
+	RegistryName    string
+	RegistryURL     string
+}
+
+// Pack defaults used unless overridden for the server
+const (
+	defaultCount  = 1
+	defaultCPU    = 500
+	defaultMemory = 512
+)
+
+var defaultDatacenters = []string{"dc1"}
+
+// Job meta keys recording the provenance of a generated job, so operators and tooling can
+// identify MCP workloads and compare deployed jobs with the registry
+const (
+	JobMetaServer            = "nomad_mcp_pack_server"
+	JobMetaServerVersion     = "nomad_mcp_pack_server_version"
+	JobMetaPackageType       = "nomad_mcp_pack_package_type"
+	JobMetaPackageIdentifier = "nomad_mcp_pack_package_identifier"
+	JobMetaPackageVersion    = "nomad_mcp_pack_package_version"
+	JobMetaTransportType     = "nomad_mcp_pack_transport_type"
+	JobMetaRegistry          = "nomad_mcp_pack_registry"
+	JobMetaRegistryURL       = "nomad_mcp_pack_registry_url"
+	JobMetaRegistryEntryID   = "nomad_mcp_pack_registry_entry_id"
+	JobMetaGeneratedAt       = "nomad_mcp_pack_generated_at"
+	JobMetaGeneratorVersion  = "nomad_mcp_pack_generator_version"
+)
+
+// Version is the nomad-mcp-pack version recorded in generated jobs
+var Version = "dev"
+
+// secretsPathPrefix is the Nomad Variables path prefix a job's tasks can read without an ACL
+// policy. Packs read their secret environment variables from the variable at the prefix and the job ID.
+const secretsPathPrefix = "nomad/jobs/"
+
+// secretsDestination is the env file the secrets template renders in the task's secrets directory
+const secretsDestination = "secrets/mcp-server.env"
+
+// SecretsPath returns the Nomad Variables path a generated job reads its secrets from
+func SecretsPath(jobID string) string {
+	return secretsPathPrefix + jobID
 }
 
 type VariablesData struct {
-	ServerName            string
-	PackageType           string
-	HasEnvironment        bool
-	Environment           []model.KeyValueInput
-	HasArguments          bool
-	Arguments             []model.Argument
-	PackageID             string
-	PackageVersion        string
-	InferredServiceName   string
-	InferredContainerPort int
-	IsHTTPTransport       bool
+	ServerName          string
+	PackageType         string
+	HasEnvironment      bool
+	Environment         []model.KeyValueInput
+	HasArguments        bool
+	Arguments           []model.Argument
+	PackageID           string
+	PackageVersion      string
+	InferredServiceName string
+	ContainerPort       int
+	IsHTTPTransport     bool
+	Datacenters         []string
+	Count               int
+	CPU                 int
+	Memory              int
+	ServiceTags         []string
 }
 
 type JobData struct {
@@ -113,6 +169,11 @@ type JobData struct {
 	NPMExecution          *NPMExecutionData // Add NPM execution pattern info
 	InferredServiceName   string
 	InferredContainerPort int
+	Driver                string
+	JobMeta               map[string]string     // Job meta identifying the server version, keyed by the JobMeta* constants
+	Secrets               []model.KeyValueInput // Secret environment variables, read from Nomad Variables
+	SecretsTemplate       string                // Consul template rendering the secrets as an env file
+	SecretsDestination    string
 }
 
 type ReadmeData struct {
@@ -123,15 +184,20 @@ type ReadmeData struct {
 	Version             string
 	RepositoryURL       string
 	HasRepository       bool
+	RegistryName        string
+	RegistryURL         string
 	InferredServiceName string
 	IsHTTPTransport     bool
 	ContainerPort       int
+	Secrets             []model.KeyValueInput
+	SecretsPath         string
 }
 
-func renderMetadataTemplate(server *v0.ServerJSON, pkg *model.Package) (string, error) {
+func renderMetadataTemplate(server *v0.ServerJSON, pkg *model.Package, opts Options) (string, error) {
 	packName := computePackName(server.Name, server.Version, pkg.RegistryType, pkg.Transport.Type)
 
-	appURL := ""
+	// Fall back to the registry the server came from when it has no repository
+	appURL := strings.TrimSuffix(opts.RegistryURL, "/")
 	if server.Repository.URL != "" {
 		appURL = server.Repository.URL
 	}
@@ -142,6 +208,8 @@ func renderMetadataTemplate(server *v0.ServerJSON, pkg *model.Package) (string,
 		PackVersion:     server.Version,
 		AppURL:          appURL,
 		ServerName:      server.Name,
+		RegistryName:    opts.RegistryName,
+		RegistryURL:     opts.RegistryURL,
 	}
 
 	var buf bytes.Buffer
@@ -152,7 +220,7 @@ func renderMetadataTemplate(server *v0.ServerJSON, pkg *model.Package) (string,
 	return buf.String(), nil
 }
 
-func renderVariablesTemplate(server *v0.ServerJSON, pkg *model.Package) (string, error) {
+func renderVariablesTemplate(server *v0.ServerJSON, pkg *model.Package, opts Options) (string, error) {
 	// Only include named arguments as variables (positional args don't have names)
 	var validArgs []model.Argument
 	for _, arg := range append(pkg.RuntimeArguments, pkg.PackageArguments...) {
@@ -161,18 +229,28 @@ func renderVariablesTemplate(server *v0.ServerJSON, pkg *model.Package) (string,
 		}
 	}
 
+	environment, _ := splitSecrets(overrideEnvironment(pkg.EnvironmentVariables, opts.Overrides.Env))
+
 	data := VariablesData{
-		ServerName:            server.Name,
-		PackageType:           pkg.RegistryType,
-		HasEnvironment:        len(pkg.EnvironmentVariables) > 0,
-		Environment:           pkg.EnvironmentVariables,
-		HasArguments:          len(validArgs) > 0,
-		Arguments:             validArgs,
-		PackageID:             pkg.Identifier,
-		PackageVersion:        pkg.Version,
-		InferredServiceName:   inferServiceName(server.Name),
-		InferredContainerPort: inferContainerPort(pkg),
-		IsHTTPTransport:       isHTTPTransport(pkg.Transport),
+		ServerName:          server.Name,
+		PackageType:         pkg.RegistryType,
+		HasEnvironment:      len(environment) > 0,
+		Environment:         environment,
+		HasArguments:        len(validArgs) > 0,
+		Arguments:           validArgs,
+		PackageID:           pkg.Identifier,
+		PackageVersion:      pkg.Version,
+		InferredServiceName: inferServiceName(server.Name),
+		ContainerPort:       containerPort(pkg, opts.Overrides),
+		IsHTTPTransport:     isHTTPTransport(pkg.Transport),
+		Datacenters:         defaultDatacenters,
+		Count:               cmp.Or(opts.Overrides.Count, defaultCount),
+		CPU:                 cmp.Or(opts.Overrides.CPU, defaultCPU),
+		Memory:              cmp.Or(opts.Overrides.Memory, defaultMemory),
+		ServiceTags:         opts.Overrides.ServiceTags,
+	}
+	if len(opts.Overrides.Datacenters) > 0 {
+		data.Datacenters = opts.Overrides.Datacenters
 	}
 
 	var buf bytes.Buffer
@@ -183,7 +261,7 @@ func renderVariablesTemplate(server *v0.ServerJSON, pkg *model.Package) (string,
 	return buf.String(), nil
 }
 
-func renderJobTemplate(server *v0.ServerJSON, pkg *model.Package) (string, error) {
+func renderJobTemplate(ctx context.Context, server *v0.ServerJSON, pkg *model.Package, opts Options) (string, error) {
 	tmpl, exists := jobTemplates[pkg.RegistryType]
 	if !exists {
 		return "", fmt.Errorf("no job template found for package type; %s", pkg.RegistryType)
@@ -206,14 +284,16 @@ func renderJobTemplate(server *v0.ServerJSON, pkg *model.Package) (string, error
 	// For NPM packages, resolve execution pattern
 	var npmExecution *NPMExecutionData
 	if pkg.RegistryType == "npm" {
-		npmData, err := resolveNPMExecutionPattern(pkg)
+		npmData, err := resolveNPMExecutionPattern(ctx, pkg)
 		if err != nil {
 			// Log warning but continue with defaults
-			slog.Warn("failed to resolve NPM execution pattern", "error", err, "package", pkg.Identifier)
+			slog.WarnContext(ctx, "failed to resolve NPM execution pattern", "error", err, "package", pkg.Identifier)
 		}
 		npmExecution = npmData
 	}
 
+	environment, secrets := splitSecrets(overrideEnvironment(pkg.EnvironmentVariables, opts.Overrides.Env))
+
 	data := JobData{
 		ServerName:            server.Name,
 		TaskName:              sanitizeServerName(server.Name),
@@ -222,14 +302,19 @@ func renderJobTemplate(server *v0.ServerJSON, pkg *model.Package) (string, error
 		PackageVersion:        pkg.Version,
 		RegistryURL:           registryURL,
 		RunTimeHint:           pkg.RunTimeHint,
-		Environment:           pkg.EnvironmentVariables,
+		Environment:           environment,
 		RuntimeArgs:           pkg.RuntimeArguments,
 		PackageArgs:           pkg.PackageArguments,
 		Transport:             pkg.Transport,
 		HasTransport:          pkg.Transport.Type != "",
 		NPMExecution:          npmExecution,
 		InferredServiceName:   inferServiceName(server.Name),
-		InferredContainerPort: inferContainerPort(pkg),
+		InferredContainerPort: containerPort(pkg, opts.Overrides),
+		Driver:                cmp.Or(opts.Overrides.Driver, defaultDriver(pkg.RegistryType)),
+		JobMeta:               jobMeta(server, pkg, opts),
+		Secrets:               secrets,
+		SecretsTemplate:       secretsTemplate(secrets),
+		SecretsDestination:    secretsDestination,
 	}
 
 	var buf bytes.Buffer
@@ -253,7 +338,7 @@ func renderOutputsTemplate(server *v0.ServerJSON) (string, error) {
 	return buf.String(), nil
 }
 
-func renderReadmeTemplate(server *v0.ServerJSON, pkg *model.Package) (string, error) {
+func renderReadmeTemplate(server *v0.ServerJSON, pkg *model.Package, opts Options) (string, error) {
 	data := ReadmeData{
 		ServerName:          server.Name,
 		Description:         server.Description,
@@ -262,10 +347,14 @@ func renderReadmeTemplate(server *v0.ServerJSON, pkg *model.Package) (string, er
 		Version:             server.Version,
 		RepositoryURL:       server.Repository.URL,
 		HasRepository:       server.Repository.URL != "",
+		RegistryName:        opts.RegistryName,
+		RegistryURL:         opts.RegistryURL,
 		InferredServiceName: inferServiceName(server.Name),
 		IsHTTPTransport:     isHTTPTransport(pkg.Transport),
-		ContainerPort:       inferContainerPort(pkg),
+		ContainerPort:       containerPort(pkg, opts.Overrides),
+		SecretsPath:         SecretsPath("<job>"),
 	}
+	_, data.Secrets = splitSecrets(overrideEnvironment(pkg.EnvironmentVariables, opts.Overrides.Env))
 
 	var buf bytes.Buffer
 	if err := readmeTemplate.Execute(&buf, data); err != nil {
@@ -284,6 +373,26 @@ func renderHelpersTemplate() (string, error) {
 	return buf.String(), nil
 }
 
+// jobMeta returns the job meta recording where the job came from, leaving out values the
+// server or registry doesn't provide. The registry identifies an entry by server name and version.
+func jobMeta(server *v0.ServerJSON, pkg *model.Package, opts Options) map[string]string {
+	meta := map[string]string{
+		JobMetaServer:            server.Name,
+		JobMetaServerVersion:     server.Version,
+		JobMetaPackageType:       pkg.RegistryType,
+		JobMetaPackageIdentifier: pkg.Identifier,
+		JobMetaPackageVersion:    pkg.Version,
+		JobMetaTransportType:     utils.MapFromRegistryTransportType(pkg.Transport.Type),
+		JobMetaRegistry:          opts.RegistryName,
+		JobMetaRegistryURL:       opts.RegistryURL,
+		JobMetaRegistryEntryID:   server.Name + "@" + server.Version,
+		JobMetaGeneratedAt:       time.Now().UTC().Format(time.RFC3339),
+		JobMetaGeneratorVersion:  Version,
+	}
+	maps.DeleteFunc(meta, func(_, value string) bool { return value == "" })
+	return meta
+}
+
 // Helper functions for templates
 func formatEnvironmentVars(envVars []model.KeyValueInput) string {
 	if len(envVars) == 0 {
@@ -367,6 +476,94 @@ func inferContainerPort(pkg *model.Package) int {
 	}
 }
 
+// containerPort returns the overridden container port, or the port inferred from package metadata
+func containerPort(pkg *model.Package, overrides config.OverrideConfig) int {
+	if overrides.ContainerPort > 0 {
+		return overrides.ContainerPort
+	}
+
+	return inferContainerPort(pkg)
+}
+
+// defaultDriver returns the task driver used by the job template for the package type
+func defaultDriver(packageType string) string {
+	switch packageType {
+	case "pypi", "nuget":
+		return "exec"
+	default:
+		return "docker"
+	}
+}
+
+// overrideEnvironment sets the defaults of the package's environment variables to the
+// overridden values, adding overridden variables the package doesn't declare
+func overrideEnvironment(envVars []model.KeyValueInput, overrides []config.EnvVarConfig) []model.KeyValueInput {
+	if len(overrides) == 0 {
+		return envVars
+	}
+
+	environment := slices.Clone(envVars)
+	for _, override := range overrides {
+		i := slices.IndexFunc(environment, func(env model.KeyValueInput) bool { return env.Name == override.Name })
+		if i < 0 {
+			env := model.KeyValueInput{Name: override.Name}
+			env.Description = "Set by a nomad-mcp-pack override"
+			environment = append(environment, env)
+			i = len(environment) - 1
+		}
+		environment[i].Default = override.Value
+	}
+
+	return environment
+}
+
+// splitSecrets separates the secret environment variables, which packs read from Nomad
+// Variables, from those set by pack variables
+func splitSecrets(envVars []model.KeyValueInput) (environment, secrets []model.KeyValueInput) {
+	for _, env := range envVars {
+		if env.IsSecret {
+			secrets = append(secrets, env)
+		} else {
+			environment = append(environment, env)
+		}
+	}
+
+	return environment, secrets
+}
+
+// secretsTemplate returns the consul template rendering the items of the job's secrets
+// variable as an env file. When a secret is required the template waits for the variable
+// to be written, otherwise a missing variable renders nothing.
+func secretsTemplate(secrets []model.KeyValueInput) string {
+	if len(secrets) == 0 {
+		return ""
+	}
+
+	path := `[[ template "secrets_path" . ]]`
+	lines := []string{
+		fmt.Sprintf(`{{- with nomadVar "%s" }}`, path),
+		`{{- range $key, $value := . }}`,
+		`{{ $key }}={{ $value }}`,
+		`{{- end }}`,
+		`{{- end }}`,
+	}
+	if !slices.ContainsFunc(secrets, func(env model.KeyValueInput) bool { return env.IsRequired }) {
+		lines = slices.Concat([]string{fmt.Sprintf(`{{- if nomadVarExists "%s" }}`, path)}, lines, []string{`{{- end }}`})
+	}
+
+	return strings.Join(lines, "\n        ")
+}
+
+// hclList renders strings as an HCL list literal
+func hclList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
 // isHTTPTransport checks if the transport type requires HTTP networking
 func isHTTPTransport(transport model.Transport) bool {
 	return transport.Type == "streamable-http" || transport.Type == "sse"
@@ -0,0 +1,268 @@
+package gitrepo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+)
+
+// Entry describes a generated pack to be committed to the repository
+type Entry struct {
+	ServerName    string
+	ServerVersion string
+	PackageType   string
+	TransportType string
+	PackName      string
+	PackPath      string
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s@%s (%s, %s)", e.ServerName, e.ServerVersion, e.PackageType, e.TransportType)
+}
+
+// Repo commits generated packs to the git repository rooted at the output directory, or
+// at the configured repository directory containing it
+type Repo struct {
+	dir    string
+	branch string
+	config config.GitConfig
+	mu     sync.Mutex
+}
+
+// Open prepares the output directory for git-backed output. The repository must be
+// rooted at the output directory, or at git.repo_dir when set, so packs are never
+// committed to an enclosing repository like the project the output directory sits in.
+// A new repository is initialized there when it isn't the root of a working tree.
+func Open(ctx context.Context, outputDir string, cfg config.GitConfig) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git executable not found; %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	dir := outputDir
+	if cfg.RepoDir != "" {
+		dir = cfg.RepoDir
+		if rel, err := relativePath(dir, outputDir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("output directory %s is not inside git repository directory %s", outputDir, dir)
+		}
+	}
+
+	r := &Repo{
+		dir:    dir,
+		config: cfg,
+	}
+
+	root, err := r.isRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !root {
+		slog.Info("directory is not the root of a git working tree, initializing repository", "dir", dir)
+		if _, err := r.git(ctx, "init"); err != nil {
+			return nil, fmt.Errorf("failed to initialize git repository; %w", err)
+		}
+	}
+
+	if cfg.Branch != "" {
+		if err := r.checkoutBranch(ctx, cfg.Branch); err != nil {
+			return nil, err
+		}
+	}
+
+	// Pushes name the branch packs are committed to, rather than whatever HEAD is later
+	r.branch = cfg.Branch
+	if r.branch == "" {
+		if r.branch, err = r.git(ctx, "symbolic-ref", "--short", "HEAD"); err != nil {
+			return nil, fmt.Errorf("failed to determine the current branch; %w", err)
+		}
+	}
+
+	return r, nil
+}
+
+// isRoot reports whether the repository directory is the top level of a git working tree
+func (r *Repo) isRoot(ctx context.Context) (bool, error) {
+	top, err := r.git(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return false, nil
+	}
+
+	dir, err := filepath.Abs(r.dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve git repository directory; %w", err)
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return false, fmt.Errorf("failed to resolve git repository directory; %w", err)
+	}
+	if top, err = filepath.EvalSymlinks(top); err != nil {
+		return false, fmt.Errorf("failed to resolve git working tree; %w", err)
+	}
+
+	return dir == top, nil
+}
+
+func (r *Repo) checkoutBranch(ctx context.Context, branch string) error {
+	if current, err := r.git(ctx, "symbolic-ref", "--short", "HEAD"); err == nil && current == branch {
+		return nil
+	}
+
+	args := []string{"checkout", branch}
+	if _, err := r.git(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		args = []string{"checkout", "-b", branch}
+	}
+
+	if _, err := r.git(ctx, args...); err != nil {
+		return fmt.Errorf("failed to check out branch %q; %w", branch, err)
+	}
+
+	return nil
+}
+
+// Commit stages the given packs, commits them with a message listing the generated
+// servers, tags each pack if enabled, and pushes if enabled. Committing packs again
+// after a failed tag or push makes no new commit but retries the tags and push.
+func (r *Repo) Commit(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Only the packs are staged, checked and committed, leaving anything else in the index alone
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		path, err := relativePath(r.dir, e.PackPath)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+	if _, err := r.git(ctx, append([]string{"add", "--all", "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to stage packs; %w", err)
+	}
+
+	// Regenerated packs, or packs retried after a failed tag or push, may already be committed
+	if _, err := r.git(ctx, append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...); err == nil {
+		slog.Info("no pack changes to commit", "packs", len(entries))
+	} else {
+		if _, err := r.git(ctx, append([]string{"commit", "--quiet", "--message", commitMessage(entries), "--"}, paths...)...); err != nil {
+			return fmt.Errorf("failed to commit packs; %w", err)
+		}
+
+		slog.Info("committed packs", "packs", len(entries))
+	}
+
+	if r.config.Tag {
+		for _, e := range entries {
+			if _, err := r.git(ctx, "tag", "--force", "--annotate", e.PackName, "--message", "Pack "+e.String()); err != nil {
+				return fmt.Errorf("failed to tag pack %s; %w", e.PackName, err)
+			}
+		}
+	}
+
+	if r.config.Push {
+		if err := r.push(ctx, entries); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Repo) push(ctx context.Context, entries []Entry) error {
+	remote := r.config.Remote
+	if remote == "" {
+		remote = config.DefaultConfig.GitRemote
+	}
+
+	if _, err := r.git(ctx, "push", remote, "refs/heads/"+r.branch); err != nil {
+		return fmt.Errorf("failed to push to remote %q; %w", remote, err)
+	}
+
+	if r.config.Tag {
+		args := []string{"push", "--force", remote}
+		for _, e := range entries {
+			args = append(args, "refs/tags/"+e.PackName)
+		}
+		if _, err := r.git(ctx, args...); err != nil {
+			return fmt.Errorf("failed to push tags to remote %q; %w", remote, err)
+		}
+	}
+
+	slog.Info("pushed packs", "remote", remote, "packs", len(entries))
+
+	return nil
+}
+
+// relativePath resolves a path against the directory git is run in
+func relativePath(dir, path string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve git repository directory; %w", err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path; %w", err)
+	}
+
+	return filepath.Rel(absDir, absPath)
+}
+
+func (r *Repo) git(ctx context.Context, args ...string) (string, error) {
+	gitArgs := []string{"-C", r.dir}
+	// The configured identity is used for both the author and committer of pack commits
+	if r.config.AuthorName != "" {
+		gitArgs = append(gitArgs, "-c", "user.name="+r.config.AuthorName)
+	}
+	if r.config.AuthorEmail != "" {
+		gitArgs = append(gitArgs, "-c", "user.email="+r.config.AuthorEmail)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append(gitArgs, args...)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func commitMessage(entries []Entry) string {
+	var b strings.Builder
+
+	if len(entries) == 1 {
+		fmt.Fprintf(&b, "Add pack for %s\n\n", entries[0])
+	} else {
+		fmt.Fprintf(&b, "Add packs for %d MCP Servers\n\n", len(entries))
+	}
+
+	for _, e := range entries {
+		fmt.Fprintf(&b, "- %s: %s\n", e, e.PackName)
+	}
+
+	b.WriteString("\nGenerated-By: nomad-mcp-pack\n")
+
+	return b.String()
+}
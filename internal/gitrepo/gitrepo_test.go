@@ -0,0 +1,181 @@
+package gitrepo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+)
+
+var testConfig = config.GitConfig{AuthorName: "Test", AuthorEmail: "test@example.com"}
+
+// run runs git in dir for test setup and assertions
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	args = append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// writePack writes a pack directory with a metadata file
+func writePack(t *testing.T, outputDir, name, content string) Entry {
+	t.Helper()
+
+	dir := filepath.Join(outputDir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "metadata.hcl"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return Entry{ServerName: "io.github.example/" + name, ServerVersion: "1.0.0", PackageType: "oci", TransportType: "http", PackName: name, PackPath: dir}
+}
+
+func TestOpenInsideEnclosingRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	project := t.TempDir()
+	run(t, project, "init", "--quiet")
+	if err := os.WriteFile(filepath.Join(project, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run(t, project, "add", "main.go")
+
+	outputDir := filepath.Join(project, "packs")
+	repo, err := Open(context.Background(), outputDir, testConfig)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if top := run(t, outputDir, "rev-parse", "--show-toplevel"); filepath.Base(top) != "packs" {
+		t.Errorf("Open() used the repository at %s, expected a new one in the output directory", top)
+	}
+
+	entry := writePack(t, outputDir, "weather-mcp", "pack {}\n")
+	if err := repo.Commit(context.Background(), []Entry{entry}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	// The project's staged file is left alone and the project has no commits
+	if staged := run(t, project, "diff", "--cached", "--name-only"); staged != "main.go" {
+		t.Errorf("project index = %q, expected main.go to stay staged", staged)
+	}
+	if _, err := exec.Command("git", "-C", project, "rev-parse", "--verify", "HEAD").Output(); err == nil {
+		t.Error("Commit() committed to the enclosing project")
+	}
+}
+
+func TestCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	outputDir := t.TempDir()
+	remote := t.TempDir()
+	run(t, remote, "init", "--quiet", "--bare")
+
+	cfg := testConfig
+	cfg.Branch = "packs"
+	cfg.Tag = true
+	cfg.Push = true
+	cfg.Remote = remote
+
+	repo, err := Open(context.Background(), outputDir, cfg)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// A file staged by someone else isn't part of pack commits
+	if err := os.WriteFile(filepath.Join(outputDir, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run(t, outputDir, "add", "notes.txt")
+
+	entry := writePack(t, outputDir, "weather-mcp", "pack {}\n")
+	if err := repo.Commit(context.Background(), []Entry{entry}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if files := run(t, outputDir, "show", "--name-only", "--format=", "HEAD"); files != "weather-mcp/metadata.hcl" {
+		t.Errorf("commit files = %q, expected only the pack", files)
+	}
+	if staged := run(t, outputDir, "diff", "--cached", "--name-only"); staged != "notes.txt" {
+		t.Errorf("index = %q, expected notes.txt to stay staged", staged)
+	}
+	if subject := run(t, outputDir, "log", "-1", "--format=%s"); subject != "Add pack for io.github.example/weather-mcp@1.0.0 (oci, http)" {
+		t.Errorf("commit subject = %q", subject)
+	}
+
+	// The branch and tag are pushed
+	head := run(t, outputDir, "rev-parse", "HEAD")
+	if pushed := run(t, remote, "rev-parse", "refs/heads/packs"); pushed != head {
+		t.Errorf("remote branch = %s, expected %s", pushed, head)
+	}
+	run(t, remote, "rev-parse", "--verify", "refs/tags/weather-mcp")
+
+	// A failed push is retried when the pack is committed again, without a new commit
+	second := writePack(t, outputDir, "news-mcp", "pack {}\n")
+	repo.config.Remote = filepath.Join(t.TempDir(), "missing")
+	if err := repo.Commit(context.Background(), []Entry{second}); err == nil {
+		t.Fatal("Commit() expected an error pushing to a missing remote")
+	}
+	repo.config.Remote = remote
+	if err := repo.Commit(context.Background(), []Entry{second}); err != nil {
+		t.Fatalf("Commit() retry error = %v", err)
+	}
+	head = run(t, outputDir, "rev-parse", "HEAD")
+	if pushed := run(t, remote, "rev-parse", "refs/heads/packs"); pushed != head {
+		t.Errorf("remote branch = %s after retry, expected %s", pushed, head)
+	}
+	run(t, remote, "rev-parse", "--verify", "refs/tags/news-mcp")
+
+	// Identical regeneration makes no commit
+	writePack(t, outputDir, "weather-mcp", "pack {}\n")
+	if err := repo.Commit(context.Background(), []Entry{entry}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if count := run(t, outputDir, "rev-list", "--count", "HEAD"); count != "2" {
+		t.Errorf("commits = %s, expected no commit for an unchanged pack", count)
+	}
+}
+
+func TestOpenRepoDir(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	registry := t.TempDir()
+	run(t, registry, "init", "--quiet")
+
+	cfg := testConfig
+	cfg.RepoDir = registry
+
+	outputDir := filepath.Join(registry, "packs")
+	repo, err := Open(context.Background(), outputDir, cfg)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	entry := writePack(t, outputDir, "weather-mcp", "pack {}\n")
+	if err := repo.Commit(context.Background(), []Entry{entry}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if files := run(t, registry, "show", "--name-only", "--format=", "HEAD"); files != "packs/weather-mcp/metadata.hcl" {
+		t.Errorf("commit files = %q, expected the pack under packs/", files)
+	}
+
+	if _, err := Open(context.Background(), t.TempDir(), cfg); err == nil {
+		t.Error("Open() expected an error for an output directory outside git.repo_dir")
+	}
+}
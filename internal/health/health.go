@@ -0,0 +1,157 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/schedule"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/watcher"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Source provides the watcher status the health endpoints report on
+type Source interface {
+	Status() watcher.Status
+	CheckState(ctx context.Context) error
+}
+
+// InFlight counts generation tasks waiting for or holding a worker
+type InFlight struct {
+	Queued  int `json:"queued"`
+	Running int `json:"running"`
+}
+
+// Response is the JSON body returned by the health endpoints
+type Response struct {
+	Status             string            `json:"status"`
+	LastPoll           *time.Time        `json:"last_poll"`
+	LastSuccessfulPoll *time.Time        `json:"last_successful_poll"`
+	LastError          string            `json:"last_error,omitempty"`
+	InFlight           InFlight          `json:"in_flight"`
+	Checks             map[string]string `json:"checks,omitempty"`
+}
+
+// Checker serves the /healthz and /readyz endpoints for the watch daemon
+type Checker struct {
+	source         Source
+	maxMissedPolls int
+}
+
+// NewChecker builds a Checker that reports not ready once more than
+// maxMissedPolls poll intervals pass without a successful poll
+func NewChecker(source Source, maxMissedPolls int) *Checker {
+	return &Checker{
+		source:         source,
+		maxMissedPolls: maxMissedPolls,
+	}
+}
+
+// Healthz reports that the process is alive
+func (c *Checker) Healthz(w http.ResponseWriter, r *http.Request) {
+	resp := newResponse(c.source.Status())
+	resp.Status = StatusOK
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Readyz reports whether the watcher is polling successfully and its state store is usable
+func (c *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	status := c.source.Status()
+	resp := newResponse(status)
+	resp.Status = StatusOK
+	resp.Checks = map[string]string{
+		"poll":  StatusOK,
+		"state": StatusOK,
+	}
+
+	if err := c.checkPoll(status, time.Now()); err != nil {
+		resp.Status = StatusUnavailable
+		resp.Checks["poll"] = err.Error()
+	}
+
+	if err := c.source.CheckState(r.Context()); err != nil {
+		resp.Status = StatusUnavailable
+		resp.Checks["state"] = err.Error()
+	}
+
+	code := http.StatusOK
+	if resp.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+		slog.Debug("readiness check failed", "checks", resp.Checks)
+	}
+
+	writeJSON(w, code, resp)
+}
+
+// checkPoll fails when no poll has succeeded within the allowed number of scheduled polls,
+// measured from startup until the first successful poll. Polls skipped during a quiet
+// window count as on time.
+func (c *Checker) checkPoll(status watcher.Status, now time.Time) error {
+	since := status.LastSuccessfulPoll
+	if since.IsZero() {
+		since = status.StartedAt
+	}
+	if since.IsZero() {
+		return errors.New("watcher has not started")
+	}
+	if status.LastQuietPoll.After(since) {
+		since = status.LastQuietPoll
+	}
+
+	sched := status.Schedule
+	if sched == nil {
+		sched = schedule.Every(status.PollInterval)
+	}
+	deadline := since
+	for range c.maxMissedPolls {
+		deadline = sched.Next(deadline)
+	}
+	deadline = deadline.Add(status.PollJitter)
+
+	if now.After(deadline) {
+		age := now.Sub(since)
+		if status.LastSuccessfulPoll.IsZero() {
+			return fmt.Errorf("no successful poll since startup %v ago", age.Round(time.Second))
+		}
+		return fmt.Errorf("last successful poll was %v ago, more than %d scheduled polls", age.Round(time.Second), c.maxMissedPolls)
+	}
+
+	return nil
+}
+
+func newResponse(status watcher.Status) Response {
+	return Response{
+		LastPoll:           timePtr(status.LastPoll),
+		LastSuccessfulPoll: timePtr(status.LastSuccessfulPoll),
+		LastError:          status.LastError,
+		InFlight: InFlight{
+			Queued:  len(status.Queued),
+			Running: len(status.Running),
+		},
+	}
+}
+
+// timePtr returns nil for the zero time so it is reported as null
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("failed to write health response", "error", err)
+	}
+}
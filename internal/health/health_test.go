@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/watcher"
+)
+
+type fakeSource struct {
+	status   watcher.Status
+	stateErr error
+}
+
+func (f *fakeSource) Status() watcher.Status { return f.status }
+
+func (f *fakeSource) CheckState(ctx context.Context) error { return f.stateErr }
+
+func TestReadyz(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name           string
+		status         watcher.Status
+		stateErr       error
+		expectedCode   int
+		expectedChecks map[string]string // Prefixes of the expected check results
+	}{
+		{
+			name:           "recent successful poll",
+			status:         watcher.Status{StartedAt: now.Add(-time.Hour), LastSuccessfulPoll: now.Add(-90 * time.Second), PollInterval: time.Minute},
+			expectedCode:   http.StatusOK,
+			expectedChecks: map[string]string{"poll": StatusOK, "state": StatusOK},
+		},
+		{
+			name:           "missed polls",
+			status:         watcher.Status{StartedAt: now.Add(-time.Hour), LastSuccessfulPoll: now.Add(-4 * time.Minute), PollInterval: time.Minute},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"poll": "last successful poll was 4m0s ago, more than 3 scheduled polls", "state": StatusOK},
+		},
+		{
+			name:           "jitter extends the deadline",
+			status:         watcher.Status{StartedAt: now.Add(-time.Hour), LastSuccessfulPoll: now.Add(-4 * time.Minute), PollInterval: time.Minute, PollJitter: 2 * time.Minute},
+			expectedCode:   http.StatusOK,
+			expectedChecks: map[string]string{"poll": StatusOK, "state": StatusOK},
+		},
+		{
+			name:           "starting up",
+			status:         watcher.Status{StartedAt: now.Add(-2 * time.Minute), PollInterval: time.Minute},
+			expectedCode:   http.StatusOK,
+			expectedChecks: map[string]string{"poll": StatusOK, "state": StatusOK},
+		},
+		{
+			name:           "no successful poll since startup",
+			status:         watcher.Status{StartedAt: now.Add(-5 * time.Minute), PollInterval: time.Minute},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"poll": "no successful poll since startup", "state": StatusOK},
+		},
+		{
+			name:           "quiet window",
+			status:         watcher.Status{StartedAt: now.Add(-time.Hour), LastSuccessfulPoll: now.Add(-time.Hour), LastQuietPoll: now.Add(-time.Minute), PollInterval: time.Minute},
+			expectedCode:   http.StatusOK,
+			expectedChecks: map[string]string{"poll": StatusOK, "state": StatusOK},
+		},
+		{
+			name:           "not started",
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"poll": "watcher has not started", "state": StatusOK},
+		},
+		{
+			name:           "state check failure",
+			status:         watcher.Status{StartedAt: now.Add(-time.Hour), LastSuccessfulPoll: now, PollInterval: time.Minute},
+			stateErr:       errors.New("state file is not writable"),
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"poll": StatusOK, "state": "state file is not writable"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(&fakeSource{status: tt.status, stateErr: tt.stateErr}, 3)
+
+			rec := httptest.NewRecorder()
+			checker.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.expectedCode {
+				t.Errorf("Readyz() code = %d, expected %d", rec.Code, tt.expectedCode)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Readyz() Content-Type = %q", ct)
+			}
+
+			var resp Response
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Readyz() body is not valid JSON; %v", err)
+			}
+
+			expectedStatus := StatusOK
+			if tt.expectedCode != http.StatusOK {
+				expectedStatus = StatusUnavailable
+			}
+			if resp.Status != expectedStatus {
+				t.Errorf("Readyz() status = %q, expected %q", resp.Status, expectedStatus)
+			}
+			for check, expected := range tt.expectedChecks {
+				if !strings.HasPrefix(resp.Checks[check], expected) {
+					t.Errorf("Readyz() checks[%q] = %q, expected %q", check, resp.Checks[check], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	lastPoll := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	source := &fakeSource{
+		status: watcher.Status{
+			LastPoll:  lastPoll,
+			LastError: "registry unavailable",
+			Queued:    make([]watcher.TaskProgress, 2),
+			Running:   make([]watcher.TaskProgress, 1),
+		},
+		stateErr: errors.New("state file is not writable"),
+	}
+
+	rec := httptest.NewRecorder()
+	NewChecker(source, 3).Healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	// The process is alive regardless of the poll and state checks
+	if rec.Code != http.StatusOK {
+		t.Errorf("Healthz() code = %d, expected %d", rec.Code, http.StatusOK)
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Healthz() body is not valid JSON; %v", err)
+	}
+	if body["status"] != StatusOK || body["last_poll"] != "2026-10-16T12:00:00Z" || body["last_error"] != "registry unavailable" {
+		t.Errorf("Healthz() body = %v", body)
+	}
+	if v, ok := body["last_successful_poll"]; !ok || v != nil {
+		t.Errorf("Healthz() last_successful_poll = %v, expected null", v)
+	}
+	if inFlight, _ := body["in_flight"].(map[string]any); inFlight["queued"] != 2.0 || inFlight["running"] != 1.0 {
+		t.Errorf("Healthz() in_flight = %v", body["in_flight"])
+	}
+}
@@ -0,0 +1,18 @@
+package hooks
+
+import "fmt"
+
+type HookError struct {
+	Event    Event
+	Command  string
+	ExitCode int
+	Err      error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s hook %q failed with exit code %d; %v", e.Event, e.Command, e.ExitCode, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
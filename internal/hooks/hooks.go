@@ -0,0 +1,198 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+)
+
+// Event identifies the point in the generation lifecycle at which a hook runs
+type Event string
+
+const (
+	EventPreGenerate  Event = "pre_generate"
+	EventPostGenerate Event = "post_generate"
+	EventOnFailure    Event = "on_failure"
+	EventPostPoll     Event = "post_poll"
+)
+
+const waitDelay = 2 * time.Second
+
+// Hook is a single configured hook command
+type Hook struct {
+	Command        string
+	Timeout        time.Duration
+	FailGeneration bool
+}
+
+// Runner executes the hooks configured for each lifecycle event
+type Runner struct {
+	hooks map[Event][]Hook
+}
+
+// ServerInfo describes the MCP Server and package a hook is being run for
+type ServerInfo struct {
+	Registry          string `json:"registry"`
+	Name              string `json:"name"`
+	Version           string `json:"version"`
+	Description       string `json:"description,omitempty"`
+	PackageType       string `json:"package_type"`
+	TransportType     string `json:"transport_type"`
+	PackageIdentifier string `json:"package_identifier"`
+	PackageVersion    string `json:"package_version"`
+}
+
+// PollInfo summarizes a completed watch poll cycle
+type PollInfo struct {
+	StartedAt      time.Time `json:"started_at"`
+	DurationMillis int64     `json:"duration_ms"`
+	ServersFetched int       `json:"servers_fetched"`
+	Attempted      int       `json:"attempted"`
+	Succeeded      int       `json:"succeeded"`
+	Failed         int       `json:"failed"`
+}
+
+// Payload is the JSON document written to a hook's stdin
+type Payload struct {
+	Event    Event       `json:"event"`
+	PackPath string      `json:"pack_path,omitempty"`
+	Server   *ServerInfo `json:"server,omitempty"`
+	Poll     *PollInfo   `json:"poll,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// NewRunner builds a Runner from the hooks section of the configuration
+func NewRunner(cfg config.HooksConfig) *Runner {
+	r := &Runner{
+		hooks: make(map[Event][]Hook),
+	}
+
+	r.add(EventPreGenerate, cfg.PreGenerate)
+	r.add(EventPostGenerate, cfg.PostGenerate)
+	r.add(EventOnFailure, cfg.OnFailure)
+	r.add(EventPostPoll, cfg.PostPoll)
+
+	return r
+}
+
+func (r *Runner) add(event Event, hookConfigs []config.HookConfig) {
+	for _, hc := range hookConfigs {
+		timeout := hc.Timeout
+		if timeout <= 0 {
+			timeout = config.DefaultConfig.HookTimeout
+		}
+
+		r.hooks[event] = append(r.hooks[event], Hook{
+			Command:        hc.Command,
+			Timeout:        time.Duration(timeout) * time.Second,
+			FailGeneration: hc.FailGeneration,
+		})
+	}
+}
+
+// Has reports whether any hooks are configured for the given event
+func (r *Runner) Has(event Event) bool {
+	return r != nil && len(r.hooks[event]) > 0
+}
+
+// Run executes every hook configured for the event in order. Failures of hooks
+// with FailGeneration set are returned as a *HookError and stop further hooks
+// for the event; all other failures are logged and otherwise ignored.
+func (r *Runner) Run(ctx context.Context, event Event, payload Payload) error {
+	if !r.Has(event) {
+		return nil
+	}
+
+	payload.Event = event
+
+	stdin, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hook payload; %w", err)
+	}
+
+	for _, hook := range r.hooks[event] {
+		err := hook.run(ctx, stdin, payloadEnv(payload))
+		if err == nil {
+			continue
+		}
+
+		hookErr := &HookError{Event: event, Command: hook.Command, ExitCode: -1, Err: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			hookErr.ExitCode = exitErr.ExitCode()
+		}
+
+		if hook.FailGeneration {
+			return hookErr
+		}
+
+		slog.Warn("hook failed", "event", event, "command", hook.Command, "exit_code", hookErr.ExitCode, "error", err)
+	}
+
+	return nil
+}
+
+func (h Hook) run(ctx context.Context, stdin []byte, env []string) error {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	slog.Debug("running hook", "command", h.Command, "timeout", h.Timeout)
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", h.Command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	// Don't wait indefinitely on children of the shell that hold the output pipes open after a timeout
+	cmd.WaitDelay = waitDelay
+
+	var combined bytes.Buffer
+	cmd.Stdout = &combined
+	cmd.Stderr = &combined
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("hook timed out after %v", h.Timeout)
+	}
+
+	slog.Debug("hook finished", "command", h.Command, "output", combined.String(), "error", err)
+
+	return err
+}
+
+func payloadEnv(p Payload) []string {
+	env := []string{
+		"NOMAD_MCP_PACK_HOOK_EVENT=" + string(p.Event),
+		"NOMAD_MCP_PACK_HOOK_PACK_PATH=" + p.PackPath,
+		"NOMAD_MCP_PACK_HOOK_ERROR=" + p.Error,
+	}
+
+	if p.Server != nil {
+		env = append(env,
+			"NOMAD_MCP_PACK_HOOK_REGISTRY="+p.Server.Registry,
+			"NOMAD_MCP_PACK_HOOK_SERVER_NAME="+p.Server.Name,
+			"NOMAD_MCP_PACK_HOOK_SERVER_VERSION="+p.Server.Version,
+			"NOMAD_MCP_PACK_HOOK_PACKAGE_TYPE="+p.Server.PackageType,
+			"NOMAD_MCP_PACK_HOOK_TRANSPORT_TYPE="+p.Server.TransportType,
+			"NOMAD_MCP_PACK_HOOK_PACKAGE_IDENTIFIER="+p.Server.PackageIdentifier,
+			"NOMAD_MCP_PACK_HOOK_PACKAGE_VERSION="+p.Server.PackageVersion,
+		)
+	}
+
+	if p.Poll != nil {
+		env = append(env,
+			"NOMAD_MCP_PACK_HOOK_POLL_SERVERS_FETCHED="+strconv.Itoa(p.Poll.ServersFetched),
+			"NOMAD_MCP_PACK_HOOK_POLL_SUCCEEDED="+strconv.Itoa(p.Poll.Succeeded),
+			"NOMAD_MCP_PACK_HOOK_POLL_FAILED="+strconv.Itoa(p.Poll.Failed),
+		)
+	}
+
+	return env
+}
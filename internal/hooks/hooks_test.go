@@ -0,0 +1,127 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+)
+
+func TestRunnerRun(t *testing.T) {
+	payload := Payload{
+		PackPath: "/packs/weather-mcp",
+		Server:   &ServerInfo{Registry: "default", Name: "io.github.example/weather-mcp", Version: "1.2.0", PackageType: "oci"},
+	}
+
+	tests := []struct {
+		name         string
+		hooks        []Hook
+		expectErr    bool
+		expectedCode int
+		expectedOut  []string // Lines the hooks are expected to write to out.txt
+	}{
+		{
+			name:        "successful hook",
+			hooks:       []Hook{{Command: `echo ok >> "$OUT"`}},
+			expectedOut: []string{"ok"},
+		},
+		{
+			name:        "failure ignored without fail_generation",
+			hooks:       []Hook{{Command: "exit 3"}, {Command: `echo next >> "$OUT"`}},
+			expectedOut: []string{"next"},
+		},
+		{
+			name:         "failure returned with fail_generation",
+			hooks:        []Hook{{Command: "exit 3", FailGeneration: true}, {Command: `echo next >> "$OUT"`}},
+			expectErr:    true,
+			expectedCode: 3,
+		},
+		{
+			name:         "timeout",
+			hooks:        []Hook{{Command: "exec sleep 5", Timeout: 100 * time.Millisecond, FailGeneration: true}},
+			expectErr:    true,
+			expectedCode: -1,
+		},
+		{
+			name:        "environment",
+			hooks:       []Hook{{Command: `echo "$NOMAD_MCP_PACK_HOOK_EVENT $NOMAD_MCP_PACK_HOOK_PACK_PATH $NOMAD_MCP_PACK_HOOK_SERVER_NAME $NOMAD_MCP_PACK_HOOK_SERVER_VERSION" >> "$OUT"`}},
+			expectedOut: []string{"post_generate /packs/weather-mcp io.github.example/weather-mcp 1.2.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "out.txt")
+			t.Setenv("OUT", out)
+
+			r := &Runner{hooks: map[Event][]Hook{}}
+			for _, h := range tt.hooks {
+				if h.Timeout == 0 {
+					h.Timeout = 10 * time.Second
+				}
+				r.hooks[EventPostGenerate] = append(r.hooks[EventPostGenerate], h)
+			}
+
+			err := r.Run(context.Background(), EventPostGenerate, payload)
+			if tt.expectErr {
+				var hookErr *HookError
+				if !errors.As(err, &hookErr) {
+					t.Fatalf("Run() error = %v, expected a *HookError", err)
+				}
+				if hookErr.ExitCode != tt.expectedCode || hookErr.Event != EventPostGenerate {
+					t.Errorf("Run() error = %+v, expected exit code %d", hookErr, tt.expectedCode)
+				}
+			} else if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			data, _ := os.ReadFile(out)
+			var lines []string
+			if s := strings.TrimSpace(string(data)); s != "" {
+				lines = strings.Split(s, "\n")
+			}
+			if strings.Join(lines, "\n") != strings.Join(tt.expectedOut, "\n") {
+				t.Errorf("hooks wrote %q, expected %q", lines, tt.expectedOut)
+			}
+		})
+	}
+}
+
+func TestRunnerPayloadStdin(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "payload.json")
+	r := NewRunner(config.HooksConfig{
+		OnFailure: []config.HookConfig{{Command: "cat > " + out}},
+	})
+
+	if r.Has(EventPreGenerate) || !r.Has(EventOnFailure) {
+		t.Fatalf("Has() should only report on_failure hooks")
+	}
+
+	payload := Payload{
+		Event:  EventPreGenerate, // Run sets the event it runs for
+		Server: &ServerInfo{Name: "io.github.example/weather-mcp", Version: "1.2.0"},
+		Error:  "generation failed",
+	}
+	if err := r.Run(context.Background(), EventOnFailure, payload); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook did not receive its payload; %v", err)
+	}
+
+	var got Payload
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("payload is not valid JSON; %v", err)
+	}
+	if got.Event != EventOnFailure || got.Error != "generation failed" || got.Server == nil || got.Server.Name != "io.github.example/weather-mcp" {
+		t.Errorf("payload = %+v", got)
+	}
+}
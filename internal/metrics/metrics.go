@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "nomad_mcp_pack"
+
+// Generation results used for the result label
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultSkipped = "skipped"
+)
+
+// Registry holds every metric exported by nomad-mcp-pack
+var Registry = prometheus.NewRegistry()
+
+var (
+	PollDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "poll_duration_seconds",
+		Help:      "Duration of watch poll cycles.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200},
+	}, []string{"result"})
+
+	LastPollTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_poll_timestamp_seconds",
+		Help:      "Unix time the last watch poll cycle finished.",
+	})
+
+	LastSuccessfulPollTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_successful_poll_timestamp_seconds",
+		Help:      "Unix time the last successful watch poll cycle finished.",
+	})
+
+	PollsSkipped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "polls_skipped_total",
+		Help:      "Watch poll cycles skipped during quiet windows.",
+	})
+
+	ServersFetched = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "servers_fetched",
+		Help:      "Number of MCP Servers fetched from the registry in the last poll cycle.",
+	})
+
+	TasksNeeded = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tasks_needing_generation",
+		Help:      "Number of packs needing generation in the last poll cycle.",
+	})
+
+	Generations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "generations_total",
+		Help:      "Pack generations by result, package type and transport type.",
+	}, []string{"result", "package_type", "transport_type"})
+
+	RegistryRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "registry_request_duration_seconds",
+		Help:      "Latency of MCP Registry requests by HTTP status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"code"})
+
+	RegistryRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registry_request_errors_total",
+		Help:      "Failed MCP Registry requests by reason.",
+	}, []string{"reason"})
+
+	NPMLookupDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "npm_lookup_duration_seconds",
+		Help:      "Latency of NPM package metadata lookups.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	StateFileSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "state_file_size_bytes",
+		Help:      "Size of the serialized watch state after the last save.",
+	})
+
+	StateServers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "state_servers",
+		Help:      "Number of packs tracked in the watch state.",
+	})
+
+	ConfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Watch configuration reloads by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		PollDuration,
+		LastPollTimestamp,
+		LastSuccessfulPollTimestamp,
+		PollsSkipped,
+		ServersFetched,
+		TasksNeeded,
+		Generations,
+		RegistryRequestDuration,
+		RegistryRequestErrors,
+		NPMLookupDuration,
+		StateFileSize,
+		StateServers,
+		ConfigReloads,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObservePoll records the outcome of a watch poll cycle
+func ObservePoll(start time.Time, err error) {
+	now := time.Now()
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailure
+	}
+
+	PollDuration.WithLabelValues(result).Observe(now.Sub(start).Seconds())
+	LastPollTimestamp.Set(float64(now.Unix()))
+	if err == nil {
+		LastSuccessfulPollTimestamp.Set(float64(now.Unix()))
+	}
+}
+
+// ObserveState records the number of tracked packs and the size of the serialized state after a save
+func ObserveState(servers, size int) {
+	StateServers.Set(float64(servers))
+	StateFileSize.Set(float64(size))
+}
+
+// ObserveConfigReload records the outcome of a watch configuration reload
+func ObserveConfigReload(err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailure
+	}
+	ConfigReloads.WithLabelValues(result).Inc()
+}
+
+// InstrumentRegistryTransport wraps next so registry request latency and errors are recorded
+func InstrumentRegistryTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		elapsed := time.Since(start).Seconds()
+
+		if err != nil {
+			RegistryRequestDuration.WithLabelValues("error").Observe(elapsed)
+			RegistryRequestErrors.WithLabelValues("transport").Inc()
+			return nil, err
+		}
+
+		RegistryRequestDuration.WithLabelValues(strconv.Itoa(resp.StatusCode)).Observe(elapsed)
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			RegistryRequestErrors.WithLabelValues("rate_limited").Inc()
+		case resp.StatusCode >= 500:
+			RegistryRequestErrors.WithLabelValues("server_error").Inc()
+		case resp.StatusCode >= 400:
+			RegistryRequestErrors.WithLabelValues("client_error").Inc()
+		}
+
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObservePoll(t *testing.T) {
+	before := map[string]uint64{ResultSuccess: histogramCount(t, ResultSuccess), ResultFailure: histogramCount(t, ResultFailure)}
+
+	ObservePoll(time.Now().Add(-2*time.Second), nil)
+	successTimestamp := testutil.ToFloat64(LastSuccessfulPollTimestamp)
+	if successTimestamp == 0 || testutil.ToFloat64(LastPollTimestamp) != successTimestamp {
+		t.Errorf("ObservePoll() success timestamps = %v, %v", testutil.ToFloat64(LastPollTimestamp), successTimestamp)
+	}
+
+	LastSuccessfulPollTimestamp.Set(1)
+	ObservePoll(time.Now(), errors.New("registry unavailable"))
+	if testutil.ToFloat64(LastSuccessfulPollTimestamp) != 1 {
+		t.Error("ObservePoll() of a failed poll set the last successful poll timestamp")
+	}
+
+	for result, expected := range map[string]uint64{ResultSuccess: before[ResultSuccess] + 1, ResultFailure: before[ResultFailure] + 1} {
+		if got := histogramCount(t, result); got != expected {
+			t.Errorf("poll duration count{result=%q} = %d, expected %d", result, got, expected)
+		}
+	}
+}
+
+func TestObserveConfigReload(t *testing.T) {
+	success := testutil.ToFloat64(ConfigReloads.WithLabelValues(ResultSuccess))
+	failure := testutil.ToFloat64(ConfigReloads.WithLabelValues(ResultFailure))
+
+	ObserveConfigReload(nil)
+	ObserveConfigReload(errors.New("invalid config"))
+	ObserveConfigReload(errors.New("invalid config"))
+
+	if got := testutil.ToFloat64(ConfigReloads.WithLabelValues(ResultSuccess)); got != success+1 {
+		t.Errorf("config reloads{result=success} = %v, expected %v", got, success+1)
+	}
+	if got := testutil.ToFloat64(ConfigReloads.WithLabelValues(ResultFailure)); got != failure+2 {
+		t.Errorf("config reloads{result=failure} = %v, expected %v", got, failure+2)
+	}
+}
+
+func TestInstrumentRegistryTransport(t *testing.T) {
+	codes := []int{http.StatusOK, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusNotFound}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(codes[0])
+		codes = codes[1:]
+	}))
+	defer srv.Close()
+
+	reasons := []string{"rate_limited", "server_error", "client_error", "transport"}
+	before := make(map[string]float64)
+	for _, reason := range reasons {
+		before[reason] = testutil.ToFloat64(RegistryRequestErrors.WithLabelValues(reason))
+	}
+
+	client := &http.Client{Transport: InstrumentRegistryTransport(nil)}
+	for range 4 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := client.Get("http://127.0.0.1:1"); err == nil {
+		t.Fatal("Get() expected a transport error")
+	}
+
+	for _, reason := range reasons {
+		if got := testutil.ToFloat64(RegistryRequestErrors.WithLabelValues(reason)); got != before[reason]+1 {
+			t.Errorf("registry request errors{reason=%q} = %v, expected %v", reason, got, before[reason]+1)
+		}
+	}
+}
+
+// histogramCount returns the number of poll durations observed with the result
+func histogramCount(t *testing.T, result string) uint64 {
+	t.Helper()
+
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != namespace+"_poll_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "result" && label.GetValue() == result {
+					return m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,172 @@
+package nomad
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
+)
+
+// DefaultAddress is the Nomad API address used when none is configured
+const DefaultAddress = "http://127.0.0.1:4646"
+
+// requestTimeout bounds each request to the Nomad API
+const requestTimeout = 30 * time.Second
+
+// ErrNotFound is returned when the requested object does not exist
+var ErrNotFound = errors.New("not found")
+
+// Config configures a Client
+type Config struct {
+	Address   string
+	Token     string
+	Namespace string
+	Region    string
+}
+
+// DefaultConfig returns a Config populated from the NOMAD_ADDR, NOMAD_TOKEN,
+// NOMAD_NAMESPACE and NOMAD_REGION environment variables
+func DefaultConfig() Config {
+	cfg := Config{
+		Address:   os.Getenv("NOMAD_ADDR"),
+		Token:     os.Getenv("NOMAD_TOKEN"),
+		Namespace: os.Getenv("NOMAD_NAMESPACE"),
+		Region:    os.Getenv("NOMAD_REGION"),
+	}
+	if cfg.Address == "" {
+		cfg.Address = DefaultAddress
+	}
+	return cfg
+}
+
+// APIError is returned when the Nomad API responds with an unexpected status
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("nomad API %s %s returned %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// Client is a minimal client for the Nomad HTTP API
+type Client struct {
+	baseURL   *url.URL
+	token     string
+	namespace string
+	region    string
+	http      *http.Client
+}
+
+// NewClient creates a Client for the Nomad API described by cfg
+func NewClient(cfg Config) (*Client, error) {
+	address := cfg.Address
+	if address == "" {
+		address = DefaultAddress
+	}
+
+	baseURL, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse nomad address; %w", err)
+	}
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, fmt.Errorf("nomad address must be an http or https URL, got %q", address)
+	}
+
+	return &Client{
+		baseURL:   baseURL,
+		token:     cfg.Token,
+		namespace: cfg.Namespace,
+		region:    cfg.Region,
+		http:      &http.Client{Timeout: requestTimeout, Transport: tracing.Transport(nil)},
+	}, nil
+}
+
+// Address returns the Nomad API address the client sends requests to
+func (c *Client) Address() string {
+	return c.baseURL.String()
+}
+
+// do sends a request to the Nomad API, decoding a successful JSON response into out.
+// Responses with a status in allowed are also decoded rather than returned as an *APIError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any, allowed ...int) (int, error) {
+	u := c.baseURL.JoinPath(path)
+
+	if query == nil {
+		query = url.Values{}
+	}
+	if c.namespace != "" && query.Get("namespace") == "" {
+		query.Set("namespace", c.namespace)
+	}
+	if c.region != "" {
+		query.Set("region", c.region)
+	}
+	u.RawQuery = query.Encode()
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal nomad request; %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create nomad request; %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("X-Nomad-Token", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("nomad request failed; %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read nomad response; %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.StatusCode, ErrNotFound
+	}
+
+	ok := resp.StatusCode >= 200 && resp.StatusCode < 300
+	for _, code := range allowed {
+		ok = ok || resp.StatusCode == code
+	}
+	if !ok {
+		return resp.StatusCode, &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(data)),
+		}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode nomad response; %w", err)
+		}
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,181 @@
+package nomad
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient creates a client for a Nomad API served by handler
+func newTestClient(t *testing.T, cfg Config, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg.Address = srv.URL
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error = %v", err)
+	}
+
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected string
+		wantErr  bool
+	}{
+		{address: "", expected: DefaultAddress},
+		{address: "https://nomad.example.com:4646", expected: "https://nomad.example.com:4646"},
+		{address: "nomad.example.com:4646", wantErr: true},
+		{address: "ftp://nomad.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			client, err := NewClient(Config{Address: tt.address})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && client.Address() != tt.expected {
+				t.Errorf("Address() = %q, expected %q", client.Address(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestDoRequest(t *testing.T) {
+	var seen *http.Request
+	client := newTestClient(t, Config{Token: "secret", Namespace: "mcp", Region: "eu"}, func(w http.ResponseWriter, r *http.Request) {
+		seen = r
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	var out struct{ OK bool }
+	code, err := client.do(context.Background(), http.MethodPost, "/v1/jobs", nil, map[string]any{"Job": "x"}, &out)
+	if err != nil {
+		t.Fatalf("do() unexpected error = %v", err)
+	}
+	if code != http.StatusOK || !out.OK {
+		t.Errorf("do() = %d, %+v, expected 200 and the decoded response", code, out)
+	}
+
+	if got := seen.Header.Get("X-Nomad-Token"); got != "secret" {
+		t.Errorf("X-Nomad-Token = %q, expected secret", got)
+	}
+	if got := seen.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, expected application/json", got)
+	}
+	if got := seen.URL.Query().Get("namespace"); got != "mcp" {
+		t.Errorf("namespace = %q, expected the client's namespace mcp", got)
+	}
+	if got := seen.URL.Query().Get("region"); got != "eu" {
+		t.Errorf("region = %q, expected eu", got)
+	}
+}
+
+func TestDoNamespace(t *testing.T) {
+	var namespace string
+	client := newTestClient(t, Config{Namespace: "mcp"}, func(w http.ResponseWriter, r *http.Request) {
+		namespace = r.URL.Query().Get("namespace")
+	})
+
+	// A namespace set by the request takes precedence over the client's
+	if _, err := client.do(context.Background(), http.MethodGet, "/v1/job/weather", namespaceQuery("tools"), nil, nil); err != nil {
+		t.Fatalf("do() unexpected error = %v", err)
+	}
+	if namespace != "tools" {
+		t.Errorf("namespace = %q, expected tools", namespace)
+	}
+
+	if _, err := client.do(context.Background(), http.MethodGet, "/v1/job/weather", namespaceQuery(""), nil, nil); err != nil {
+		t.Fatalf("do() unexpected error = %v", err)
+	}
+	if namespace != "mcp" {
+		t.Errorf("namespace = %q, expected mcp", namespace)
+	}
+}
+
+func TestDoErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		allowed    []int
+		expectCode int
+		expectErr  func(t *testing.T, err error)
+	}{
+		{
+			name:       "not found",
+			status:     http.StatusNotFound,
+			body:       "job not found",
+			expectCode: http.StatusNotFound,
+			expectErr: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("do() error = %v, expected ErrNotFound", err)
+				}
+			},
+		},
+		{
+			name:       "api error",
+			status:     http.StatusForbidden,
+			body:       "Permission denied\n",
+			expectCode: http.StatusForbidden,
+			expectErr: func(t *testing.T, err error) {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) {
+					t.Fatalf("do() error = %v, expected an *APIError", err)
+				}
+				expected := APIError{Method: http.MethodGet, Path: "/v1/jobs", StatusCode: http.StatusForbidden, Message: "Permission denied"}
+				if *apiErr != expected {
+					t.Errorf("do() error = %+v, expected %+v", *apiErr, expected)
+				}
+			},
+		},
+		{
+			name:       "allowed status",
+			status:     http.StatusConflict,
+			body:       `{"ModifyIndex":7}`,
+			allowed:    []int{http.StatusConflict},
+			expectCode: http.StatusConflict,
+		},
+		{
+			name:       "invalid response",
+			status:     http.StatusOK,
+			body:       "not json",
+			expectCode: http.StatusOK,
+			expectErr: func(t *testing.T, err error) {
+				if err == nil {
+					t.Error("do() expected error for an invalid response")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			var out map[string]any
+			code, err := client.do(context.Background(), http.MethodGet, "/v1/jobs", nil, nil, &out, tt.allowed...)
+			if code != tt.expectCode {
+				t.Errorf("do() status = %d, expected %d", code, tt.expectCode)
+			}
+			if tt.expectErr == nil {
+				if err != nil {
+					t.Errorf("do() unexpected error = %v", err)
+				}
+				return
+			}
+			tt.expectErr(t, err)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package nomad
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Job types
+const (
+	JobTypeService = "service"
+	JobTypeBatch   = "batch"
+	JobTypeSystem  = "system"
+)
+
+// Deployment statuses
+const (
+	DeploymentStatusRunning    = "running"
+	DeploymentStatusPending    = "pending"
+	DeploymentStatusPaused     = "paused"
+	DeploymentStatusBlocked    = "blocked"
+	DeploymentStatusSuccessful = "successful"
+	DeploymentStatusFailed     = "failed"
+	DeploymentStatusCancelled  = "cancelled"
+)
+
+// Job is a job parsed or read from Nomad. Spec holds the complete job as Nomad returned it,
+// which is what plan and register requests send back. An empty Type is a service job.
+type Job struct {
+	ID        string `json:"ID"`
+	Name      string `json:"Name"`
+	Namespace string `json:"Namespace"`
+	Type      string `json:"Type"`
+	Version   uint64 `json:"Version"`
+	Status    string `json:"Status"`
+
+	Spec json.RawMessage `json:"-"`
+}
+
+// JobStub summarises a job in a job listing
+type JobStub struct {
+	ID        string            `json:"ID"`
+	Name      string            `json:"Name"`
+	Namespace string            `json:"Namespace"`
+	Type      string            `json:"Type"`
+	Status    string            `json:"Status"`
+	Stop      bool              `json:"Stop"`
+	Meta      map[string]string `json:"Meta"`
+}
+
+// RegisterResponse is the result of registering a job
+type RegisterResponse struct {
+	EvalID         string `json:"EvalID"`
+	JobModifyIndex uint64 `json:"JobModifyIndex"`
+	Warnings       string `json:"Warnings"`
+}
+
+// PlanResponse is the result of a dry run of the scheduler for a job
+type PlanResponse struct {
+	Diff           *JobDiff                   `json:"Diff"`
+	Annotations    *PlanAnnotations           `json:"Annotations"`
+	FailedTGAllocs map[string]json.RawMessage `json:"FailedTGAllocs"`
+	JobModifyIndex uint64                     `json:"JobModifyIndex"`
+	Warnings       string                     `json:"Warnings"`
+}
+
+// PlanAnnotations summarises the changes the scheduler would make to each task group
+type PlanAnnotations struct {
+	DesiredTGUpdates map[string]DesiredUpdates `json:"DesiredTGUpdates"`
+}
+
+type DesiredUpdates struct {
+	Ignore            uint64 `json:"Ignore"`
+	Place             uint64 `json:"Place"`
+	Migrate           uint64 `json:"Migrate"`
+	Stop              uint64 `json:"Stop"`
+	InPlaceUpdate     uint64 `json:"InPlaceUpdate"`
+	DestructiveUpdate uint64 `json:"DestructiveUpdate"`
+	Canary            uint64 `json:"Canary"`
+}
+
+// Diff types
+const (
+	DiffTypeNone    = "None"
+	DiffTypeAdded   = "Added"
+	DiffTypeDeleted = "Deleted"
+	DiffTypeEdited  = "Edited"
+)
+
+// JobDiff is the difference between a job and the version registered in Nomad
+type JobDiff struct {
+	Type       string          `json:"Type"`
+	ID         string          `json:"ID"`
+	Fields     []FieldDiff     `json:"Fields"`
+	Objects    []ObjectDiff    `json:"Objects"`
+	TaskGroups []TaskGroupDiff `json:"TaskGroups"`
+}
+
+type TaskGroupDiff struct {
+	Type    string            `json:"Type"`
+	Name    string            `json:"Name"`
+	Fields  []FieldDiff       `json:"Fields"`
+	Objects []ObjectDiff      `json:"Objects"`
+	Tasks   []TaskDiff        `json:"Tasks"`
+	Updates map[string]uint64 `json:"Updates"`
+}
+
+type TaskDiff struct {
+	Type        string       `json:"Type"`
+	Name        string       `json:"Name"`
+	Fields      []FieldDiff  `json:"Fields"`
+	Objects     []ObjectDiff `json:"Objects"`
+	Annotations []string     `json:"Annotations"`
+}
+
+type ObjectDiff struct {
+	Type    string       `json:"Type"`
+	Name    string       `json:"Name"`
+	Fields  []FieldDiff  `json:"Fields"`
+	Objects []ObjectDiff `json:"Objects"`
+}
+
+type FieldDiff struct {
+	Type        string   `json:"Type"`
+	Name        string   `json:"Name"`
+	Old         string   `json:"Old"`
+	New         string   `json:"New"`
+	Annotations []string `json:"Annotations"`
+}
+
+// Deployment tracks the rollout of a job version
+type Deployment struct {
+	ID                string                          `json:"ID"`
+	JobID             string                          `json:"JobID"`
+	JobVersion        uint64                          `json:"JobVersion"`
+	Status            string                          `json:"Status"`
+	StatusDescription string                          `json:"StatusDescription"`
+	TaskGroups        map[string]DeploymentGroupState `json:"TaskGroups"`
+}
+
+// DeploymentGroupState is the progress of a task group in a deployment
+type DeploymentGroupState struct {
+	DesiredTotal    int `json:"DesiredTotal"`
+	PlacedAllocs    int `json:"PlacedAllocs"`
+	HealthyAllocs   int `json:"HealthyAllocs"`
+	UnhealthyAllocs int `json:"UnhealthyAllocs"`
+}
+
+// ListJobs lists the jobs in the client's namespace, or in every namespace when it is *,
+// including their meta
+func (c *Client) ListJobs(ctx context.Context) ([]JobStub, error) {
+	query := url.Values{}
+	query.Set("meta", "true")
+
+	var jobs []JobStub
+	if _, err := c.do(ctx, http.MethodGet, "/v1/jobs", query, nil, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to list jobs; %w", err)
+	}
+
+	return jobs, nil
+}
+
+// ParseJob converts an HCL jobspec to a job, without registering it. The job isn't
+// canonicalized, so a jobspec without a namespace or type leaves them empty and the
+// client's namespace and Nomad's defaults apply when it is planned or registered.
+func (c *Client) ParseJob(ctx context.Context, jobHCL string) (*Job, error) {
+	body := map[string]any{"JobHCL": jobHCL, "Canonicalize": false}
+
+	var spec json.RawMessage
+	if _, err := c.do(ctx, http.MethodPost, "/v1/jobs/parse", nil, body, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse job; %w", err)
+	}
+
+	return decodeJob(spec)
+}
+
+// PlanJob runs the scheduler for job without applying the result, returning the diff
+// against the registered version
+func (c *Client) PlanJob(ctx context.Context, job *Job) (*PlanResponse, error) {
+	body := map[string]any{"Job": job.Spec, "Diff": true}
+
+	var plan PlanResponse
+	if _, err := c.do(ctx, http.MethodPost, "/v1/job/"+job.ID+"/plan", namespaceQuery(job.Namespace), body, &plan); err != nil {
+		return nil, fmt.Errorf("failed to plan job %s; %w", job.ID, err)
+	}
+
+	return &plan, nil
+}
+
+// RegisterJob registers job, creating it or updating the registered version
+func (c *Client) RegisterJob(ctx context.Context, job *Job) (*RegisterResponse, error) {
+	body := map[string]any{"Job": job.Spec}
+
+	var resp RegisterResponse
+	if _, err := c.do(ctx, http.MethodPost, "/v1/jobs", namespaceQuery(job.Namespace), body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to register job %s; %w", job.ID, err)
+	}
+
+	return &resp, nil
+}
+
+// GetJob reads the registered job, returning ErrNotFound if it does not exist
+func (c *Client) GetJob(ctx context.Context, namespace, id string) (*Job, error) {
+	var spec json.RawMessage
+	if _, err := c.do(ctx, http.MethodGet, "/v1/job/"+id, namespaceQuery(namespace), nil, &spec); err != nil {
+		return nil, err
+	}
+
+	return decodeJob(spec)
+}
+
+// LatestDeployment returns the most recent deployment of a job, or nil if it has none
+func (c *Client) LatestDeployment(ctx context.Context, namespace, jobID string) (*Deployment, error) {
+	var deployment *Deployment
+	_, err := c.do(ctx, http.MethodGet, "/v1/job/"+jobID+"/deployment", namespaceQuery(namespace), nil, &deployment)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return deployment, nil
+}
+
+// namespaceQuery targets a namespace set by a jobspec, or the client's namespace when empty
+func namespaceQuery(namespace string) url.Values {
+	query := url.Values{}
+	if namespace != "" {
+		query.Set("namespace", namespace)
+	}
+	return query
+}
+
+func decodeJob(spec json.RawMessage) (*Job, error) {
+	var job Job
+	if err := json.Unmarshal(spec, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job; %w", err)
+	}
+	if job.ID == "" {
+		return nil, errors.New("failed to decode job; job has no ID")
+	}
+	job.Spec = spec
+
+	return &job, nil
+}
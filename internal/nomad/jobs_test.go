@@ -0,0 +1,181 @@
+package nomad
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+const testJobSpec = `{"ID":"weather-mcp","Name":"weather-mcp","Namespace":"tools","Type":"service","TaskGroups":[{"Name":"mcp"}]}`
+
+func TestParseJob(t *testing.T) {
+	var body map[string]any
+	client := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/jobs/parse" {
+			t.Errorf("request = %s %s, expected POST /v1/jobs/parse", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(testJobSpec))
+	})
+
+	job, err := client.ParseJob(context.Background(), `job "weather-mcp" {}`)
+	if err != nil {
+		t.Fatalf("ParseJob() unexpected error = %v", err)
+	}
+
+	if body["JobHCL"] != `job "weather-mcp" {}` || body["Canonicalize"] != false {
+		t.Errorf("request body = %v, expected the jobspec without canonicalization", body)
+	}
+	if job.ID != "weather-mcp" || job.Namespace != "tools" || job.Type != JobTypeService {
+		t.Errorf("ParseJob() = %+v, expected job weather-mcp in namespace tools", job)
+	}
+	if string(job.Spec) != testJobSpec {
+		t.Errorf("ParseJob() spec = %s, expected the complete job", job.Spec)
+	}
+}
+
+func TestParseJobErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		apiStatus int
+	}{
+		{name: "invalid jobspec", status: http.StatusBadRequest, body: "1 error occurred: invalid job", apiStatus: http.StatusBadRequest},
+		{name: "job without ID", status: http.StatusOK, body: `{"Name":"weather-mcp"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := client.ParseJob(context.Background(), `job "weather-mcp" {}`)
+			if err == nil {
+				t.Fatal("ParseJob() expected error")
+			}
+
+			var apiErr *APIError
+			if isAPIErr := errors.As(err, &apiErr); isAPIErr != (tt.apiStatus != 0) || (isAPIErr && apiErr.StatusCode != tt.apiStatus) {
+				t.Errorf("ParseJob() error = %v, expected an *APIError with status %d: %v", err, tt.apiStatus, tt.apiStatus != 0)
+			}
+		})
+	}
+}
+
+func TestPlanJob(t *testing.T) {
+	job, err := decodeJob(json.RawMessage(testJobSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body map[string]json.RawMessage
+	client := newTestClient(t, Config{Namespace: "default"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/job/weather-mcp/plan" {
+			t.Errorf("request = %s %s, expected POST /v1/job/weather-mcp/plan", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query().Get("namespace"); got != "tools" {
+			t.Errorf("namespace = %q, expected the jobspec's namespace tools", got)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"Diff":{"Type":"Added","ID":"weather-mcp"},"Annotations":{"DesiredTGUpdates":{"mcp":{"Place":1}}},"JobModifyIndex":0}`))
+	})
+
+	plan, err := client.PlanJob(context.Background(), job)
+	if err != nil {
+		t.Fatalf("PlanJob() unexpected error = %v", err)
+	}
+
+	if string(body["Job"]) != testJobSpec || string(body["Diff"]) != "true" {
+		t.Errorf("request body = %v, expected the job spec with a diff", body)
+	}
+	if plan.Diff == nil || plan.Diff.Type != DiffTypeAdded {
+		t.Errorf("PlanJob() diff = %+v, expected an added job", plan.Diff)
+	}
+	if plan.Annotations.DesiredTGUpdates["mcp"].Place != 1 {
+		t.Errorf("PlanJob() annotations = %+v, expected 1 placement", plan.Annotations)
+	}
+}
+
+func TestRegisterJob(t *testing.T) {
+	job, err := decodeJob(json.RawMessage(testJobSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("registered", func(t *testing.T) {
+		var body map[string]json.RawMessage
+		client := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/v1/jobs" {
+				t.Errorf("request = %s %s, expected POST /v1/jobs", r.Method, r.URL.Path)
+			}
+			if got := r.URL.Query().Get("namespace"); got != "tools" {
+				t.Errorf("namespace = %q, expected tools", got)
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			w.Write([]byte(`{"EvalID":"eval-1","JobModifyIndex":42}`))
+		})
+
+		resp, err := client.RegisterJob(context.Background(), job)
+		if err != nil {
+			t.Fatalf("RegisterJob() unexpected error = %v", err)
+		}
+		if string(body["Job"]) != testJobSpec {
+			t.Errorf("request body = %v, expected the job spec", body)
+		}
+		if resp.EvalID != "eval-1" || resp.JobModifyIndex != 42 {
+			t.Errorf("RegisterJob() = %+v, expected eval-1 at index 42", resp)
+		}
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		client := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("Permission denied"))
+		})
+
+		var apiErr *APIError
+		if _, err := client.RegisterJob(context.Background(), job); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+			t.Errorf("RegisterJob() error = %v, expected an *APIError with status 403", err)
+		}
+	})
+}
+
+func TestListJobs(t *testing.T) {
+	client := newTestClient(t, Config{Namespace: "*"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/jobs" {
+			t.Errorf("path = %s, expected /v1/jobs", r.URL.Path)
+		}
+		if got := r.URL.Query(); got.Get("meta") != "true" || got.Get("namespace") != "*" {
+			t.Errorf("query = %v, expected meta=true and namespace=*", got)
+		}
+		w.Write([]byte(`[{"ID":"weather-mcp","Namespace":"tools","Status":"running","Meta":{"nomad_mcp_pack_server":"io.github.example/weather-mcp"}}]`))
+	})
+
+	jobs, err := client.ListJobs(context.Background())
+	if err != nil {
+		t.Fatalf("ListJobs() unexpected error = %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != "weather-mcp" || jobs[0].Meta["nomad_mcp_pack_server"] != "io.github.example/weather-mcp" {
+		t.Errorf("ListJobs() = %+v, expected job weather-mcp with its meta", jobs)
+	}
+}
+
+func TestGetJobNotFound(t *testing.T) {
+	client := newTestClient(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	if _, err := client.GetJob(context.Background(), "", "weather-mcp"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetJob() error = %v, expected ErrNotFound", err)
+	}
+
+	deployment, err := client.LatestDeployment(context.Background(), "", "weather-mcp")
+	if err != nil || deployment != nil {
+		t.Errorf("LatestDeployment() = %v, %v, expected no deployment", deployment, err)
+	}
+}
@@ -0,0 +1,56 @@
+package nomad
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ErrCASConflict is returned when a check-and-set write fails because the
+// variable was modified since it was read
+var ErrCASConflict = errors.New("variable was modified concurrently")
+
+// Variable is a Nomad Variable
+type Variable struct {
+	Namespace   string            `json:"Namespace,omitempty"`
+	Path        string            `json:"Path"`
+	Items       map[string]string `json:"Items"`
+	CreateIndex uint64            `json:"CreateIndex,omitempty"`
+	ModifyIndex uint64            `json:"ModifyIndex,omitempty"`
+}
+
+// GetVariable reads the variable at path, returning ErrNotFound if it does not exist
+func (c *Client) GetVariable(ctx context.Context, path string) (*Variable, error) {
+	var v Variable
+	if _, err := c.do(ctx, http.MethodGet, "/v1/var/"+path, nil, nil, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// PutVariable writes v. When cas is true the write only succeeds if the variable's
+// current modify index matches v.ModifyIndex, where 0 means it must not exist yet,
+// and ErrCASConflict is returned otherwise.
+func (c *Client) PutVariable(ctx context.Context, v *Variable, cas bool) (*Variable, error) {
+	query := url.Values{}
+	if cas {
+		query.Set("cas", strconv.FormatUint(v.ModifyIndex, 10))
+	}
+	if v.Namespace != "" {
+		query.Set("namespace", v.Namespace)
+	}
+
+	var out Variable
+	code, err := c.do(ctx, http.MethodPut, "/v1/var/"+v.Path, query, v, &out, http.StatusConflict)
+	if err != nil {
+		return nil, err
+	}
+	if code == http.StatusConflict {
+		return nil, fmt.Errorf("failed to write variable %s at index %d; %w", v.Path, v.ModifyIndex, ErrCASConflict)
+	}
+
+	return &out, nil
+}
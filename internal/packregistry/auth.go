@@ -0,0 +1,140 @@
+package packregistry
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+)
+
+// Authentication methods recorded on identities
+const (
+	AuthMethodToken      = "token"
+	AuthMethodClientCert = "client_cert"
+)
+
+var (
+	errNoCredentials      = errors.New("no credentials")
+	errInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Token is a static API token granting scopes to a named client
+type Token struct {
+	Name   string
+	Token  string
+	Scopes []config.ServerScope
+}
+
+// ClientCert grants scopes to verified client certificates whose common name matches a pattern
+type ClientCert struct {
+	CommonName string
+	Scopes     []config.ServerScope
+}
+
+// Identity is an authenticated client and the scopes it was granted
+type Identity struct {
+	Name   string
+	Method string
+	Scopes []config.ServerScope
+}
+
+func (id *Identity) HasScope(scope config.ServerScope) bool {
+	return slices.Contains(id.Scopes, scope)
+}
+
+// Authenticator identifies clients by API token, sent as a bearer token or a basic auth
+// password, or by a client certificate verified by the TLS listener
+type Authenticator struct {
+	tokens      []tokenIdentity
+	clientCerts []ClientCert
+}
+
+type tokenIdentity struct {
+	hash     [sha256.Size]byte
+	identity Identity
+}
+
+func NewAuthenticator(tokens []Token, clientCerts []ClientCert) *Authenticator {
+	a := &Authenticator{clientCerts: clientCerts}
+	for _, t := range tokens {
+		a.tokens = append(a.tokens, tokenIdentity{
+			hash:     sha256.Sum256([]byte(t.Token)),
+			identity: Identity{Name: t.Name, Method: AuthMethodToken, Scopes: t.Scopes},
+		})
+	}
+
+	return a
+}
+
+// Authenticate returns the identity of the client sending a request. A token takes precedence
+// over a client certificate; a certificate matching no rule is identified without scopes.
+func (a *Authenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if token, ok := requestToken(r); ok {
+		return a.authenticateToken(token)
+	}
+
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.PeerCertificates) > 0 {
+		return a.authenticateClientCert(r.TLS.PeerCertificates[0].Subject.CommonName), nil
+	}
+
+	return nil, errNoCredentials
+}
+
+func (a *Authenticator) authenticateToken(token string) (*Identity, error) {
+	hash := sha256.Sum256([]byte(token))
+
+	// Compare against every token so the time taken doesn't reveal which one matched
+	var match *Identity
+	for i := range a.tokens {
+		if subtle.ConstantTimeCompare(hash[:], a.tokens[i].hash[:]) == 1 {
+			match = &a.tokens[i].identity
+		}
+	}
+	if match == nil {
+		return nil, errInvalidCredentials
+	}
+
+	id := *match
+	return &id, nil
+}
+
+func (a *Authenticator) authenticateClientCert(commonName string) *Identity {
+	id := &Identity{Name: commonName, Method: AuthMethodClientCert}
+
+	for _, rule := range a.clientCerts {
+		if ok, _ := path.Match(rule.CommonName, commonName); !ok {
+			continue
+		}
+		for _, scope := range rule.Scopes {
+			if !id.HasScope(scope) {
+				id.Scopes = append(id.Scopes, scope)
+			}
+		}
+	}
+
+	return id
+}
+
+// requestToken returns the token from a bearer Authorization header, or the password of a basic one
+// so browsers can prompt for it
+func requestToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", false
+	}
+
+	if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token), true
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password, true
+	}
+
+	// An unrecognised scheme is a credential that can't be accepted
+	return "", true
+}
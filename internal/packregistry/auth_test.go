@@ -0,0 +1,184 @@
+package packregistry
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+)
+
+var testTokens = []Token{
+	{Name: "reader", Token: "read-token", Scopes: []config.ServerScope{config.ServerScopeRead}},
+	{Name: "generator", Token: "generate-token", Scopes: []config.ServerScope{config.ServerScopeGenerate}},
+}
+
+func TestTokenAuthentication(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(NewCatalog(t.TempDir(), nil, ""), NewAuthenticator(testTokens, nil)))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name      string
+		setHeader func(r *http.Request)
+		path      string
+		status    int
+	}{
+		{
+			name:      "no credentials",
+			setHeader: func(r *http.Request) {},
+			path:      "/v1/packs",
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "bearer token",
+			setHeader: func(r *http.Request) { r.Header.Set("Authorization", "Bearer read-token") },
+			path:      "/v1/packs",
+			status:    http.StatusOK,
+		},
+		{
+			name:      "basic auth password",
+			setHeader: func(r *http.Request) { r.SetBasicAuth("anyone", "read-token") },
+			path:      "/",
+			status:    http.StatusOK,
+		},
+		{
+			name:      "unknown token",
+			setHeader: func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong-token") },
+			path:      "/v1/packs",
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "unsupported scheme",
+			setHeader: func(r *http.Request) { r.Header.Set("Authorization", "Digest read-token") },
+			path:      "/v1/packs",
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "token without read scope",
+			setHeader: func(r *http.Request) { r.Header.Set("Authorization", "Bearer generate-token") },
+			path:      "/v1/packs",
+			status:    http.StatusForbidden,
+		},
+		{
+			name:      "health check without credentials",
+			setHeader: func(r *http.Request) {},
+			path:      "/healthz",
+			status:    http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.setHeader(req)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, expected %d", resp.StatusCode, tt.status)
+			}
+			if tt.status == http.StatusUnauthorized && len(resp.Header.Values("WWW-Authenticate")) == 0 {
+				t.Errorf("401 response has no WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestClientCertAuthentication(t *testing.T) {
+	ca, caKey := newTestCertificate(t, "test-ca", nil, nil)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.Leaf)
+
+	auth := NewAuthenticator(testTokens, []ClientCert{
+		{CommonName: "*.ops.example.com", Scopes: []config.ServerScope{config.ServerScopeRead}},
+	})
+
+	srv := httptest.NewUnstartedServer(NewHandler(NewCatalog(t.TempDir(), nil, ""), auth))
+	srv.TLS = &tls.Config{ClientCAs: clientCAs, ClientAuth: tls.VerifyClientCertIfGiven}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name       string
+		commonName string
+		status     int
+	}{
+		{name: "matching certificate", commonName: "deploy.ops.example.com", status: http.StatusOK},
+		{name: "certificate matching no rule", commonName: "laptop.example.com", status: http.StatusForbidden},
+		{name: "no certificate", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := srv.Client().Transport.(*http.Transport).Clone()
+			if tt.commonName != "" {
+				cert, _ := newTestCertificate(t, tt.commonName, ca.Leaf, caKey)
+				transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+			}
+			client := &http.Client{Transport: transport}
+
+			resp, err := client.Get(srv.URL + "/v1/packs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, expected %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+// newTestCertificate returns a certificate for the common name signed by parent, or a
+// self-signed CA certificate when parent is nil
+func newTestCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (tls.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, key
+}
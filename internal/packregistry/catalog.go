@@ -0,0 +1,246 @@
+package packregistry
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/watcher"
+	"github.com/leefowlercu/nomad-mcp-pack/pkg/packapi"
+	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
+	"github.com/modelcontextprotocol/registry/pkg/model"
+)
+
+// ErrPackNotFound is returned for pack names that aren't in the output directory
+var ErrPackNotFound = errors.New("pack not found")
+
+// Catalog reads the packs generated in an output directory. The watch state, if any,
+// adds the server, package and generation details that metadata.hcl doesn't record.
+type Catalog struct {
+	dir            string
+	store          statestore.Store
+	legacyRegistry string // Registry of servers in state saved before multiple registries were supported
+}
+
+func NewCatalog(dir string, store statestore.Store, legacyRegistry string) *Catalog {
+	return &Catalog{
+		dir:            dir,
+		store:          store,
+		legacyRegistry: legacyRegistry,
+	}
+}
+
+// List returns the packs in the output directory sorted by name. Pack directories and
+// archives that can't be read are logged and left out.
+func (c *Catalog) List(ctx context.Context) ([]packapi.Pack, error) {
+	return c.list(ctx, false)
+}
+
+func (c *Catalog) list(ctx context.Context, withFiles bool) ([]packapi.Pack, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []packapi.Pack{}, nil
+		}
+		return nil, fmt.Errorf("failed to read output directory; %w", err)
+	}
+
+	generated := c.loadState(ctx)
+
+	packs := []packapi.Pack{}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		// A pack directory takes precedence over an archive of the same name
+		name, ok := packName(entry)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		pack, err := c.readPack(name, generated, withFiles)
+		if errors.Is(err, ErrPackNotFound) {
+			continue
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "skipping unreadable pack", "pack", name, "error", err)
+			continue
+		}
+		packs = append(packs, *pack)
+	}
+
+	slices.SortFunc(packs, func(a, b packapi.Pack) int { return strings.Compare(a.Name, b.Name) })
+
+	return packs, nil
+}
+
+// Get returns a pack with the list of its files
+func (c *Catalog) Get(ctx context.Context, name string) (*packapi.Pack, error) {
+	return c.readPack(name, c.loadState(ctx), true)
+}
+
+// Open returns the pack's files, and the path of its archive if it is stored as one
+func (c *Catalog) Open(name string) (fs.FS, string, io.Closer, error) {
+	if !validPackName(name) {
+		return nil, "", nil, ErrPackNotFound
+	}
+
+	dir := filepath.Join(c.dir, name)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		if _, err := os.Stat(filepath.Join(dir, "metadata.hcl")); err != nil {
+			return nil, "", nil, ErrPackNotFound
+		}
+		return os.DirFS(dir), "", io.NopCloser(nil), nil
+	}
+
+	archive := dir + ".zip"
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, "", nil, ErrPackNotFound
+		}
+		return nil, "", nil, fmt.Errorf("failed to open pack archive; %w", err)
+	}
+
+	return r, archive, r, nil
+}
+
+func (c *Catalog) readPack(name string, generated map[string]*watcher.ServerState, withFiles bool) (*packapi.Pack, error) {
+	files, archive, closer, err := c.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	data, err := fs.ReadFile(files, "metadata.hcl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack metadata; %w", err)
+	}
+	meta := parseMetadata(data)
+
+	path := filepath.Join(c.dir, name)
+	if archive != "" {
+		path = archive
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat pack; %w", err)
+	}
+
+	pack := &packapi.Pack{
+		Name:        name,
+		Description: meta.Description,
+		Version:     meta.Version,
+		AppURL:      meta.AppURL,
+		Registry:    meta.Registry,
+		RegistryURL: meta.RegistryURL,
+		Archive:     archive != "",
+		ModifiedAt:  info.ModTime().UTC(),
+	}
+
+	if s, ok := generated[name]; ok {
+		pack.Registry = s.Registry
+		pack.ServerName = s.Namespace + "/" + s.Name
+		pack.PackageType = s.PackageType
+		pack.TransportType = s.TransportType
+		pack.GeneratedAt = s.GeneratedAt
+		pack.UpdatedAt = s.UpdatedAt
+		pack.Checksum = s.Checksum
+	}
+
+	if withFiles {
+		pack.Files, err = listFiles(files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pack files; %w", err)
+		}
+	}
+
+	return pack, nil
+}
+
+// loadState returns the successfully generated state entries by pack name. State that
+// can't be read is logged and packs are listed from their metadata alone.
+func (c *Catalog) loadState(ctx context.Context) map[string]*watcher.ServerState {
+	generated := make(map[string]*watcher.ServerState)
+	if c.store == nil {
+		return generated
+	}
+
+	data, err := c.store.Load(ctx)
+	if err != nil || data == nil {
+		if err != nil {
+			slog.WarnContext(ctx, "failed to load watch state, listing packs without it", "store", c.store.String(), "error", err)
+		}
+		return generated
+	}
+
+	state, err := watcher.ParseState(data, c.legacyRegistry)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to parse watch state, listing packs without it", "store", c.store.String(), "error", err)
+		return generated
+	}
+
+	for _, s := range state.Servers {
+		if s.Failed || s.GeneratedAt.IsZero() {
+			continue
+		}
+
+		name := generator.PackName(
+			&v0.ServerJSON{Name: s.Namespace + "/" + s.Name, Version: s.Version},
+			&model.Package{RegistryType: s.PackageType, Transport: model.Transport{Type: s.TransportType}},
+		)
+
+		// The same server from several registries is written to the same pack, so the latest wins
+		if prev, ok := generated[name]; !ok || s.GeneratedAt.After(prev.GeneratedAt) {
+			generated[name] = s
+		}
+	}
+
+	return generated
+}
+
+// packName returns the name of the pack stored in an output directory entry
+func packName(entry fs.DirEntry) (string, bool) {
+	name := entry.Name()
+	if strings.HasPrefix(name, ".") {
+		return "", false
+	}
+
+	if entry.IsDir() {
+		return name, true
+	}
+	if base, ok := strings.CutSuffix(name, ".zip"); ok && entry.Type().IsRegular() {
+		return base, true
+	}
+
+	return "", false
+}
+
+// validPackName rejects names that could escape the output directory
+func validPackName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`)
+}
+
+func listFiles(files fs.FS) ([]string, error) {
+	var paths []string
+	err := fs.WalkDir(files, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+
+	return paths, err
+}
@@ -0,0 +1,418 @@
+package packregistry
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
+	"github.com/leefowlercu/nomad-mcp-pack/pkg/packapi"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var catalogTemplate = template.Must(template.ParseFS(templateFS, "templates/catalog.html.tmpl"))
+
+// NewHandler serves the catalog read-only: an HTML catalog page at /, and the pack list,
+// pack details, downloads and individual files under /v1/packs, described by an OpenAPI
+// document at /openapi.json. With an authenticator, every endpoint but /healthz and
+// /openapi.json requires the read scope; without one, access is open.
+func NewHandler(catalog *Catalog, auth *Authenticator) http.Handler {
+	h := &handler{catalog: catalog, auth: auth}
+
+	routes := h.routes()
+
+	// The document is built from the routes served, so it can't fall out of step with them
+	h.openAPI = newOpenAPIDocument(routes, auth != nil)
+
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		mux.HandleFunc(rt.pattern, h.require(rt.scope, rt.handler))
+	}
+
+	return mux
+}
+
+type handler struct {
+	catalog *Catalog
+	auth    *Authenticator
+	openAPI map[string]any
+}
+
+// routes returns the endpoints served, with the operations documenting them
+func (h *handler) routes() []route {
+	nameParam := parameter{name: "name", in: "path", description: "Pack name"}
+	notFound := errorResponse(http.StatusNotFound, "Pack not found")
+	internalError := errorResponse(http.StatusInternalServerError, "The pack could not be read")
+
+	return []route{
+		{
+			pattern: "GET /{$}",
+			scope:   config.ServerScopeRead,
+			handler: h.catalogPage,
+			operation: operation{
+				id:      "getCatalogPage",
+				summary: "HTML catalog of the generated packs",
+				responses: []response{
+					{status: http.StatusOK, description: "Catalog page", contentTypes: []string{"text/html"}},
+					{status: http.StatusInternalServerError, description: "The packs could not be listed", contentTypes: []string{"text/plain"}},
+				},
+			},
+		},
+		{
+			pattern: "GET /healthz",
+			handler: h.healthz,
+			operation: operation{
+				id:        "getHealth",
+				summary:   "Liveness check",
+				responses: []response{jsonResponse(http.StatusOK, "The server is running", packapi.Health{})},
+			},
+		},
+		{
+			pattern: "GET /openapi.json",
+			handler: h.openAPIDocument,
+			operation: operation{
+				id:        "getOpenAPIDocument",
+				summary:   "This OpenAPI document",
+				responses: []response{jsonResponse(http.StatusOK, "OpenAPI document", map[string]any{})},
+			},
+		},
+		{
+			pattern: "GET /v1/packs",
+			scope:   config.ServerScopeRead,
+			handler: h.listPacks,
+			operation: operation{
+				id:          "listPacks",
+				summary:     "List packs",
+				description: "Lists the packs in the output directory, without their files.",
+				responses: []response{
+					jsonResponse(http.StatusOK, "Packs sorted by name", packapi.PackList{}),
+					errorResponse(http.StatusInternalServerError, "The packs could not be listed"),
+				},
+			},
+		},
+		{
+			pattern: "GET /v1/packs/{name}",
+			scope:   config.ServerScopeRead,
+			handler: h.getPack,
+			operation: operation{
+				id:         "getPack",
+				summary:    "Pack details and files",
+				parameters: []parameter{nameParam},
+				responses: []response{
+					jsonResponse(http.StatusOK, "Pack details", packapi.Pack{}),
+					notFound,
+					internalError,
+				},
+			},
+		},
+		{
+			pattern: "GET /v1/packs/{name}/download",
+			scope:   config.ServerScopeRead,
+			handler: h.downloadPack,
+			operation: operation{
+				id:      "downloadPack",
+				summary: "Download a pack",
+				parameters: []parameter{
+					nameParam,
+					{name: "format", in: "query", description: "Archive format", enum: []string{packapi.FormatZip, packapi.FormatTarGz}, defaultValue: packapi.FormatZip},
+				},
+				responses: []response{
+					{status: http.StatusOK, description: "Pack archive", contentTypes: []string{"application/zip", "application/gzip"}},
+					errorResponse(http.StatusBadRequest, "Invalid format"),
+					notFound,
+					internalError,
+				},
+			},
+		},
+		{
+			pattern: "GET /v1/packs/{name}/files/{path...}",
+			scope:   config.ServerScopeRead,
+			handler: h.getFile,
+			operation: operation{
+				id:      "getPackFile",
+				summary: "Fetch a single pack file",
+				parameters: []parameter{
+					nameParam,
+					{name: "path", in: "path", description: "Path of the file in the pack, which may contain slashes"},
+				},
+				responses: []response{
+					{status: http.StatusOK, description: "File content", contentTypes: []string{"text/plain"}},
+					errorResponse(http.StatusNotFound, "Pack or file not found"),
+					internalError,
+				},
+			},
+		},
+	}
+}
+
+// require authenticates requests and rejects those without the scope, recording the
+// client's identity in the request context for logging. Routes without a scope are public.
+func (h *handler) require(scope config.ServerScope, next http.HandlerFunc) http.HandlerFunc {
+	if h.auth == nil || scope == "" {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := h.auth.Authenticate(r)
+		if err != nil {
+			slog.WarnContext(r.Context(), "rejected unauthenticated request",
+				"method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr, "error", err)
+			w.Header().Add("WWW-Authenticate", `Bearer realm="nomad-mcp-pack"`)
+			w.Header().Add("WWW-Authenticate", `Basic realm="nomad-mcp-pack"`)
+			writeError(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		ctx := utils.WithUserID(r.Context(), id.Name)
+		if !id.HasScope(scope) {
+			slog.WarnContext(ctx, "rejected request without required scope",
+				"method", r.Method, "path", r.URL.Path, "auth_method", id.Method, "scope", scope)
+			writeError(w, http.StatusForbidden, fmt.Sprintf("%s scope required", scope))
+			return
+		}
+
+		slog.InfoContext(ctx, "authorized request", "method", r.Method, "path", r.URL.Path, "auth_method", id.Method)
+
+		next(w, r.WithContext(ctx))
+	}
+}
+
+func (h *handler) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, packapi.Health{Status: "ok"})
+}
+
+func (h *handler) openAPIDocument(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.openAPI)
+}
+
+func (h *handler) catalogPage(w http.ResponseWriter, r *http.Request) {
+	// The page lists each pack's files, which the API leaves to the pack details
+	packs, err := h.catalog.list(r.Context(), true)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list packs", "error", err)
+		http.Error(w, "failed to list packs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := catalogTemplate.Execute(w, packs); err != nil {
+		slog.WarnContext(r.Context(), "failed to render catalog page", "error", err)
+	}
+}
+
+func (h *handler) listPacks(w http.ResponseWriter, r *http.Request) {
+	packs, err := h.catalog.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list packs", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to list packs")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, packapi.PackList{Packs: packs})
+}
+
+func (h *handler) getPack(w http.ResponseWriter, r *http.Request) {
+	pack, err := h.catalog.Get(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.packError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pack)
+}
+
+func (h *handler) downloadPack(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = packapi.FormatZip
+	}
+	if format != packapi.FormatZip && format != packapi.FormatTarGz {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid format %q; must be one of [%s %s]", format, packapi.FormatZip, packapi.FormatTarGz))
+		return
+	}
+
+	files, archive, closer, err := h.catalog.Open(name)
+	if err != nil {
+		h.packError(w, r, err)
+		return
+	}
+	defer closer.Close()
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + "." + format}))
+
+	// Archived packs are served as they were written
+	if format == packapi.FormatZip && archive != "" {
+		f, err := os.Open(archive)
+		if err != nil {
+			h.packError(w, r, err)
+			return
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			h.packError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		http.ServeContent(w, r, name+".zip", info.ModTime(), f)
+		return
+	}
+
+	if format == packapi.FormatZip {
+		w.Header().Set("Content-Type", "application/zip")
+		err = writeZip(w, files)
+	} else {
+		w.Header().Set("Content-Type", "application/gzip")
+		err = writeTarGz(w, files)
+	}
+	if err != nil {
+		// The status has been sent, so the client sees a truncated archive
+		slog.ErrorContext(r.Context(), "failed to write pack archive", "pack", name, "format", format, "error", err)
+	}
+}
+
+func (h *handler) getFile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	filePath := r.PathValue("path")
+
+	files, _, closer, err := h.catalog.Open(name)
+	if err != nil {
+		h.packError(w, r, err)
+		return
+	}
+	defer closer.Close()
+
+	if !fs.ValidPath(filePath) {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+
+	if info, err := fs.Stat(files, filePath); err == nil && !info.Mode().IsRegular() {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+
+	data, err := fs.ReadFile(files, filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+			writeError(w, http.StatusNotFound, "file not found")
+			return
+		}
+		h.packError(w, r, err)
+		return
+	}
+
+	// Pack files are text, whatever their extension suggests
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": path.Base(filePath)}))
+	w.Write(data)
+}
+
+func (h *handler) packError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrPackNotFound) {
+		writeError(w, http.StatusNotFound, "pack not found")
+		return
+	}
+
+	slog.ErrorContext(r.Context(), "failed to read pack", "path", r.URL.Path, "error", err)
+	writeError(w, http.StatusInternalServerError, "failed to read pack")
+}
+
+// writeZip writes the pack's files to a ZIP archive, laid out like archives written by the generator
+func writeZip(w io.Writer, files fs.FS) error {
+	zw := zip.NewWriter(w)
+
+	paths, err := listFiles(files)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		dst, err := zw.Create(p)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(dst, files, p); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// writeTarGz writes the pack's files to a gzipped tarball, laid out like the ZIP archives
+func writeTarGz(w io.Writer, files fs.FS) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	paths, err := listFiles(files)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		info, err := fs.Stat(files, p)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = p
+		header.Mode = 0644
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := copyFile(tw, files, p); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func copyFile(dst io.Writer, files fs.FS, name string) error {
+	f, err := files.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(dst, f)
+	return err
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, packapi.ErrorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("failed to write pack registry response", "error", err)
+	}
+}
@@ -0,0 +1,74 @@
+package packregistry
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// metadata is the content of a generated metadata.hcl
+type metadata struct {
+	Description string
+	Version     string
+	AppURL      string
+	Registry    string
+	RegistryURL string
+}
+
+// parseMetadata reads the attributes of the app and pack blocks written by the generator,
+// along with the registry comment. It is not a general HCL parser.
+func parseMetadata(data []byte) metadata {
+	var meta metadata
+	var block string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if comment, ok := strings.CutPrefix(line, "# Generated from the "); ok {
+			// # Generated from the <name> MCP registry at <url>
+			if name, url, ok := strings.Cut(comment, " MCP registry at "); ok {
+				meta.Registry = name
+				meta.RegistryURL = url
+			}
+			continue
+		}
+
+		if name, ok := strings.CutSuffix(line, "{"); ok {
+			block = strings.TrimSpace(name)
+			continue
+		}
+		if line == "}" {
+			block = ""
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		switch block + "." + strings.TrimSpace(key) {
+		case "app.url":
+			meta.AppURL = unquote(value)
+		case "pack.description":
+			meta.Description = unquote(value)
+		case "pack.version":
+			meta.Version = unquote(value)
+		}
+	}
+
+	return meta
+}
+
+// unquote returns a quoted string value, falling back to the text between the outer
+// quotes as the generator doesn't escape descriptions
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if s, err := strconv.Unquote(value); err == nil {
+		return s
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(value, `"`), `"`)
+}
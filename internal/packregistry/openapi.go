@@ -0,0 +1,249 @@
+package packregistry
+
+import (
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/pkg/packapi"
+)
+
+// route is an endpoint served by the handler and the operation documenting it
+type route struct {
+	pattern   string             // ServeMux pattern
+	scope     config.ServerScope // Scope required when authentication is configured; empty for public routes
+	handler   http.HandlerFunc
+	operation operation
+}
+
+type operation struct {
+	id          string
+	summary     string
+	description string
+	parameters  []parameter
+	responses   []response
+}
+
+type parameter struct {
+	name         string
+	in           string // path or query
+	description  string
+	enum         []string
+	defaultValue string
+}
+
+type response struct {
+	status       int
+	description  string
+	contentTypes []string
+	body         any // A value of the JSON body's type, for JSON responses
+}
+
+func jsonResponse(status int, description string, body any) response {
+	return response{status: status, description: description, contentTypes: []string{"application/json"}, body: body}
+}
+
+func errorResponse(status int, description string) response {
+	return jsonResponse(status, description, packapi.ErrorResponse{})
+}
+
+// Security schemes documented when authentication is configured
+var securitySchemes = map[string]any{
+	"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "description": "API token"},
+	"basicAuth":  map[string]any{"type": "http", "scheme": "basic", "description": "API token as the password; the username is ignored"},
+	"mutualTLS":  map[string]any{"type": "mutualTLS", "description": "Client certificate verified against the server's client CA"},
+}
+
+// newOpenAPIDocument returns an OpenAPI 3.1 document for the routes, with JSON body
+// schemas generated from the Go types the handlers encode
+func newOpenAPIDocument(routes []route, authenticated bool) map[string]any {
+	schemas := schemaBuilder{schemas: map[string]any{}}
+
+	paths := map[string]any{}
+	for _, rt := range routes {
+		method, pattern, _ := strings.Cut(rt.pattern, " ")
+		p := openAPIPath(pattern)
+
+		item, ok := paths[p].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[p] = item
+		}
+		item[strings.ToLower(method)] = schemas.operation(rt, authenticated)
+	}
+
+	components := map[string]any{"schemas": schemas.schemas}
+	if authenticated {
+		components["securitySchemes"] = securitySchemes
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "nomad-mcp-pack pack registry",
+			"description": "Read-only registry of the Nomad Packs generated by nomad-mcp-pack",
+			"version":     packapi.APIVersion,
+		},
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+// openAPIPath converts a ServeMux path pattern to an OpenAPI path template
+func openAPIPath(pattern string) string {
+	pattern = strings.ReplaceAll(pattern, "{$}", "")
+	pattern = strings.ReplaceAll(pattern, "...}", "}")
+	if pattern == "" {
+		return "/"
+	}
+	return pattern
+}
+
+// schemaBuilder generates JSON schemas for Go types, collecting named struct types as components
+type schemaBuilder struct {
+	schemas map[string]any
+}
+
+func (b *schemaBuilder) operation(rt route, authenticated bool) map[string]any {
+	op := map[string]any{
+		"operationId": rt.operation.id,
+		"summary":     rt.operation.summary,
+	}
+	if rt.operation.description != "" {
+		op["description"] = rt.operation.description
+	}
+
+	if len(rt.operation.parameters) > 0 {
+		var params []any
+		for _, p := range rt.operation.parameters {
+			schema := map[string]any{"type": "string"}
+			if len(p.enum) > 0 {
+				schema["enum"] = p.enum
+			}
+			if p.defaultValue != "" {
+				schema["default"] = p.defaultValue
+			}
+			params = append(params, map[string]any{
+				"name":        p.name,
+				"in":          p.in,
+				"description": p.description,
+				"required":    p.in == "path",
+				"schema":      schema,
+			})
+		}
+		op["parameters"] = params
+	}
+
+	responses := rt.operation.responses
+	if authenticated && rt.scope != "" {
+		var security []any
+		for _, name := range slices.Sorted(maps.Keys(securitySchemes)) {
+			security = append(security, map[string]any{name: []string{string(rt.scope)}})
+		}
+		op["security"] = security
+
+		responses = append(responses,
+			errorResponse(http.StatusUnauthorized, "Missing or invalid credentials"),
+			errorResponse(http.StatusForbidden, "The "+string(rt.scope)+" scope is required"),
+		)
+	}
+
+	docs := map[string]any{}
+	for _, resp := range responses {
+		content := map[string]any{}
+		for _, contentType := range resp.contentTypes {
+			content[contentType] = map[string]any{"schema": b.contentSchema(contentType, resp.body)}
+		}
+		docs[strconv.Itoa(resp.status)] = map[string]any{"description": resp.description, "content": content}
+	}
+	op["responses"] = docs
+
+	return op
+}
+
+func (b *schemaBuilder) contentSchema(contentType string, body any) map[string]any {
+	switch {
+	case body != nil:
+		return b.schema(reflect.TypeOf(body))
+	case strings.HasPrefix(contentType, "text/"):
+		return map[string]any{"type": "string"}
+	default:
+		return map[string]any{"type": "string", "contentMediaType": contentType}
+	}
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	if t == reflect.TypeFor[time.Time]() {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		if _, ok := b.schemas[t.Name()]; !ok {
+			// Reserve the name first so recursive types refer to it rather than recursing
+			b.schemas[t.Name()] = nil
+			b.schemas[t.Name()] = b.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		// Interfaces and anything else can hold any value
+		return map[string]any{}
+	}
+}
+
+// structSchema describes a struct as encoding/json encodes it. Fields that are always
+// encoded are required, and a field's description tag describes its property.
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := b.schema(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+		properties[name] = schema
+
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
@@ -0,0 +1,132 @@
+package packregistry
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testOpenAPIDocument struct {
+	OpenAPI string                                     `json:"openapi"`
+	Paths   map[string]map[string]testOpenAPIOperation `json:"paths"`
+
+	Components struct {
+		Schemas         map[string]map[string]any `json:"schemas"`
+		SecuritySchemes map[string]any            `json:"securitySchemes"`
+	} `json:"components"`
+}
+
+type testOpenAPIOperation struct {
+	Security  []map[string][]string `json:"security"`
+	Responses map[string]struct {
+		Content map[string]any `json:"content"`
+	} `json:"responses"`
+}
+
+func getOpenAPIDocument(t *testing.T, url string) testOpenAPIDocument {
+	t.Helper()
+
+	resp, body := get(t, url+"/openapi.json")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, expected %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var doc testOpenAPIDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+// TestOpenAPIDocument calls every documented operation, checking the handler serves it
+// with a documented status and content type
+func TestOpenAPIDocument(t *testing.T) {
+	srv := newTestServer(t)
+	doc := getOpenAPIDocument(t, srv.URL)
+
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q, expected 3.1.0", doc.OpenAPI)
+	}
+
+	routes := (&handler{}).routes()
+	operations := 0
+	for _, ops := range doc.Paths {
+		operations += len(ops)
+	}
+	if operations != len(routes) {
+		t.Errorf("document has %d operations, expected one for each of the %d routes", operations, len(routes))
+	}
+
+	params := strings.NewReplacer("{name}", dirPack, "{path}", "templates/weather-mcp.nomad.tpl")
+	for p, ops := range doc.Paths {
+		for method, op := range ops {
+			t.Run(strings.ToUpper(method)+" "+p, func(t *testing.T) {
+				req, err := http.NewRequest(strings.ToUpper(method), srv.URL+params.Replace(p), nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+
+				documented, ok := op.Responses[strconv.Itoa(resp.StatusCode)]
+				if !ok {
+					t.Fatalf("status %d is not documented", resp.StatusCode)
+				}
+
+				contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+				if _, ok := documented.Content[contentType]; !ok {
+					t.Errorf("content type %q is not documented", contentType)
+				}
+			})
+		}
+	}
+
+	pack := doc.Components.Schemas["Pack"]
+	if pack == nil {
+		t.Fatal("Pack schema is missing")
+	}
+	required, _ := pack["required"].([]any)
+	for _, name := range []string{"name", "archive", "modified_at"} {
+		if !slices.Contains(required, any(name)) {
+			t.Errorf("Pack schema doesn't require %q", name)
+		}
+	}
+	if slices.Contains(required, any("files")) {
+		t.Errorf("Pack schema requires files, which are omitted from the pack list")
+	}
+}
+
+func TestOpenAPIDocumentSecurity(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(NewCatalog(t.TempDir(), nil, ""), NewAuthenticator(testTokens, nil)))
+	t.Cleanup(srv.Close)
+
+	// The document is public, like the health check
+	doc := getOpenAPIDocument(t, srv.URL)
+
+	if len(doc.Components.SecuritySchemes) == 0 {
+		t.Error("security schemes are missing")
+	}
+
+	list := doc.Paths["/v1/packs"]["get"]
+	if len(list.Security) == 0 {
+		t.Error("pack list has no security requirements")
+	}
+	for _, status := range []string{"401", "403"} {
+		if _, ok := list.Responses[status]; !ok {
+			t.Errorf("pack list doesn't document status %s", status)
+		}
+	}
+
+	if health := doc.Paths["/healthz"]["get"]; len(health.Security) != 0 {
+		t.Error("health check has security requirements")
+	}
+}
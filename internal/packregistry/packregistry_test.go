@@ -0,0 +1,365 @@
+package packregistry
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+	"github.com/leefowlercu/nomad-mcp-pack/pkg/packapi"
+)
+
+const dirPack = "io-github-example-weather-mcp-1-0-0-npm-stdio"
+
+const zipPack = "ai-waystation-gmail-0-3-0-pypi-stdio"
+
+const dirPackMetadata = `# Generated from the official MCP registry at https://registry.modelcontextprotocol.io
+
+app {
+  url = "https://github.com/example/weather-mcp"
+}
+
+pack {
+  name        = "io-github-example-weather-mcp-1-0-0-npm-stdio"
+  description = "Weather forecasts for MCP clients"
+  version     = "1.0.0"
+}
+`
+
+const zipPackMetadata = `app {
+  url = "https://github.com/waystation/gmail"
+}
+
+pack {
+  name        = "ai-waystation-gmail-0-3-0-pypi-stdio"
+  description = "Gmail for MCP clients"
+  version     = "0.3.0"
+}
+`
+
+const testState = `{
+  "schema_version": 3,
+  "last_poll": "2026-10-01T00:00:00Z",
+  "servers": {
+    "official:io.github.example/weather-mcp@1.0.0:npm:stdio": {
+      "registry": "official",
+      "namespace": "io.github.example",
+      "name": "weather-mcp",
+      "version": "1.0.0",
+      "package_type": "npm",
+      "transport_type": "stdio",
+      "updated_at": "2026-09-30T00:00:00Z",
+      "generated_at": "2026-10-01T00:00:00Z",
+      "checksum": "abc123"
+    }
+  }
+}`
+
+// newTestServer writes a pack directory and a pack archive to an output directory and serves them
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	packDir := filepath.Join(dir, dirPack)
+	if err := os.MkdirAll(filepath.Join(packDir, "templates"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"metadata.hcl":                    dirPackMetadata,
+		"variables.hcl":                   "variable \"count\" {}\n",
+		"templates/weather-mcp.nomad.tpl": "job \"weather-mcp\" {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(packDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"metadata.hcl": zipPackMetadata, "README.md": "# gmail\n"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, zipPack+".zip"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither is a pack, so neither is listed
+	os.MkdirAll(filepath.Join(dir, "not-a-pack"), 0755)
+	os.WriteFile(filepath.Join(dir, ".hidden.zip"), []byte("not a zip"), 0644)
+
+	statePath := filepath.Join(t.TempDir(), "watch.json")
+	if err := os.WriteFile(statePath, []byte(testState), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(NewHandler(NewCatalog(dir, statestore.NewFileStore(statePath), ""), nil))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func get(t *testing.T, url string) (*http.Response, []byte) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp, body
+}
+
+func TestListPacks(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, body := get(t, srv.URL+"/v1/packs")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, expected %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var list packapi.PackList
+	if err := json.Unmarshal(body, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Packs) != 2 {
+		t.Fatalf("listed %d packs, expected 2: %s", len(list.Packs), body)
+	}
+
+	gmail, weather := list.Packs[0], list.Packs[1]
+	if gmail.Name != zipPack || weather.Name != dirPack {
+		t.Fatalf("packs = [%s %s], expected sorted by name", gmail.Name, weather.Name)
+	}
+
+	if !gmail.Archive || gmail.Description != "Gmail for MCP clients" || gmail.Version != "0.3.0" {
+		t.Errorf("archived pack = %+v, expected its metadata", gmail)
+	}
+	if gmail.ServerName != "" || !gmail.GeneratedAt.IsZero() {
+		t.Errorf("archived pack = %+v, expected no state details", gmail)
+	}
+
+	if weather.Archive || weather.AppURL != "https://github.com/example/weather-mcp" {
+		t.Errorf("directory pack = %+v, expected its metadata", weather)
+	}
+	if weather.Registry != "official" || weather.RegistryURL != "https://registry.modelcontextprotocol.io" {
+		t.Errorf("directory pack registry = %q %q, expected the official registry", weather.Registry, weather.RegistryURL)
+	}
+	if weather.ServerName != "io.github.example/weather-mcp" || weather.PackageType != "npm" || weather.Checksum != "abc123" {
+		t.Errorf("directory pack = %+v, expected the state details", weather)
+	}
+	if weather.Files != nil {
+		t.Errorf("list files = %v, expected files only in the pack details", weather.Files)
+	}
+}
+
+func TestGetPack(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, body := get(t, srv.URL+"/v1/packs/"+dirPack)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, expected %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var pack packapi.Pack
+	if err := json.Unmarshal(body, &pack); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"metadata.hcl", "templates/weather-mcp.nomad.tpl", "variables.hcl"}
+	if !slices.Equal(pack.Files, expected) {
+		t.Errorf("files = %v, expected %v", pack.Files, expected)
+	}
+
+	for _, name := range []string{"missing", "not-a-pack", ".hidden", "..", "%2e%2e"} {
+		resp, _ := get(t, srv.URL+"/v1/packs/"+name)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET /v1/packs/%s status = %d, expected %d", name, resp.StatusCode, http.StatusNotFound)
+		}
+	}
+}
+
+func TestGetFile(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		contains string
+	}{
+		{
+			name:     "directory pack file",
+			path:     "/v1/packs/" + dirPack + "/files/templates/weather-mcp.nomad.tpl",
+			status:   http.StatusOK,
+			contains: `job "weather-mcp"`,
+		},
+		{
+			name:     "archived pack file",
+			path:     "/v1/packs/" + zipPack + "/files/README.md",
+			status:   http.StatusOK,
+			contains: "# gmail",
+		},
+		{
+			name:   "missing file",
+			path:   "/v1/packs/" + dirPack + "/files/missing.hcl",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "directory",
+			path:   "/v1/packs/" + dirPack + "/files/templates",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "path traversal",
+			path:   "/v1/packs/" + dirPack + "/files/..%2f..%2fwatch.json",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "missing pack",
+			path:   "/v1/packs/missing/files/metadata.hcl",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(t, srv.URL+tt.path)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, expected %d: %s", resp.StatusCode, tt.status, body)
+			}
+			if tt.contains != "" && !strings.Contains(string(body), tt.contains) {
+				t.Errorf("body = %q, expected to contain %q", body, tt.contains)
+			}
+		})
+	}
+}
+
+func TestDownloadPack(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, name := range []string{dirPack, zipPack} {
+		t.Run(name+" zip", func(t *testing.T) {
+			resp, body := get(t, srv.URL+"/v1/packs/"+name+"/download")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, expected %d", resp.StatusCode, http.StatusOK)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "application/zip" {
+				t.Errorf("content type = %q, expected application/zip", ct)
+			}
+
+			r, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.Open("metadata.hcl"); err != nil {
+				t.Errorf("archive has no top-level metadata.hcl; %v", err)
+			}
+		})
+
+		t.Run(name+" tar.gz", func(t *testing.T) {
+			resp, body := get(t, srv.URL+"/v1/packs/"+name+"/download?format=tar.gz")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, expected %d", resp.StatusCode, http.StatusOK)
+			}
+
+			gr, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr := tar.NewReader(gr)
+
+			var names []string
+			for {
+				header, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, header.Name)
+			}
+			if !slices.Contains(names, "metadata.hcl") {
+				t.Errorf("tarball files = %v, expected a top-level metadata.hcl", names)
+			}
+		})
+	}
+
+	resp, _ := get(t, srv.URL+"/v1/packs/"+dirPack+"/download?format=rar")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid format status = %d, expected %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp, _ = get(t, srv.URL+"/v1/packs/missing/download")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing pack status = %d, expected %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestCatalogPage(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, body := get(t, srv.URL+"/")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, expected %d", resp.StatusCode, http.StatusOK)
+	}
+
+	for _, expected := range []string{dirPack, zipPack, "/v1/packs/" + dirPack + "/files/variables.hcl", "format=tar.gz"} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("catalog page does not contain %q", expected)
+		}
+	}
+
+	resp, _ = get(t, srv.URL+"/missing")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown path status = %d, expected %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestCatalogWithoutState(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, dirPack), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, dirPack, "metadata.hcl"), []byte(dirPackMetadata), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog := NewCatalog(dir, statestore.NewFileStore(filepath.Join(dir, "missing.json")), "")
+	packs, err := catalog.List(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packs) != 1 || packs[0].Description != "Weather forecasts for MCP clients" {
+		t.Errorf("packs = %+v, expected the pack from its metadata alone", packs)
+	}
+
+	packs, err = NewCatalog(filepath.Join(dir, "missing"), nil, "").List(t.Context())
+	if err != nil || len(packs) != 0 {
+		t.Errorf("List() = %v, %v, expected no packs for a missing output directory", packs, err)
+	}
+}
@@ -0,0 +1,480 @@
+package packrender
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The Nomad API job structure, limited to the fields generated job templates set. Nomad
+// fills in the defaults of the other fields when the job is registered.
+type apiJob struct {
+	ID          string
+	Name        string
+	Type        string            `json:",omitempty"`
+	Namespace   string            `json:",omitempty"`
+	Region      string            `json:",omitempty"`
+	Datacenters []string          `json:",omitempty"`
+	Meta        map[string]string `json:",omitempty"`
+	TaskGroups  []*apiTaskGroup
+}
+
+type apiTaskGroup struct {
+	Name     string
+	Count    *int          `json:",omitempty"`
+	Networks []*apiNetwork `json:",omitempty"`
+	Services []*apiService `json:",omitempty"`
+	Tasks    []*apiTask
+}
+
+type apiNetwork struct {
+	DynamicPorts  []apiPort `json:",omitempty"`
+	ReservedPorts []apiPort `json:",omitempty"`
+}
+
+type apiPort struct {
+	Label string
+	Value int `json:",omitempty"`
+	To    int `json:",omitempty"`
+}
+
+type apiService struct {
+	Name      string
+	PortLabel string      `json:",omitempty"`
+	Tags      []string    `json:",omitempty"`
+	Checks    []*apiCheck `json:",omitempty"`
+}
+
+type apiCheck struct {
+	Type     string
+	Path     string        `json:",omitempty"`
+	Interval time.Duration `json:",omitempty"`
+	Timeout  time.Duration `json:",omitempty"`
+}
+
+type apiTask struct {
+	Name      string
+	Driver    string
+	Config    map[string]any    `json:",omitempty"`
+	Env       map[string]string `json:",omitempty"`
+	Templates []*apiTemplate    `json:",omitempty"`
+	Resources *apiResources     `json:",omitempty"`
+}
+
+type apiTemplate struct {
+	EmbeddedTmpl string
+	DestPath     string
+	Envvars      bool   `json:",omitempty"`
+	ChangeMode   string `json:",omitempty"`
+	Perms        string `json:",omitempty"`
+}
+
+type apiResources struct {
+	CPU      *int `json:",omitempty"`
+	MemoryMB *int `json:",omitempty"`
+}
+
+// JSON converts the rendered jobspec to the Nomad API job structure, wrapped in a Job
+// field so it can be submitted to the jobs API or run with nomad job run -json. Only the
+// blocks and attributes generated job templates use are supported. Runtime interpolations
+// like ${NOMAD_TASK_DIR} are kept for Nomad to resolve, as when it parses the HCL.
+func (j RenderedJob) JSON() ([]byte, error) {
+	src := []byte(j.Content)
+	file, diags := hclsyntax.ParseConfig(src, j.Name, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s; %w", j.Name, diags)
+	}
+
+	c := &jobConverter{src: src}
+	job, err := c.job(file.Body.(*hclsyntax.Body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s; %w", j.Name, err)
+	}
+
+	data, err := json.MarshalIndent(map[string]*apiJob{"Job": job}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s; %w", j.Name, err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// jobConverter converts the blocks of a jobspec, reading expression source from src
+type jobConverter struct {
+	src []byte
+}
+
+func (c *jobConverter) job(body *hclsyntax.Body) (*apiJob, error) {
+	var block *hclsyntax.Block
+	for _, b := range body.Blocks {
+		if b.Type != "job" || len(b.Labels) != 1 {
+			return nil, fmt.Errorf("unsupported block %q outside the job", b.Type)
+		}
+		if block != nil {
+			return nil, fmt.Errorf("more than one job block")
+		}
+		block = b
+	}
+	if block == nil {
+		return nil, fmt.Errorf("no job block")
+	}
+
+	job := &apiJob{ID: block.Labels[0], Name: block.Labels[0]}
+	if err := c.attributes(block, map[string]any{
+		"type":        &job.Type,
+		"namespace":   &job.Namespace,
+		"region":      &job.Region,
+		"datacenters": &job.Datacenters,
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, b := range block.Body.Blocks {
+		switch b.Type {
+		case "meta":
+			meta, err := c.stringMap(b)
+			if err != nil {
+				return nil, err
+			}
+			job.Meta = meta
+		case "group":
+			group, err := c.group(b)
+			if err != nil {
+				return nil, err
+			}
+			job.TaskGroups = append(job.TaskGroups, group)
+		default:
+			return nil, unsupportedBlock(b, "job")
+		}
+	}
+
+	return job, nil
+}
+
+func (c *jobConverter) group(block *hclsyntax.Block) (*apiTaskGroup, error) {
+	if len(block.Labels) != 1 {
+		return nil, fmt.Errorf("group block must have one label")
+	}
+
+	group := &apiTaskGroup{Name: block.Labels[0]}
+	if err := c.attributes(block, map[string]any{"count": &group.Count}); err != nil {
+		return nil, err
+	}
+
+	for _, b := range block.Body.Blocks {
+		switch b.Type {
+		case "network":
+			network, err := c.network(b)
+			if err != nil {
+				return nil, err
+			}
+			group.Networks = append(group.Networks, network)
+		case "service":
+			service, err := c.service(b)
+			if err != nil {
+				return nil, err
+			}
+			group.Services = append(group.Services, service)
+		case "task":
+			task, err := c.task(b)
+			if err != nil {
+				return nil, err
+			}
+			group.Tasks = append(group.Tasks, task)
+		default:
+			return nil, unsupportedBlock(b, "group")
+		}
+	}
+
+	return group, nil
+}
+
+func (c *jobConverter) network(block *hclsyntax.Block) (*apiNetwork, error) {
+	if err := c.attributes(block, nil); err != nil {
+		return nil, err
+	}
+
+	network := &apiNetwork{}
+	for _, b := range block.Body.Blocks {
+		if b.Type != "port" || len(b.Labels) != 1 {
+			return nil, unsupportedBlock(b, "network")
+		}
+
+		port := apiPort{Label: b.Labels[0]}
+		if err := c.attributes(b, map[string]any{"static": &port.Value, "to": &port.To}); err != nil {
+			return nil, err
+		}
+
+		// A port with a static host port is reserved, otherwise Nomad allocates one
+		if port.Value != 0 {
+			network.ReservedPorts = append(network.ReservedPorts, port)
+		} else {
+			network.DynamicPorts = append(network.DynamicPorts, port)
+		}
+	}
+
+	return network, nil
+}
+
+func (c *jobConverter) service(block *hclsyntax.Block) (*apiService, error) {
+	service := &apiService{}
+	if err := c.attributes(block, map[string]any{
+		"name": &service.Name,
+		"port": &service.PortLabel,
+		"tags": &service.Tags,
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, b := range block.Body.Blocks {
+		if b.Type != "check" {
+			return nil, unsupportedBlock(b, "service")
+		}
+
+		check := &apiCheck{}
+		if err := c.attributes(b, map[string]any{
+			"type":     &check.Type,
+			"path":     &check.Path,
+			"interval": &check.Interval,
+			"timeout":  &check.Timeout,
+		}); err != nil {
+			return nil, err
+		}
+		service.Checks = append(service.Checks, check)
+	}
+
+	return service, nil
+}
+
+func (c *jobConverter) task(block *hclsyntax.Block) (*apiTask, error) {
+	if len(block.Labels) != 1 {
+		return nil, fmt.Errorf("task block must have one label")
+	}
+
+	task := &apiTask{Name: block.Labels[0]}
+	if err := c.attributes(block, map[string]any{"driver": &task.Driver}); err != nil {
+		return nil, err
+	}
+
+	for _, b := range block.Body.Blocks {
+		switch b.Type {
+		case "config":
+			config, err := c.anyMap(b)
+			if err != nil {
+				return nil, err
+			}
+			task.Config = config
+		case "env":
+			env, err := c.stringMap(b)
+			if err != nil {
+				return nil, err
+			}
+			task.Env = env
+		case "template":
+			tmpl := &apiTemplate{}
+			if err := c.attributes(b, map[string]any{
+				"data":        &tmpl.EmbeddedTmpl,
+				"destination": &tmpl.DestPath,
+				"env":         &tmpl.Envvars,
+				"change_mode": &tmpl.ChangeMode,
+				"perms":       &tmpl.Perms,
+			}); err != nil {
+				return nil, err
+			}
+			task.Templates = append(task.Templates, tmpl)
+		case "resources":
+			task.Resources = &apiResources{}
+			if err := c.attributes(b, map[string]any{"cpu": &task.Resources.CPU, "memory": &task.Resources.MemoryMB}); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, unsupportedBlock(b, "task")
+		}
+	}
+
+	return task, nil
+}
+
+// attributes sets the fields for a block's attributes, failing on nested blocks the caller
+// doesn't handle and attributes without a field
+func (c *jobConverter) attributes(block *hclsyntax.Block, fields map[string]any) error {
+	for _, name := range sortedAttributes(block.Body) {
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("unsupported attribute %q in %s block", name, block.Type)
+		}
+
+		value, err := c.value(block.Body.Attributes[name].Expr)
+		if err != nil {
+			return fmt.Errorf("invalid %s in %s block; %w", name, block.Type, err)
+		}
+		if err := assign(field, value); err != nil {
+			return fmt.Errorf("invalid %s in %s block; %w", name, block.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// stringMap reads a block of string attributes, like meta and env
+func (c *jobConverter) stringMap(block *hclsyntax.Block) (map[string]string, error) {
+	values, err := c.anyMap(block)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string, len(values))
+	for name, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s in %s block; expected a string", name, block.Type)
+		}
+		m[name] = s
+	}
+
+	return m, nil
+}
+
+// anyMap reads the attributes of a block without nested blocks, like a task's config
+func (c *jobConverter) anyMap(block *hclsyntax.Block) (map[string]any, error) {
+	if len(block.Body.Blocks) > 0 {
+		return nil, unsupportedBlock(block.Body.Blocks[0], block.Type)
+	}
+
+	m := make(map[string]any, len(block.Body.Attributes))
+	for name, attr := range block.Body.Attributes {
+		value, err := c.value(attr.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in %s block; %w", name, block.Type, err)
+		}
+		m[name] = value
+	}
+
+	return m, nil
+}
+
+// value evaluates an expression without variables. Templates referring to runtime
+// variables, like "${NOMAD_TASK_DIR}/run.sh", keep the references as written.
+func (c *jobConverter) value(expr hclsyntax.Expression) (any, error) {
+	if v, diags := expr.Value(nil); !diags.HasErrors() {
+		return goValue(v), nil
+	}
+
+	switch e := expr.(type) {
+	case *hclsyntax.TemplateWrapExpr:
+		return c.templateString([]hclsyntax.Expression{e.Wrapped})
+	case *hclsyntax.TemplateExpr:
+		return c.templateString(e.Parts)
+	case *hclsyntax.TupleConsExpr:
+		list := make([]any, 0, len(e.Exprs))
+		for _, item := range e.Exprs {
+			v, err := c.value(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+
+	_, diags := expr.Value(nil)
+	return nil, diags
+}
+
+func (c *jobConverter) templateString(parts []hclsyntax.Expression) (string, error) {
+	var b strings.Builder
+	for _, part := range parts {
+		if traversal, ok := part.(*hclsyntax.ScopeTraversalExpr); ok {
+			b.WriteString("${" + string(traversal.Range().SliceBytes(c.src)) + "}")
+			continue
+		}
+
+		v, diags := part.Value(nil)
+		if diags.HasErrors() {
+			return "", diags
+		}
+		if v.IsNull() || v.Type() != cty.String {
+			return "", fmt.Errorf("unsupported template expression at %s", part.Range())
+		}
+		b.WriteString(v.AsString())
+	}
+
+	return b.String(), nil
+}
+
+// assign sets a field to a converted value, parsing durations like "30s"
+func assign(field any, value any) error {
+	switch f := field.(type) {
+	case *string:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a string")
+		}
+		*f = s
+	case *bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected a bool")
+		}
+		*f = b
+	case *int:
+		n, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("expected a whole number")
+		}
+		*f = n
+	case **int:
+		n, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("expected a whole number")
+		}
+		*f = &n
+	case *time.Duration:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a duration")
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*f = d
+	case *[]string:
+		list, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("expected a list of strings")
+		}
+		strs := make([]string, 0, len(list))
+		for _, e := range list {
+			s, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("expected a list of strings")
+			}
+			strs = append(strs, s)
+		}
+		*f = strs
+	default:
+		return fmt.Errorf("unsupported field type %T", field)
+	}
+
+	return nil
+}
+
+func sortedAttributes(body *hclsyntax.Body) []string {
+	names := make([]string, 0, len(body.Attributes))
+	for name := range body.Attributes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func unsupportedBlock(block *hclsyntax.Block, parent string) error {
+	return fmt.Errorf("unsupported %s block in %s block", block.Type, parent)
+}
@@ -0,0 +1,156 @@
+// Package packrender loads generated Nomad Packs and renders their templates as nomad-pack does
+package packrender
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// Pack is a generated pack loaded for rendering
+type Pack struct {
+	Name      string
+	Meta      map[string]string // metadata.hcl attributes, keyed by block and attribute name like pack.name
+	Variables []*Variable
+
+	files fs.FS
+}
+
+// Variable is a variable declared in the pack's variables.hcl
+type Variable struct {
+	Name        string
+	Description string
+	Type        cty.Type
+	Default     cty.Value // Null when the variable is required
+}
+
+// Load reads a pack's metadata and variables
+func Load(files fs.FS, name string) (*Pack, error) {
+	p := &Pack{Name: name, Meta: map[string]string{}, files: files}
+
+	if err := p.loadMetadata(); err != nil {
+		return nil, err
+	}
+	if err := p.loadVariables(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Variable returns the variable with the name, or nil if the pack doesn't declare it
+func (p *Pack) Variable(name string) *Variable {
+	i := slices.IndexFunc(p.Variables, func(v *Variable) bool { return v.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return p.Variables[i]
+}
+
+// loadMetadata reads the string attributes of metadata.hcl's blocks, defaulting pack.name to the pack's name
+func (p *Pack) loadMetadata() error {
+	file, err := p.parseHCL("metadata.hcl")
+	if err != nil {
+		return err
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return errors.New("failed to parse metadata.hcl; unexpected syntax")
+	}
+
+	for _, block := range body.Blocks {
+		for name, attr := range block.Body.Attributes {
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() || value.IsNull() || value.Type() != cty.String {
+				continue
+			}
+			p.Meta[block.Type+"."+name] = value.AsString()
+		}
+	}
+
+	if p.Meta["pack.name"] == "" {
+		p.Meta["pack.name"] = p.Name
+	}
+
+	return nil
+}
+
+var variablesSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "variable", LabelNames: []string{"name"}}},
+}
+
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "description"}, {Name: "type"}, {Name: "default"}},
+}
+
+func (p *Pack) loadVariables() error {
+	file, err := p.parseHCL("variables.hcl")
+	if err != nil {
+		return err
+	}
+
+	content, diags := file.Body.Content(variablesSchema)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to read variables.hcl; %w", diags)
+	}
+
+	for _, block := range content.Blocks {
+		attrs, diags := block.Body.Content(variableSchema)
+		if diags.HasErrors() {
+			return fmt.Errorf("failed to read variable %q; %w", block.Labels[0], diags)
+		}
+
+		v := &Variable{Name: block.Labels[0], Type: cty.DynamicPseudoType, Default: cty.NullVal(cty.DynamicPseudoType)}
+
+		if attr, ok := attrs.Attributes["description"]; ok {
+			value, diags := attr.Expr.Value(nil)
+			if !diags.HasErrors() && value.Type() == cty.String && !value.IsNull() {
+				v.Description = value.AsString()
+			}
+		}
+
+		if attr, ok := attrs.Attributes["type"]; ok {
+			v.Type, diags = typeexpr.TypeConstraint(attr.Expr)
+			if diags.HasErrors() {
+				return fmt.Errorf("failed to read type of variable %q; %w", v.Name, diags)
+			}
+		}
+
+		if attr, ok := attrs.Attributes["default"]; ok {
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				return fmt.Errorf("failed to read default of variable %q; %w", v.Name, diags)
+			}
+			if v.Default, err = convert.Convert(value, v.Type); err != nil {
+				return fmt.Errorf("default of variable %q is not a %s; %w", v.Name, v.Type.FriendlyName(), err)
+			}
+		}
+
+		p.Variables = append(p.Variables, v)
+	}
+
+	return nil
+}
+
+func (p *Pack) parseHCL(name string) (*hcl.File, error) {
+	data, err := fs.ReadFile(p.files, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s; %w", name, err)
+	}
+
+	file, diags := hclparse.NewParser().ParseHCL(data, name)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s; %w", name, diags)
+	}
+
+	return file, nil
+}
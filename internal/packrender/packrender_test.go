@@ -0,0 +1,311 @@
+package packrender
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// fixturePack is a minimal pack exercising each variable type the generator declares
+var fixturePack = fstest.MapFS{
+	"metadata.hcl": {Data: []byte(`app {
+  url = "https://example.com/weather-mcp"
+}
+
+pack {
+  name        = "weather-mcp"
+  description = "Weather MCP server"
+}
+`)},
+	"variables.hcl": {Data: []byte(`variable "job_name" {
+  description = "Job name"
+  type        = string
+  default     = "weather-mcp"
+}
+
+variable "count" {
+  type    = number
+  default = 1
+}
+
+variable "datacenters" {
+  type    = list(string)
+  default = ["dc1"]
+}
+
+variable "api_key" {
+  description = "API key, which has no default"
+  type        = string
+}
+`)},
+	"templates/_helpers.tpl": {Data: []byte(`[[- define "job_name" -]][[ var "job_name" . ]][[- end -]]`)},
+	"templates/weather-mcp.nomad.tpl": {Data: []byte(`job [[ template "job_name" . ]] {
+  datacenters = [[ var "datacenters" . | toStringList ]]
+
+  group "mcp" {
+    count = [[ var "count" . ]]
+
+    task "server" {
+      driver = "docker"
+
+      env {
+        API_KEY = [[ var "api_key" . | quote ]]
+      }
+    }
+  }
+}
+`)},
+	"outputs.tpl": {Data: []byte(`Deployed [[ meta "pack.name" . ]] as [[ var "job_name" . ]]`)},
+}
+
+func TestValues(t *testing.T) {
+	pack, err := Load(fixturePack, "weather-mcp")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	hclFile := filepath.Join(dir, "prod.hcl")
+	if err := os.WriteFile(hclFile, []byte("api_key = \"secret\"\ncount = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jsonFile := filepath.Join(dir, "dcs.json")
+	if err := os.WriteFile(jsonFile, []byte(`{"datacenters": ["dc1", "dc2"], "count": 3}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unknownFile := filepath.Join(dir, "unknown.hcl")
+	if err := os.WriteFile(unknownFile, []byte("region = \"eu\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		varFiles    []string
+		overrides   []string
+		expected    map[string]cty.Value
+		errorSubstr string
+	}{
+		{
+			name:     "var files in order",
+			varFiles: []string{hclFile, jsonFile},
+			expected: map[string]cty.Value{
+				"job_name":    cty.StringVal("weather-mcp"),
+				"count":       cty.NumberIntVal(3),
+				"datacenters": cty.ListVal([]cty.Value{cty.StringVal("dc1"), cty.StringVal("dc2")}),
+				"api_key":     cty.StringVal("secret"),
+			},
+		},
+		{
+			name:      "overrides after var files",
+			varFiles:  []string{hclFile},
+			overrides: []string{"count=5", `datacenters=["dc3"]`, "job_name=weather=prod"},
+			expected: map[string]cty.Value{
+				"job_name":    cty.StringVal("weather=prod"),
+				"count":       cty.NumberIntVal(5),
+				"datacenters": cty.ListVal([]cty.Value{cty.StringVal("dc3")}),
+				"api_key":     cty.StringVal("secret"),
+			},
+		},
+		{
+			name:        "missing required variable",
+			errorSubstr: "variables without defaults must be set in a var file or with --var: api_key",
+		},
+		{
+			name:        "unknown variable in var file",
+			varFiles:    []string{unknownFile},
+			errorSubstr: `the pack has no variable "region"`,
+		},
+		{
+			name:        "unknown variable override",
+			overrides:   []string{"region=eu"},
+			errorSubstr: `the pack has no variable "region"`,
+		},
+		{
+			name:        "override of the wrong type",
+			overrides:   []string{"api_key=x", "count=many"},
+			errorSubstr: `invalid variable "count=many"`,
+		},
+		{
+			name:        "override without a value",
+			overrides:   []string{"count"},
+			errorSubstr: "expected name=value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := pack.Values(tt.varFiles, tt.overrides)
+
+			if tt.errorSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorSubstr) {
+					t.Fatalf("Values() error = %v, expected to contain %q", err, tt.errorSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Values() unexpected error = %v", err)
+			}
+
+			for name, expected := range tt.expected {
+				if !values[name].RawEquals(expected) {
+					t.Errorf("Values()[%q] = %#v, expected %#v", name, values[name], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	pack, err := Load(fixturePack, "weather-mcp")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	values, err := pack.Values(nil, []string{"api_key=s3cr3t", "count=2"})
+	if err != nil {
+		t.Fatalf("Values() error = %v", err)
+	}
+
+	rendered, err := pack.Render(values)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	if len(rendered.Jobs) != 1 || rendered.Jobs[0].Name != "templates/weather-mcp.nomad" {
+		t.Fatalf("Render() jobs = %+v, expected templates/weather-mcp.nomad", rendered.Jobs)
+	}
+
+	content := rendered.Jobs[0].Content
+	for _, expected := range []string{`job weather-mcp {`, `datacenters = ["dc1"]`, `count = 2`, `API_KEY = "s3cr3t"`} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("rendered job does not contain %q:\n%s", expected, content)
+		}
+	}
+
+	if rendered.Outputs != "Deployed weather-mcp as weather-mcp" {
+		t.Errorf("Render() outputs = %q", rendered.Outputs)
+	}
+}
+
+func TestRenderedJobJSON(t *testing.T) {
+	job := RenderedJob{Name: "templates/weather-mcp.nomad", Content: `job "weather-mcp" {
+  region      = "global"
+  datacenters = ["dc1"]
+  type        = "service"
+
+  meta {
+    nomad_mcp_pack_server = "io.github.example/weather"
+  }
+
+  group "mcp-server" {
+    count = 2
+
+    network {
+      port "http" {
+        to     = 3000
+        static = 8080
+      }
+    }
+
+    service {
+      name = "weather-mcp"
+      port = "http"
+      tags = ["mcp"]
+
+      check {
+        type     = "tcp"
+        interval = "10s"
+        timeout  = "2s"
+      }
+    }
+
+    task "server" {
+      driver = "exec"
+
+      config {
+        command = "${NOMAD_TASK_DIR}/venv/bin/weather"
+        args    = ["--port", "3000"]
+      }
+
+      env {
+        PATH = "${NOMAD_TASK_DIR}/venv/bin:${PATH}"
+      }
+
+      template {
+        data        = <<-EOT
+        {{ with nomadVar "nomad/jobs/weather-mcp" }}{{ end }}
+        EOT
+        destination = "secrets/mcp-server.env"
+        env         = true
+        change_mode = "restart"
+      }
+
+      resources {
+        cpu    = 100
+        memory = 256
+      }
+    }
+  }
+}
+`}
+
+	data, err := job.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	var decoded struct{ Job apiJob }
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("JSON() returned invalid JSON; %v", err)
+	}
+
+	got := decoded.Job
+	if got.ID != "weather-mcp" || got.Name != "weather-mcp" || got.Region != "global" || got.Type != "service" {
+		t.Errorf("JSON() job = %+v", got)
+	}
+	if got.Meta["nomad_mcp_pack_server"] != "io.github.example/weather" {
+		t.Errorf("JSON() meta = %v", got.Meta)
+	}
+	if len(got.TaskGroups) != 1 || len(got.TaskGroups[0].Tasks) != 1 {
+		t.Fatalf("JSON() task groups = %+v", got.TaskGroups)
+	}
+
+	group := got.TaskGroups[0]
+	if group.Count == nil || *group.Count != 2 {
+		t.Errorf("JSON() count = %v, expected 2", group.Count)
+	}
+	if len(group.Networks) != 1 || len(group.Networks[0].ReservedPorts) != 1 || group.Networks[0].ReservedPorts[0] != (apiPort{Label: "http", Value: 8080, To: 3000}) {
+		t.Errorf("JSON() networks = %+v", group.Networks)
+	}
+	if len(group.Services) != 1 || len(group.Services[0].Checks) != 1 || group.Services[0].Checks[0].Interval != 10*time.Second {
+		t.Errorf("JSON() services = %+v", group.Services)
+	}
+
+	task := group.Tasks[0]
+	if task.Config["command"] != "${NOMAD_TASK_DIR}/venv/bin/weather" {
+		t.Errorf("JSON() config command = %v", task.Config["command"])
+	}
+	if task.Env["PATH"] != "${NOMAD_TASK_DIR}/venv/bin:${PATH}" {
+		t.Errorf("JSON() env PATH = %q", task.Env["PATH"])
+	}
+	if len(task.Templates) != 1 || !task.Templates[0].Envvars || !strings.Contains(task.Templates[0].EmbeddedTmpl, `nomadVar "nomad/jobs/weather-mcp"`) {
+		t.Errorf("JSON() templates = %+v", task.Templates)
+	}
+	if task.Resources == nil || task.Resources.MemoryMB == nil || *task.Resources.MemoryMB != 256 {
+		t.Errorf("JSON() resources = %+v", task.Resources)
+	}
+
+	job.Content = strings.Replace(job.Content, `type        = "service"`, `type = "service"
+  update {
+    max_parallel = 1
+  }`, 1)
+	if _, err := job.JSON(); err == nil {
+		t.Error("JSON() expected an error for an unsupported block")
+	}
+}
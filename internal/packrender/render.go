@@ -0,0 +1,203 @@
+package packrender
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// jobTemplateSuffix marks the pack templates that render to jobspecs
+const jobTemplateSuffix = ".nomad.tpl"
+
+// Rendered is a rendered pack
+type Rendered struct {
+	Jobs    []RenderedJob
+	Outputs string // The rendered outputs.tpl, shown once the pack is deployed
+}
+
+// RenderedJob is the jobspec rendered from one of the pack's job templates
+type RenderedJob struct {
+	Name    string // Template path without the .tpl extension, like templates/mcp-server.nomad
+	Content string
+}
+
+// renderContext is the dot passed to pack templates, which the var and meta functions read
+type renderContext struct {
+	vars map[string]any
+	meta map[string]string
+}
+
+// templateFuncs are the nomad-pack template functions used by generated packs
+var templateFuncs = template.FuncMap{
+	"var": func(name string, ctx *renderContext) (any, error) {
+		v, ok := ctx.vars[name]
+		if !ok {
+			return nil, fmt.Errorf("the pack has no variable %q", name)
+		}
+		return v, nil
+	},
+	"meta": func(name string, ctx *renderContext) (string, error) {
+		v, ok := ctx.meta[name]
+		if !ok {
+			return "", fmt.Errorf("the pack has no metadata %q", name)
+		}
+		return v, nil
+	},
+	"quote": func(v any) string {
+		if v == nil {
+			return `""`
+		}
+		return strconv.Quote(fmt.Sprint(v))
+	},
+	"toStringList": func(v any) (string, error) {
+		list, ok := v.([]any)
+		if !ok {
+			return "", fmt.Errorf("toStringList expects a list, got %T", v)
+		}
+		quoted := make([]string, len(list))
+		for i, e := range list {
+			quoted[i] = strconv.Quote(fmt.Sprint(e))
+		}
+		return "[" + strings.Join(quoted, ", ") + "]", nil
+	},
+}
+
+// Render renders the pack's job templates, along with outputs.tpl, with the variable values.
+// Templates use nomad-pack's [[ ]] delimiters, and templates/_*.tpl files only define helpers.
+func (p *Pack) Render(values map[string]cty.Value) (*Rendered, error) {
+	ctx := &renderContext{vars: make(map[string]any, len(values)), meta: p.Meta}
+	for name, value := range values {
+		ctx.vars[name] = goValue(value)
+	}
+
+	templates, err := fs.Glob(p.files, "templates/*.tpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pack templates; %w", err)
+	}
+	if _, err := fs.Stat(p.files, "outputs.tpl"); err == nil {
+		templates = append(templates, "outputs.tpl")
+	}
+
+	set := template.New(p.Name).Delims("[[", "]]").Funcs(templateFuncs).Option("missingkey=error")
+	for _, name := range templates {
+		data, err := fs.ReadFile(p.files, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s; %w", name, err)
+		}
+		if _, err := set.New(name).Parse(string(data)); err != nil {
+			return nil, fmt.Errorf("failed to parse %s; %w", name, err)
+		}
+	}
+
+	rendered := &Rendered{}
+	for _, name := range templates {
+		if name != "outputs.tpl" && !strings.HasSuffix(name, jobTemplateSuffix) {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := set.ExecuteTemplate(&buf, name, ctx); err != nil {
+			return nil, fmt.Errorf("failed to render %s; %w", name, err)
+		}
+
+		if name == "outputs.tpl" {
+			rendered.Outputs = buf.String()
+			continue
+		}
+		rendered.Jobs = append(rendered.Jobs, RenderedJob{
+			Name:    path.Join(path.Dir(name), strings.TrimSuffix(path.Base(name), ".tpl")),
+			Content: buf.String(),
+		})
+	}
+
+	if len(rendered.Jobs) == 0 {
+		return nil, fmt.Errorf("pack %s has no job templates (templates/*%s)", p.Name, jobTemplateSuffix)
+	}
+
+	return rendered, nil
+}
+
+// Identity reads the job's ID and meta from its jobspec, without contacting Nomad. Meta
+// attributes that aren't literal strings are left out.
+func (j RenderedJob) Identity() (string, map[string]string, error) {
+	file, diags := hclsyntax.ParseConfig([]byte(j.Content), j.Name, hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", nil, fmt.Errorf("failed to parse %s; %w", j.Name, diags)
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return "", nil, fmt.Errorf("failed to parse %s; unexpected syntax", j.Name)
+	}
+
+	i := slices.IndexFunc(body.Blocks, func(b *hclsyntax.Block) bool { return b.Type == "job" && len(b.Labels) == 1 })
+	if i < 0 {
+		return "", nil, fmt.Errorf("%s has no job block", j.Name)
+	}
+	job := body.Blocks[i]
+
+	meta := make(map[string]string)
+	for _, block := range job.Body.Blocks {
+		if block.Type != "meta" {
+			continue
+		}
+		for name, attr := range block.Body.Attributes {
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() || value.IsNull() || value.Type() != cty.String {
+				continue
+			}
+			meta[name] = value.AsString()
+		}
+	}
+
+	return job.Labels[0], meta, nil
+}
+
+// goValue converts a variable value to the Go value templates see. Whole numbers are ints
+// so templates can compare them with literals like 0.
+func goValue(v cty.Value) any {
+	if v.IsNull() || !v.IsKnown() {
+		return nil
+	}
+
+	t := v.Type()
+	switch {
+	case t == cty.String:
+		return v.AsString()
+	case t == cty.Bool:
+		return v.True()
+	case t == cty.Number:
+		f := v.AsBigFloat()
+		if i, accuracy := f.Int64(); accuracy == big.Exact {
+			return int(i)
+		}
+		n, _ := f.Float64()
+		return n
+	case t.IsListType() || t.IsSetType() || t.IsTupleType():
+		list := []any{}
+		for it := v.ElementIterator(); it.Next(); {
+			_, e := it.Element()
+			list = append(list, goValue(e))
+		}
+		return list
+	case t.IsMapType() || t.IsObjectType():
+		m := map[string]any{}
+		for it := v.ElementIterator(); it.Next(); {
+			k, e := it.Element()
+			m[k.AsString()] = goValue(e)
+		}
+		return m
+	default:
+		return nil
+	}
+}
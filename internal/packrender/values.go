@@ -0,0 +1,131 @@
+package packrender
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// Values resolves the pack's variables from their defaults, then the var files in order,
+// then name=value overrides. A var file sets variables as HCL attributes, or as a JSON
+// object when its name ends in .json. Override values are strings for string variables
+// and HCL expressions, like 3 or ["dc1", "dc2"], for other types.
+func (p *Pack) Values(varFiles []string, overrides []string) (map[string]cty.Value, error) {
+	values := make(map[string]cty.Value)
+	for _, v := range p.Variables {
+		values[v.Name] = v.Default
+	}
+
+	for _, path := range varFiles {
+		set, err := readVarFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range set {
+			if err := p.setValue(values, name, value); err != nil {
+				return nil, fmt.Errorf("invalid variable in %s; %w", path, err)
+			}
+		}
+	}
+
+	for _, override := range overrides {
+		name, raw, ok := strings.Cut(override, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid variable %q; expected name=value", override)
+		}
+
+		v := p.Variable(name)
+		if v == nil {
+			return nil, fmt.Errorf("invalid variable %q; the pack has no variable %q", override, name)
+		}
+
+		value := cty.StringVal(raw)
+		if v.Type != cty.String {
+			expr, diags := hclsyntax.ParseExpression([]byte(raw), name, hcl.InitialPos)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("invalid variable %q; %w", override, diags)
+			}
+			if value, diags = expr.Value(nil); diags.HasErrors() {
+				return nil, fmt.Errorf("invalid variable %q; %w", override, diags)
+			}
+		}
+
+		if err := p.setValue(values, name, value); err != nil {
+			return nil, fmt.Errorf("invalid variable %q; %w", override, err)
+		}
+	}
+
+	var missing []string
+	for _, v := range p.Variables {
+		if values[v.Name].IsNull() {
+			missing = append(missing, v.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("variables without defaults must be set in a var file or with --var: %s", strings.Join(missing, ", "))
+	}
+
+	return values, nil
+}
+
+func (p *Pack) setValue(values map[string]cty.Value, name string, value cty.Value) error {
+	v := p.Variable(name)
+	if v == nil {
+		return fmt.Errorf("the pack has no variable %q", name)
+	}
+
+	converted, err := convert.Convert(value, v.Type)
+	if err != nil {
+		return fmt.Errorf("variable %q must be a %s; %w", name, v.Type.FriendlyName(), err)
+	}
+	values[name] = converted
+
+	return nil
+}
+
+// readVarFile returns the values set by a var file
+func readVarFile(path string) (map[string]cty.Value, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read var file; %w", err)
+	}
+
+	parser := hclparse.NewParser()
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		file, diags = parser.ParseJSON(data, path)
+	} else {
+		file, diags = parser.ParseHCL(data, path)
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse var file; %w", diags)
+	}
+
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse var file; %w", diags)
+	}
+
+	values := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to evaluate %s in var file %s; %w", name, path, diags)
+		}
+		if value.IsNull() {
+			return nil, fmt.Errorf("var file %s sets %s to null", path, name)
+		}
+		values[name] = value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,20 @@
+package registry
+
+import "fmt"
+
+// PaginationError is returned when a page of results could not be fetched
+// after retrying, after earlier pages were fetched successfully
+type PaginationError struct {
+	Page    int
+	Cursor  string
+	Fetched int
+	Err     error
+}
+
+func (e *PaginationError) Error() string {
+	return fmt.Sprintf("failed to fetch page %d of servers after %d servers fetched; %v", e.Page, e.Fetched, e.Err)
+}
+
+func (e *PaginationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,265 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/leefowlercu/go-mcp-registry/mcp"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/metrics"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
+	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
+	"golang.org/x/time/rate"
+)
+
+// maxRetryAfter is the longest Retry-After the client waits for before giving up
+const maxRetryAfter = 5 * time.Minute
+
+// Client wraps the MCP Registry client, retrying idempotent requests with
+// jittered backoff and limiting the rate of requests sent to the registry
+type Client struct {
+	mcp            *mcp.Client
+	limiter        *rate.Limiter
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// New creates a Client for the registry at registryURL. A non-empty token is sent
+// as a bearer token with every request, for registries that require authentication.
+func New(registryURL, token string, cfg config.RegistryConfig) (*Client, error) {
+	baseURL, err := url.Parse(registryURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse registry URL; %w", err)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = config.DefaultConfig.RegistryTimeout
+	}
+
+	var transport http.RoundTripper = tracing.Transport(metrics.InstrumentRegistryTransport(nil))
+	if token != "" {
+		transport = &bearerTransport{token: token, next: transport}
+	}
+
+	client := mcp.NewClient(&http.Client{
+		Timeout:   time.Duration(timeout) * time.Second,
+		Transport: transport,
+	})
+	client.BaseURL = baseURL
+
+	// A rate limit of zero disables client-side rate limiting
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if cfg.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.RateLimit), max(cfg.RateBurst, 1))
+	}
+
+	return &Client{
+		mcp:            client,
+		limiter:        limiter,
+		maxRetries:     cfg.MaxRetries,
+		initialBackoff: time.Duration(cfg.InitialBackoff) * time.Second,
+		maxBackoff:     time.Duration(cfg.MaxBackoff) * time.Second,
+	}, nil
+}
+
+// bearerTransport adds a bearer token to every request
+type bearerTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(req)
+}
+
+// ListServers fetches a single page of servers, retrying transient failures
+func (c *Client) ListServers(ctx context.Context, opts *mcp.ServerListOptions) (*v0.ServerListResponse, *mcp.Response, error) {
+	var listResp *v0.ServerListResponse
+	var resp *mcp.Response
+
+	err := c.retry(ctx, "list servers", func() error {
+		var err error
+		listResp, resp, err = c.mcp.Servers.List(ctx, opts)
+		return err
+	})
+
+	return listResp, resp, err
+}
+
+// ListAllServers fetches every page of servers matching opts. Each page is
+// retried on its own, so a transient failure resumes from the last good cursor
+// instead of restarting pagination. If a page still fails, the servers fetched
+// so far are returned along with a *PaginationError.
+func (c *Client) ListAllServers(ctx context.Context, opts *mcp.ServerListOptions) ([]v0.ServerResponse, error) {
+	var allServers []v0.ServerResponse
+
+	pageOpts := *opts
+	for page := 1; ; page++ {
+		listResp, resp, err := c.ListServers(ctx, &pageOpts)
+		if err != nil {
+			return allServers, &PaginationError{
+				Page:    page,
+				Cursor:  pageOpts.Cursor,
+				Fetched: len(allServers),
+				Err:     err,
+			}
+		}
+
+		if listResp != nil {
+			allServers = append(allServers, listResp.Servers...)
+		}
+
+		// Check if there are more pages
+		if resp == nil || resp.NextCursor == "" {
+			break
+		}
+
+		pageOpts.Cursor = resp.NextCursor
+	}
+
+	return allServers, nil
+}
+
+// retry runs fn until it succeeds, fails with an error that isn't retryable, or
+// the retry limit is reached, waiting for the rate limiter before every attempt
+func (c *Client) retry(ctx context.Context, op string, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if !retryable(ctx, err) || attempt >= c.maxRetries {
+			return err
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter, ok := retryAfter(err); ok {
+			// Give up rather than stall the caller when asked to wait too long
+			if retryAfter > maxRetryAfter {
+				return fmt.Errorf("registry requested retry after %v; %w", retryAfter, err)
+			}
+			wait = retryAfter
+		}
+
+		slog.WarnContext(ctx, "registry request failed, retrying",
+			"operation", op,
+			"attempt", attempt+1,
+			"max_retries", c.maxRetries,
+			"wait", wait,
+			"error", err,
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the exponential backoff for an attempt with equal jitter,
+// so concurrent clients don't retry in lockstep
+func (c *Client) backoff(attempt int) time.Duration {
+	backoff := c.initialBackoff << attempt
+	if backoff <= 0 || (c.maxBackoff > 0 && backoff > c.maxBackoff) {
+		backoff = c.maxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	half := backoff / 2
+	return half + rand.N(half+1)
+}
+
+// retryable reports whether a failed request is safe and worth retrying
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var rateLimitErr *mcp.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true
+	}
+
+	var errResp *mcp.ErrorResponse
+	if errors.As(err, &errResp) {
+		if errResp.Response == nil {
+			return false
+		}
+		switch errResp.Response.StatusCode {
+		case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	// Network errors and timeouts
+	return true
+}
+
+// retryAfter returns how long the registry asked the client to wait, from the
+// Retry-After header or, for rate limit errors, the rate limit reset time
+func retryAfter(err error) (time.Duration, bool) {
+	var resp *http.Response
+	var reset time.Time
+
+	var rateLimitErr *mcp.RateLimitError
+	var errResp *mcp.ErrorResponse
+	switch {
+	case errors.As(err, &rateLimitErr):
+		resp = rateLimitErr.Response
+		reset = rateLimitErr.Rate.Reset
+	case errors.As(err, &errResp):
+		resp = errResp.Response
+	}
+
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return d, true
+		}
+	}
+
+	if !reset.IsZero() {
+		return max(time.Until(reset), 0), true
+	}
+
+	return 0, false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,183 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leefowlercu/go-mcp-registry/mcp"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+)
+
+// pageHandler serves one server per page, following the cursor "page-N" to page N
+func pageHandler(pages int, fail func(page int, attempt int32) int) http.Handler {
+	var attempts [16]atomic.Int32
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			fmt.Sscanf(cursor, "page-%d", &page)
+		}
+
+		attempt := attempts[page].Add(1)
+		if code := fail(page, attempt); code != 0 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(code)
+			fmt.Fprint(w, `{"message":"unavailable"}`)
+			return
+		}
+
+		nextCursor := ""
+		if page < pages {
+			nextCursor = fmt.Sprintf("page-%d", page+1)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"servers":[{"server":{"name":"io.github.example/server-%d","version":"1.0.0"}}],"metadata":{"nextCursor":%q,"count":1}}`, page, nextCursor)
+	})
+}
+
+func newTestClient(t *testing.T, handler http.Handler, maxRetries int) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	client, err := New(srv.URL+"/", "", config.RegistryConfig{
+		Timeout:        5,
+		MaxRetries:     maxRetries,
+		InitialBackoff: 0,
+		MaxBackoff:     0,
+	})
+	if err != nil {
+		t.Fatalf("New() unexpected error = %v", err)
+	}
+
+	return client
+}
+
+func TestListAllServersResumesAfterTransientFailure(t *testing.T) {
+	// The second page fails twice before succeeding
+	handler := pageHandler(3, func(page int, attempt int32) int {
+		if page == 2 && attempt <= 2 {
+			return http.StatusServiceUnavailable
+		}
+		return 0
+	})
+	client := newTestClient(t, handler, 3)
+
+	servers, err := client.ListAllServers(context.Background(), &mcp.ServerListOptions{})
+	if err != nil {
+		t.Fatalf("ListAllServers() unexpected error = %v", err)
+	}
+
+	if len(servers) != 3 {
+		t.Fatalf("ListAllServers() returned %d servers, expected 3", len(servers))
+	}
+	for i, s := range servers {
+		if expected := fmt.Sprintf("io.github.example/server-%d", i+1); s.Server.Name != expected {
+			t.Errorf("ListAllServers() server %d = %q, expected %q", i, s.Server.Name, expected)
+		}
+	}
+}
+
+func TestListAllServersReturnsPartialResults(t *testing.T) {
+	// The second page is always rate limited
+	handler := pageHandler(3, func(page int, attempt int32) int {
+		if page == 2 {
+			return http.StatusTooManyRequests
+		}
+		return 0
+	})
+	client := newTestClient(t, handler, 2)
+
+	servers, err := client.ListAllServers(context.Background(), &mcp.ServerListOptions{})
+
+	var pageErr *PaginationError
+	if !errors.As(err, &pageErr) {
+		t.Fatalf("ListAllServers() error = %v, expected *PaginationError", err)
+	}
+	if pageErr.Page != 2 || pageErr.Cursor != "page-2" || pageErr.Fetched != 1 {
+		t.Errorf("ListAllServers() error = %+v, expected page 2, cursor page-2, 1 fetched", pageErr)
+	}
+
+	var rateLimitErr *mcp.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Errorf("ListAllServers() error = %v, expected to wrap *mcp.RateLimitError", err)
+	}
+
+	if len(servers) != 1 {
+		t.Errorf("ListAllServers() returned %d servers, expected 1", len(servers))
+	}
+}
+
+func TestListServersDoesNotRetryClientErrors(t *testing.T) {
+	var requests atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	client := newTestClient(t, handler, 3)
+
+	if _, _, err := client.ListServers(context.Background(), &mcp.ServerListOptions{}); err == nil {
+		t.Fatal("ListServers() expected error but got none")
+	}
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("ListServers() sent %d requests, expected 1", n)
+	}
+}
+
+func TestNewSendsBearerToken(t *testing.T) {
+	var authorization atomic.Value
+	handler := pageHandler(1, func(int, int32) int { return 0 })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := New(srv.URL+"/", "secret", config.RegistryConfig{Timeout: 5})
+	if err != nil {
+		t.Fatalf("New() unexpected error = %v", err)
+	}
+
+	if _, _, err := client.ListServers(context.Background(), &mcp.ServerListOptions{}); err != nil {
+		t.Fatalf("ListServers() unexpected error = %v", err)
+	}
+
+	if got := authorization.Load(); got != "Bearer secret" {
+		t.Errorf("Authorization header = %q, expected %q", got, "Bearer secret")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{name: "empty", value: "", ok: false},
+		{name: "seconds", value: "30", expected: 30 * time.Second, ok: true},
+		{name: "http date", value: now.Add(time.Minute).Format(http.TimeFormat), expected: time.Minute, ok: true},
+		{name: "date in the past", value: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0, ok: true},
+		{name: "invalid", value: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := parseRetryAfter(tt.value, now)
+			if ok != tt.ok || d != tt.expected {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, expected %v, %v", tt.value, d, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
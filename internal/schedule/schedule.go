@@ -0,0 +1,197 @@
+package schedule
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule decides when the watcher polls
+type Schedule interface {
+	// Next returns the first poll time after t
+	Next(t time.Time) time.Time
+	String() string
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+func (e every) String() string {
+	return "@every " + time.Duration(e).String()
+}
+
+// cronSchedule keeps the expression a cron schedule was parsed from
+type cronSchedule struct {
+	cron.Schedule
+	expr string
+}
+
+func (c cronSchedule) String() string {
+	return c.expr
+}
+
+// Every returns a schedule polling at a fixed interval
+func Every(interval time.Duration) Schedule {
+	return every(interval)
+}
+
+// ParseCron parses a standard five field cron expression, or a descriptor such as
+// @daily or @every 10m. A CRON_TZ=<zone> prefix sets the time zone, which is local otherwise.
+func ParseCron(expr string) (Schedule, error) {
+	s, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron schedule %q; %w", expr, err)
+	}
+
+	return cronSchedule{Schedule: s, expr: expr}, nil
+}
+
+// Jitter returns a random delay of up to max, spreading out polls of watchers started together
+func Jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	return rand.N(max)
+}
+
+// QuietWindow is a time of day range, optionally limited to some days of the week, in
+// which the watcher doesn't poll. A window ending before it starts spans midnight, and
+// its days are the days it starts on.
+type QuietWindow struct {
+	spec  string
+	days  [7]bool
+	start time.Duration
+	end   time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseQuietWindow parses a window of the form "[days ]HH:MM-HH:MM" in local time, where
+// days is a comma separated list of days or day ranges, e.g. "22:00-06:00" or "sat,sun 00:00-24:00"
+func ParseQuietWindow(spec string) (QuietWindow, error) {
+	window := QuietWindow{spec: spec}
+
+	fields := strings.Fields(strings.ToLower(spec))
+	switch len(fields) {
+	case 1:
+		for i := range window.days {
+			window.days[i] = true
+		}
+	case 2:
+		if err := window.parseDays(fields[0]); err != nil {
+			return QuietWindow{}, fmt.Errorf("invalid quiet window %q; %w", spec, err)
+		}
+		fields = fields[1:]
+	default:
+		return QuietWindow{}, fmt.Errorf("invalid quiet window %q; must be of the form [days ]HH:MM-HH:MM", spec)
+	}
+
+	start, end, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return QuietWindow{}, fmt.Errorf("invalid quiet window %q; must be of the form [days ]HH:MM-HH:MM", spec)
+	}
+
+	var err error
+	if window.start, err = parseTimeOfDay(start); err != nil {
+		return QuietWindow{}, fmt.Errorf("invalid quiet window %q; %w", spec, err)
+	}
+	if window.end, err = parseTimeOfDay(end); err != nil {
+		return QuietWindow{}, fmt.Errorf("invalid quiet window %q; %w", spec, err)
+	}
+	if window.start == window.end || window.start == 24*time.Hour {
+		return QuietWindow{}, fmt.Errorf("invalid quiet window %q; start and end must differ and start must be before 24:00", spec)
+	}
+
+	return window, nil
+}
+
+// parseDays parses a comma separated list of days or day ranges such as mon-fri
+func (q *QuietWindow) parseDays(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+
+		first, ok := weekdays[from]
+		if !ok {
+			return fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[to]; !ok {
+				return fmt.Errorf("unknown day %q", to)
+			}
+		}
+
+		for d := first; ; d = (d + 1) % 7 {
+			q.days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseTimeOfDay parses HH:MM, allowing 24:00 for the end of the day
+func parseTimeOfDay(s string) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(s, ":")
+	h, herr := strconv.Atoi(hours)
+	m, merr := strconv.Atoi(minutes)
+	if !ok || herr != nil || merr != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time of day %q; must be HH:MM", s)
+	}
+
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// Contains reports whether t falls in the window
+func (q QuietWindow) Contains(t time.Time) bool {
+	tod := timeOfDay(t)
+
+	if q.start < q.end {
+		return q.days[t.Weekday()] && tod >= q.start && tod < q.end
+	}
+
+	// The window spans midnight, so times after midnight belong to the previous day's window
+	if tod >= q.start {
+		return q.days[t.Weekday()]
+	}
+	return tod < q.end && q.days[(t.Weekday()+6)%7]
+}
+
+// End returns when the window containing t ends
+func (q QuietWindow) End(t time.Time) time.Time {
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+
+	if q.start > q.end && timeOfDay(t) >= q.start {
+		midnight = midnight.AddDate(0, 0, 1)
+	}
+
+	return midnight.Add(q.end)
+}
+
+func (q QuietWindow) String() string {
+	return q.spec
+}
+
+func timeOfDay(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+}
@@ -0,0 +1,111 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuietWindow(t *testing.T) {
+	tests := []struct {
+		spec        string
+		expectError bool
+	}{
+		{spec: "22:00-06:00"},
+		{spec: "sat,sun 00:00-24:00"},
+		{spec: "Mon-Fri 12:00-13:30"},
+		{spec: "fri-mon 18:00-09:00"},
+		{spec: "22:00", expectError: true},
+		{spec: "22:00-22:00", expectError: true},
+		{spec: "24:00-06:00", expectError: true},
+		{spec: "25:00-06:00", expectError: true},
+		{spec: "22:60-06:00", expectError: true},
+		{spec: "weekends 00:00-24:00", expectError: true},
+		{spec: "mon 09:00-10:00 extra", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseQuietWindow(tt.spec)
+			if tt.expectError && err == nil {
+				t.Errorf("ParseQuietWindow(%q) expected error but got none", tt.spec)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("ParseQuietWindow(%q) unexpected error = %v", tt.spec, err)
+			}
+		})
+	}
+}
+
+func TestQuietWindowContains(t *testing.T) {
+	// 2026-10-16 is a Friday
+	at := func(day int, clock string) time.Time {
+		tod, _ := time.Parse("15:04", clock)
+		return time.Date(2026, 10, day, tod.Hour(), tod.Minute(), 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		spec     string
+		t        time.Time
+		expected bool
+		end      time.Time
+	}{
+		{spec: "22:00-06:00", t: at(16, "23:00"), expected: true, end: at(17, "06:00")},
+		{spec: "22:00-06:00", t: at(17, "05:59"), expected: true, end: at(17, "06:00")},
+		{spec: "22:00-06:00", t: at(17, "06:00"), expected: false},
+		{spec: "22:00-06:00", t: at(16, "12:00"), expected: false},
+		{spec: "mon-fri 09:00-17:00", t: at(16, "09:00"), expected: true, end: at(16, "17:00")},
+		{spec: "mon-fri 09:00-17:00", t: at(17, "10:00"), expected: false},
+		{spec: "sat,sun 00:00-24:00", t: at(18, "23:59"), expected: true, end: at(19, "00:00")},
+		{spec: "sat,sun 00:00-24:00", t: at(19, "00:00"), expected: false},
+		// Times after midnight belong to the window that started the day before
+		{spec: "fri 22:00-02:00", t: at(17, "01:00"), expected: true, end: at(17, "02:00")},
+		{spec: "fri 22:00-02:00", t: at(16, "01:00"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec+" at "+tt.t.Format(time.DateTime), func(t *testing.T) {
+			window, err := ParseQuietWindow(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseQuietWindow(%q) unexpected error = %v", tt.spec, err)
+			}
+
+			if got := window.Contains(tt.t); got != tt.expected {
+				t.Errorf("Contains() = %v, expected %v", got, tt.expected)
+			}
+			if tt.expected {
+				if end := window.End(tt.t); !end.Equal(tt.end) {
+					t.Errorf("End() = %v, expected %v", end, tt.end)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	s, err := ParseCron("CRON_TZ=UTC */15 9-17 * * mon-fri")
+	if err != nil {
+		t.Fatalf("ParseCron() unexpected error = %v", err)
+	}
+
+	// Friday evening is followed by Monday morning
+	next := s.Next(time.Date(2026, 10, 16, 17, 50, 0, 0, time.UTC))
+	if expected := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC); !next.Equal(expected) {
+		t.Errorf("Next() = %v, expected %v", next, expected)
+	}
+
+	if _, err := ParseCron("every hour"); err == nil {
+		t.Error("ParseCron() expected error for invalid expression")
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if d := Jitter(0); d != 0 {
+		t.Errorf("Jitter(0) = %v, expected 0", d)
+	}
+
+	for range 100 {
+		if d := Jitter(time.Minute); d < 0 || d >= time.Minute {
+			t.Fatalf("Jitter(1m) = %v, expected within [0, 1m)", d)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package secrets resolves the values of an MCP server's secret environment variables,
+// which generated packs read from Nomad Variables
+package secrets
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/registry/pkg/model"
+)
+
+// Secret is a secret environment variable of an MCP server package
+type Secret struct {
+	Name        string
+	Description string
+	Required    bool
+	Default     string
+}
+
+// Status is what resolving did with a secret
+type Status string
+
+const (
+	StatusUpdated   Status = "updated"   // A supplied or prompted value was set
+	StatusUnchanged Status = "unchanged" // The variable already had a value
+	StatusDefault   Status = "default"   // The package's default was set
+	StatusMissing   Status = "missing"   // The secret has no value
+)
+
+// Result is the outcome of resolving a secret
+type Result struct {
+	Secret
+	Status Status
+}
+
+// Prompter asks for the value of a secret, returning an empty value to leave it unset
+type Prompter func(s Secret) (string, error)
+
+// FromPackage returns the package's secret environment variables
+func FromPackage(pkg *model.Package) []Secret {
+	var secrets []Secret
+	for _, env := range pkg.EnvironmentVariables {
+		if !env.IsSecret {
+			continue
+		}
+		secrets = append(secrets, Secret{
+			Name:        env.Name,
+			Description: env.Description,
+			Required:    env.IsRequired,
+			Default:     env.Default,
+		})
+	}
+
+	return secrets
+}
+
+// Resolve returns the variable items to write for the secrets, given the variable's current
+// items and the supplied values. A supplied value replaces the current one. A secret without
+// either is prompted for when prompt is not nil, then falls back to its default. Items that
+// aren't secrets are kept.
+func Resolve(secrets []Secret, current, supplied map[string]string, prompt Prompter) (map[string]string, []Result, error) {
+	items := maps.Clone(current)
+	if items == nil {
+		items = make(map[string]string)
+	}
+
+	results := make([]Result, 0, len(secrets))
+	for _, s := range secrets {
+		r := Result{Secret: s, Status: StatusMissing}
+
+		if value := supplied[s.Name]; value != "" {
+			if items[s.Name] == value {
+				r.Status = StatusUnchanged
+			} else {
+				items[s.Name], r.Status = value, StatusUpdated
+			}
+		} else if items[s.Name] != "" {
+			r.Status = StatusUnchanged
+		} else if prompt != nil {
+			value, err := prompt(s)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read secret %s; %w", s.Name, err)
+			}
+			if value != "" {
+				items[s.Name], r.Status = value, StatusUpdated
+			}
+		}
+
+		if r.Status == StatusMissing && s.Default != "" {
+			items[s.Name], r.Status = s.Default, StatusDefault
+		}
+
+		results = append(results, r)
+	}
+
+	return items, results, nil
+}
+
+// Changed reports whether any secret was set
+func Changed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusUpdated || r.Status == StatusDefault {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingRequired returns the names of the required secrets that have no value
+func MissingRequired(results []Result) []string {
+	var missing []string
+	for _, r := range results {
+		if r.Required && r.Status == StatusMissing {
+			missing = append(missing, r.Name)
+		}
+	}
+	return missing
+}
+
+// ReadEnvFile reads NAME=value lines from an env file. Blank lines and comments starting
+// with # are skipped, an export prefix is allowed, and double quoted values are unquoted
+// like Go strings and single quoted values as is.
+func ReadEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file %s; %w", path, err)
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid line %d in env file %s; expected NAME=value", n, path)
+		}
+
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			if value, err = strconv.Unquote(value); err != nil {
+				return nil, fmt.Errorf("invalid value of %s on line %d in env file %s; %w", name, n, path, err)
+			}
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		}
+
+		values[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file %s; %w", path, err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,168 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/modelcontextprotocol/registry/pkg/model"
+)
+
+func TestFromPackage(t *testing.T) {
+	pkg := &model.Package{
+		EnvironmentVariables: []model.KeyValueInput{
+			{Name: "API_KEY", InputWithVariables: model.InputWithVariables{Input: model.Input{Description: "API key", IsRequired: true, IsSecret: true}}},
+			{Name: "LOG_LEVEL", InputWithVariables: model.InputWithVariables{Input: model.Input{Default: "info"}}},
+			{Name: "WEBHOOK_SECRET", InputWithVariables: model.InputWithVariables{Input: model.Input{Default: "none", IsSecret: true}}},
+		},
+	}
+
+	expected := []Secret{
+		{Name: "API_KEY", Description: "API key", Required: true},
+		{Name: "WEBHOOK_SECRET", Default: "none"},
+	}
+	if got := FromPackage(pkg); !slices.Equal(got, expected) {
+		t.Errorf("FromPackage() = %+v, expected %+v", got, expected)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	secrets := []Secret{
+		{Name: "SUPPLIED", Required: true},
+		{Name: "SAME", Required: true},
+		{Name: "CURRENT", Required: true},
+		{Name: "PROMPTED", Required: true},
+		{Name: "DEFAULTED", Default: "fallback"},
+		{Name: "MISSING", Required: true},
+		{Name: "OPTIONAL"},
+	}
+	current := map[string]string{"SAME": "same", "CURRENT": "current", "SUPPLIED": "old", "OTHER": "kept"}
+	supplied := map[string]string{"SUPPLIED": "new", "SAME": "same"}
+
+	var prompted []string
+	prompt := func(s Secret) (string, error) {
+		prompted = append(prompted, s.Name)
+		if s.Name == "PROMPTED" {
+			return "typed", nil
+		}
+		return "", nil
+	}
+
+	items, results, err := Resolve(secrets, current, supplied, prompt)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	expectedItems := map[string]string{
+		"SUPPLIED":  "new",
+		"SAME":      "same",
+		"CURRENT":   "current",
+		"PROMPTED":  "typed",
+		"DEFAULTED": "fallback",
+		"OTHER":     "kept",
+	}
+	if len(items) != len(expectedItems) {
+		t.Errorf("Resolve() items = %v, expected %v", items, expectedItems)
+	}
+	for name, value := range expectedItems {
+		if items[name] != value {
+			t.Errorf("Resolve() items[%s] = %q, expected %q", name, items[name], value)
+		}
+	}
+
+	expectedStatuses := []Status{StatusUpdated, StatusUnchanged, StatusUnchanged, StatusUpdated, StatusDefault, StatusMissing, StatusMissing}
+	for i, r := range results {
+		if r.Status != expectedStatuses[i] {
+			t.Errorf("Resolve() %s status = %s, expected %s", r.Name, r.Status, expectedStatuses[i])
+		}
+	}
+
+	if !slices.Equal(prompted, []string{"PROMPTED", "DEFAULTED", "MISSING", "OPTIONAL"}) {
+		t.Errorf("Resolve() prompted for %v", prompted)
+	}
+	if current["SUPPLIED"] != "old" {
+		t.Error("Resolve() modified the current items")
+	}
+	if !Changed(results) {
+		t.Error("Changed() = false, expected true")
+	}
+	if missing := MissingRequired(results); !slices.Equal(missing, []string{"MISSING"}) {
+		t.Errorf("MissingRequired() = %v, expected [MISSING]", missing)
+	}
+
+	_, results, err = Resolve(secrets[1:3], current, nil, nil)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if Changed(results) {
+		t.Error("Changed() = true for unchanged secrets")
+	}
+
+	if _, _, err := Resolve(secrets, nil, nil, func(Secret) (string, error) { return "", errors.New("closed") }); err == nil {
+		t.Error("Resolve() expected an error when the prompt fails")
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expected    map[string]string
+		expectError bool
+	}{
+		{
+			name:     "plain and quoted values",
+			content:  "# API credentials\nAPI_KEY=abc123\n\nexport TOKEN = \"line\\nbreak\"\nRAW='a \"b\" c'\nEMPTY=\nURL=https://example.com/?a=b\n",
+			expected: map[string]string{"API_KEY": "abc123", "TOKEN": "line\nbreak", "RAW": `a "b" c`, "EMPTY": "", "URL": "https://example.com/?a=b"},
+		},
+		{
+			name:        "missing equals sign",
+			content:     "API_KEY\n",
+			expectError: true,
+		},
+		{
+			name:        "missing name",
+			content:     "=value\n",
+			expectError: true,
+		},
+		{
+			name:        "invalid double quoted value",
+			content:     "API_KEY=\"bad\\q\"\n",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "secrets.env")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			values, err := ReadEnvFile(path)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("ReadEnvFile() expected an error, got %v", values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadEnvFile() error = %v", err)
+			}
+			if len(values) != len(tt.expected) {
+				t.Errorf("ReadEnvFile() = %v, expected %v", values, tt.expected)
+			}
+			for name, value := range tt.expected {
+				if values[name] != value {
+					t.Errorf("ReadEnvFile()[%s] = %q, expected %q", name, values[name], value)
+				}
+			}
+		})
+	}
+
+	if _, err := ReadEnvFile(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Error("ReadEnvFile() expected an error for a missing file")
+	}
+}
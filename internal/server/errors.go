@@ -2,6 +2,15 @@ package server
 
 import "fmt"
 
+type ServerNotFoundError struct {
+	Name        string
+	VersionSpec string
+}
+
+func (e *ServerNotFoundError) Error() string {
+	return fmt.Sprintf("no server %q found with version %q", e.Name, e.VersionSpec)
+}
+
 type PackageTypeNotFoundError struct {
 	PackageType           string
 	AvailablePackageTypes []string
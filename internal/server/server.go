@@ -9,6 +9,8 @@ import (
 	"strings"
 
 	"github.com/leefowlercu/go-mcp-registry/mcp"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/registry"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
 	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
 	"github.com/modelcontextprotocol/registry/pkg/model"
@@ -79,13 +81,13 @@ func ParseSearchSpec(s string) (*SearchSpec, error) {
 	}, nil
 }
 
-func Find(ctx context.Context, searchSpec *SearchSpec, client *mcp.Client) (*Spec, error) {
+func Find(ctx context.Context, searchSpec *SearchSpec, client *registry.Client) (*Spec, error) {
 	if searchSpec == nil {
 		return nil, errors.New("search spec must not be nil")
 	}
 
 	if client == nil {
-		return nil, errors.New("mcp client must not be nil")
+		return nil, errors.New("registry client must not be nil")
 	}
 
 	// Use List() to get ServerResponse with metadata including Status
@@ -100,13 +102,13 @@ func Find(ctx context.Context, searchSpec *SearchSpec, client *mcp.Client) (*Spe
 		opts.Version = searchSpec.VersionSpec
 	}
 
-	listResp, _, err := client.Servers.List(ctx, opts)
+	listResp, _, err := client.ListServers(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failure reading from registry; %w", err)
 	}
 
 	if listResp == nil || len(listResp.Servers) == 0 {
-		return nil, fmt.Errorf("no server %q found with version %q", searchSpec.FullName(), searchSpec.VersionSpec)
+		return nil, &ServerNotFoundError{Name: searchSpec.FullName(), VersionSpec: searchSpec.VersionSpec}
 	}
 
 	// Find exact match for the server name
@@ -119,7 +121,7 @@ func Find(ctx context.Context, searchSpec *SearchSpec, client *mcp.Client) (*Spe
 	}
 
 	if matchedServer == nil {
-		return nil, fmt.Errorf("no server %q found with version %q", searchSpec.FullName(), searchSpec.VersionSpec)
+		return nil, &ServerNotFoundError{Name: searchSpec.FullName(), VersionSpec: searchSpec.VersionSpec}
 	}
 
 	if searchSpec.IsLatest() {
@@ -133,6 +135,39 @@ func Find(ctx context.Context, searchSpec *SearchSpec, client *mcp.Client) (*Spe
 	}, nil
 }
 
+// FindInRegistries searches the registries in precedence order, or only the named registry,
+// returning the first registry with a server matching searchSpec
+func FindInRegistries(ctx context.Context, sources []config.RegistrySourceConfig, registryCfg config.RegistryConfig, registryName string, searchSpec *SearchSpec) (config.RegistrySourceConfig, *Spec, error) {
+	var notFound error = &ServerNotFoundError{Name: searchSpec.FullName(), VersionSpec: searchSpec.VersionSpec}
+
+	for _, source := range sources {
+		if registryName != "" && source.Name != registryName {
+			continue
+		}
+
+		client, err := registry.New(source.URL, source.ResolveToken(), registryCfg)
+		if err != nil {
+			return source, nil, fmt.Errorf("failed to create client for registry %s; %w", source.Name, err)
+		}
+
+		spec, err := Find(ctx, searchSpec, client)
+		var notFoundErr *ServerNotFoundError
+		if errors.As(err, &notFoundErr) {
+			slog.Debug("server not found in registry, trying next registry", "registry", source.Name, "server", searchSpec)
+			notFound = err
+			continue
+		}
+		if err != nil {
+			return source, nil, fmt.Errorf("failed to search registry %s; %w", source.Name, err)
+		}
+
+		slog.Info("found server in registry", "registry", source.Name, "server", spec.Name(), "version", spec.Version())
+		return source, spec, nil
+	}
+
+	return config.RegistrySourceConfig{}, nil, notFound
+}
+
 func FindPackageWithTransport(server *v0.ServerJSON, packageType, transportType string) (*model.Package, error) {
 	if server == nil {
 		return nil, fmt.Errorf("server must not be nil")
@@ -188,3 +223,45 @@ func FindPackageWithTransport(server *v0.ServerJSON, packageType, transportType
 		AvailableTransportTypes: uniqueUserTransports,
 	}
 }
+
+// FindPreferredPackage returns the first package found trying the package types, then the
+// transport types, in order of preference. Empty preferences match any package or transport type.
+func FindPreferredPackage(server *v0.ServerJSON, packageTypes, transportTypes []string) (*model.Package, error) {
+	if server == nil {
+		return nil, fmt.Errorf("server must not be nil")
+	}
+
+	availablePackageTypes := make([]string, 0, len(server.Packages))
+	for _, pkg := range server.Packages {
+		if !slices.Contains(availablePackageTypes, pkg.RegistryType) {
+			availablePackageTypes = append(availablePackageTypes, pkg.RegistryType)
+		}
+	}
+	if len(packageTypes) == 0 {
+		packageTypes = availablePackageTypes
+	}
+
+	var lastErr error = &PackageTypeNotFoundError{AvailablePackageTypes: availablePackageTypes}
+	for _, packageType := range packageTypes {
+		packageType = strings.ToLower(packageType)
+
+		if len(transportTypes) == 0 {
+			if i := slices.IndexFunc(server.Packages, func(pkg model.Package) bool { return pkg.RegistryType == packageType }); i >= 0 {
+				pkgCopy := server.Packages[i]
+				return &pkgCopy, nil
+			}
+			lastErr = &PackageTypeNotFoundError{PackageType: packageType, AvailablePackageTypes: availablePackageTypes}
+			continue
+		}
+
+		for _, transportType := range transportTypes {
+			pkg, err := FindPackageWithTransport(server, packageType, strings.ToLower(transportType))
+			if err == nil {
+				return pkg, nil
+			}
+			lastErr = err
+		}
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,151 @@
+package statestore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/filelock"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltBucket = []byte("nomad-mcp-pack")
+	boltKey    = []byte("watch_state")
+)
+
+// boltOpenTimeout bounds how long opening the database waits for another process's file lock
+const boltOpenTimeout = 5 * time.Second
+
+// BoltStore keeps the state in an embedded bbolt database, whose writes are
+// transactional and survive crashes part way through a save
+type BoltStore struct {
+	path string
+	mu   sync.Mutex
+	db   *bolt.DB
+}
+
+func NewBoltStore(path string) *BoltStore {
+	return &BoltStore{path: path}
+}
+
+// open opens the database on first use, so it is only opened once the store is locked
+func (s *BoltStore) open() (*bolt.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db, nil
+	}
+
+	db, err := bolt.Open(s.path, 0644, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state database %s; %w", s.path, err)
+	}
+	s.db = db
+
+	return db, nil
+}
+
+func (s *BoltStore) Load(ctx context.Context) ([]byte, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		if bucket == nil {
+			return nil
+		}
+		if v := bucket.Get(boltKey); v != nil {
+			// Values are only valid for the life of the transaction
+			data = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state database; %w", err)
+	}
+
+	return data, nil
+}
+
+func (s *BoltStore) Save(ctx context.Context, data []byte) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(boltBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(boltKey, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write state database; %w", err)
+	}
+
+	return nil
+}
+
+// Backup writes data to the <key>.<name>.bak key of the state bucket
+func (s *BoltStore) Backup(ctx context.Context, data []byte, name string) (string, error) {
+	db, err := s.open()
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s.%s.bak", boltKey, name)
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(boltBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), data)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to write state backup; %w", err)
+	}
+
+	return fmt.Sprintf("%s#%s", s, key), nil
+}
+
+// Check verifies the database can be opened, or created, for writing
+func (s *BoltStore) Check(ctx context.Context) error {
+	s.mu.Lock()
+	open := s.db != nil
+	s.mu.Unlock()
+
+	if open {
+		return nil
+	}
+
+	return checkWritable(s.path)
+}
+
+func (s *BoltStore) Lock(ctx context.Context, timeout time.Duration) (*filelock.Lock, error) {
+	return filelock.Acquire(ctx, s.path, timeout)
+}
+
+func (s *BoltStore) String() string {
+	return "bolt://" + s.path
+}
+
+func (s *BoltStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return nil
+	}
+
+	err := s.db.Close()
+	s.db = nil
+
+	return err
+}
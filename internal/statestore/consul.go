@@ -0,0 +1,263 @@
+package statestore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultConsulAddress is the Consul API address used when none is configured
+const defaultConsulAddress = "http://127.0.0.1:8500"
+
+// consulRequestTimeout bounds each request to the Consul API
+const consulRequestTimeout = 30 * time.Second
+
+// ConsulStore keeps the state in a Consul KV key, written with check-and-set so
+// a save fails rather than overwrite state saved by another process
+type ConsulStore struct {
+	baseURL    *url.URL
+	key        string
+	token      string
+	datacenter string
+	http       *http.Client
+
+	mu    sync.Mutex
+	index uint64
+}
+
+// NewConsulStore creates a store from a URL of the form
+// consul://[host:port]/path/to/key[?token=...&dc=...&tls=true]. Without a host
+// CONSUL_HTTP_ADDR is used, and without a token CONSUL_HTTP_TOKEN.
+func NewConsulStore(location string) (*ConsulStore, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse consul state store URL; %w", err)
+	}
+
+	key := strings.Trim(u.Path, "/")
+	if key == "" {
+		return nil, fmt.Errorf("consul state store URL must include a key path")
+	}
+
+	query := u.Query()
+	address, err := serviceAddress(u.Host, query.Get("tls") == "true", os.Getenv("CONSUL_HTTP_ADDR"), defaultConsulAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid consul address; %w", err)
+	}
+
+	token := query.Get("token")
+	if token == "" {
+		token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+
+	return &ConsulStore{
+		baseURL:    address,
+		key:        key,
+		token:      token,
+		datacenter: query.Get("dc"),
+		http:       &http.Client{Timeout: consulRequestTimeout},
+	}, nil
+}
+
+// consulKVPair is a Consul KV entry, as returned by the KV and transaction endpoints
+type consulKVPair struct {
+	Key         string
+	Value       []byte
+	ModifyIndex uint64
+}
+
+func (s *ConsulStore) Load(ctx context.Context) ([]byte, error) {
+	pair, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pair == nil {
+		s.index = 0
+		return nil, nil
+	}
+	s.index = pair.ModifyIndex
+
+	return decompress(pair.Value)
+}
+
+func (s *ConsulStore) Save(ctx context.Context, data []byte) error {
+	value, err := compress(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A transaction is used rather than a KV PUT as it returns the new modify index
+	ops := []map[string]any{{
+		"KV": map[string]any{
+			"Verb":  "cas",
+			"Key":   s.key,
+			"Value": value,
+			"Index": s.index,
+		},
+	}}
+	body, err := json.Marshal(ops)
+	if err != nil {
+		return fmt.Errorf("failed to marshal consul transaction; %w", err)
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, "/v1/txn", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return fmt.Errorf("failed to save state to %s; %w", s, ErrConflict)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return consulError(resp)
+	}
+
+	var result struct {
+		Results []struct {
+			KV consulKVPair
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode consul transaction response; %w", err)
+	}
+	if len(result.Results) == 1 {
+		s.index = result.Results[0].KV.ModifyIndex
+	}
+
+	return nil
+}
+
+// Backup writes data, compressed, to the <key>.<name>.bak key
+func (s *ConsulStore) Backup(ctx context.Context, data []byte, name string) (string, error) {
+	value, err := compress(data)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s.%s.bak", s.key, name)
+	resp, err := s.do(ctx, http.MethodPut, "/v1/kv/"+key, bytes.NewReader(value))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", consulError(resp)
+	}
+
+	return fmt.Sprintf("consul://%s/%s", s.baseURL.Host, key), nil
+}
+
+// Check verifies the key can be read
+func (s *ConsulStore) Check(ctx context.Context) error {
+	_, err := s.get(ctx)
+	return err
+}
+
+func (s *ConsulStore) String() string {
+	return fmt.Sprintf("consul://%s/%s", s.baseURL.Host, s.key)
+}
+
+func (s *ConsulStore) Close() error {
+	return nil
+}
+
+// get reads the key, returning nil if it does not exist
+func (s *ConsulStore) get(ctx context.Context) (*consulKVPair, error) {
+	resp, err := s.do(ctx, http.MethodGet, "/v1/kv/"+s.key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, consulError(resp)
+	}
+
+	var pairs []consulKVPair
+	if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+		return nil, fmt.Errorf("failed to decode consul KV response; %w", err)
+	}
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	return &pairs[0], nil
+}
+
+func (s *ConsulStore) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	u := s.baseURL.JoinPath(path)
+	if s.datacenter != "" {
+		u.RawQuery = url.Values{"dc": {s.datacenter}}.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul request; %w", err)
+	}
+	if s.token != "" {
+		req.Header.Set("X-Consul-Token", s.token)
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("consul request failed; %w", err)
+	}
+
+	return resp, nil
+}
+
+func consulError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("consul API %s %s returned %d: %s", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+// serviceAddress builds the API address for a network store from the URL host,
+// falling back to the address from the environment and then the default
+func serviceAddress(host string, tls bool, envAddress, defaultAddress string) (*url.URL, error) {
+	if host != "" {
+		scheme := "http"
+		if tls {
+			scheme = "https"
+		}
+		return &url.URL{Scheme: scheme, Host: host}, nil
+	}
+
+	address := envAddress
+	if address == "" {
+		address = defaultAddress
+	}
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, errors.New("address has no host")
+	}
+
+	return u, nil
+}
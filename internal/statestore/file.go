@@ -0,0 +1,93 @@
+package statestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/filelock"
+)
+
+// FileStore keeps the state in a JSON file
+type FileStore struct {
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Load(ctx context.Context) ([]byte, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	return data, nil
+}
+
+func (s *FileStore) Save(ctx context.Context, data []byte) error {
+	// Write to temp file first to ensure atomic operation
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	// Move temp file to final location
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to rename state file: %w", err)
+	}
+
+	return nil
+}
+
+// Backup writes data to <path>.<name>.bak
+func (s *FileStore) Backup(ctx context.Context, data []byte, name string) (string, error) {
+	path := fmt.Sprintf("%s.%s.bak", s.path, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write state backup: %w", err)
+	}
+	return path, nil
+}
+
+// Check verifies the state file, or its directory when it doesn't exist yet, is writable
+func (s *FileStore) Check(ctx context.Context) error {
+	return checkWritable(s.path)
+}
+
+func (s *FileStore) Lock(ctx context.Context, timeout time.Duration) (*filelock.Lock, error) {
+	return filelock.Acquire(ctx, s.path, timeout)
+}
+
+func (s *FileStore) String() string {
+	return s.path
+}
+
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// checkWritable verifies the file at path, or its directory when it doesn't exist yet, is writable
+func checkWritable(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err == nil {
+		return f.Close()
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("state file is not writable; %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".readyz-*")
+	if err != nil {
+		return fmt.Errorf("state file directory is not writable; %w", err)
+	}
+	tmp.Close()
+	os.Remove(tmp.Name())
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package statestore
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+)
+
+// nomadStateItem is the variable item holding the compressed, base64 encoded state
+const nomadStateItem = "state"
+
+// NomadStore keeps the state in a Nomad Variable, written with check-and-set so
+// a save fails rather than overwrite state saved by another process
+type NomadStore struct {
+	client    *nomad.Client
+	path      string
+	namespace string
+
+	mu    sync.Mutex
+	index uint64
+}
+
+// NewNomadStore creates a store from a URL of the form
+// nomad://[host:port]/path/to/variable[?token=...&namespace=...&region=...&tls=true].
+// Without a host NOMAD_ADDR is used, and the token, namespace and region default
+// to NOMAD_TOKEN, NOMAD_NAMESPACE and NOMAD_REGION.
+func NewNomadStore(location string) (*NomadStore, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse nomad state store URL; %w", err)
+	}
+
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		return nil, fmt.Errorf("nomad state store URL must include a variable path")
+	}
+
+	cfg := nomad.DefaultConfig()
+	query := u.Query()
+
+	address, err := serviceAddress(u.Host, query.Get("tls") == "true", os.Getenv("NOMAD_ADDR"), nomad.DefaultAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nomad address; %w", err)
+	}
+	cfg.Address = address.String()
+
+	if token := query.Get("token"); token != "" {
+		cfg.Token = token
+	}
+	if namespace := query.Get("namespace"); namespace != "" {
+		cfg.Namespace = namespace
+	}
+	if region := query.Get("region"); region != "" {
+		cfg.Region = region
+	}
+
+	client, err := nomad.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NomadStore{
+		client:    client,
+		path:      path,
+		namespace: cfg.Namespace,
+	}, nil
+}
+
+func (s *NomadStore) Load(ctx context.Context) ([]byte, error) {
+	v, err := s.client.GetVariable(ctx, s.path)
+	if err != nil && !errors.Is(err, nomad.ErrNotFound) {
+		return nil, fmt.Errorf("failed to read state variable; %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v == nil {
+		s.index = 0
+		return nil, nil
+	}
+	s.index = v.ModifyIndex
+
+	encoded, ok := v.Items[nomadStateItem]
+	if !ok {
+		return nil, fmt.Errorf("state variable %s has no %q item", s.path, nomadStateItem)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode state variable; %w", err)
+	}
+
+	return decompress(data)
+}
+
+func (s *NomadStore) Save(ctx context.Context, data []byte) error {
+	value, err := compress(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, err := s.client.PutVariable(ctx, &nomad.Variable{
+		Namespace:   s.namespace,
+		Path:        s.path,
+		Items:       map[string]string{nomadStateItem: base64.StdEncoding.EncodeToString(value)},
+		ModifyIndex: s.index,
+	}, true)
+	if errors.Is(err, nomad.ErrCASConflict) {
+		return fmt.Errorf("failed to save state to %s; %w", s, ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write state variable; %w", err)
+	}
+
+	s.index = v.ModifyIndex
+
+	return nil
+}
+
+// Backup writes data to the <path>-<name>-bak variable, as variable paths can't contain dots
+func (s *NomadStore) Backup(ctx context.Context, data []byte, name string) (string, error) {
+	value, err := compress(data)
+	if err != nil {
+		return "", err
+	}
+
+	path := fmt.Sprintf("%s-%s-bak", s.path, name)
+	_, err = s.client.PutVariable(ctx, &nomad.Variable{
+		Namespace: s.namespace,
+		Path:      path,
+		Items:     map[string]string{nomadStateItem: base64.StdEncoding.EncodeToString(value)},
+	}, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to write state backup variable; %w", err)
+	}
+
+	u, _ := url.Parse(s.client.Address())
+	return fmt.Sprintf("nomad://%s/%s", u.Host, path), nil
+}
+
+// Check verifies the variable can be read
+func (s *NomadStore) Check(ctx context.Context) error {
+	if _, err := s.client.GetVariable(ctx, s.path); err != nil && !errors.Is(err, nomad.ErrNotFound) {
+		return fmt.Errorf("failed to read state variable; %w", err)
+	}
+	return nil
+}
+
+func (s *NomadStore) String() string {
+	u, _ := url.Parse(s.client.Address())
+	return fmt.Sprintf("nomad://%s/%s", u.Host, s.path)
+}
+
+func (s *NomadStore) Close() error {
+	return nil
+}
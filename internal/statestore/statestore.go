@@ -0,0 +1,132 @@
+package statestore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/filelock"
+)
+
+// ErrConflict is returned by Save when the stored state was changed by another
+// process since this process loaded it
+var ErrConflict = errors.New("state was modified by another process")
+
+// Store persists the serialized watch state
+type Store interface {
+	// Load returns the saved state, or nil if no state has been saved yet
+	Load(ctx context.Context) ([]byte, error)
+	// Save replaces the saved state
+	Save(ctx context.Context, data []byte) error
+	// Backup saves a copy of data next to the state under name, returning where it was written
+	Backup(ctx context.Context, data []byte, name string) (string, error)
+	// Check verifies the store is usable, for readiness checks
+	Check(ctx context.Context) error
+	// String describes the store location without credentials
+	String() string
+	Close() error
+}
+
+// Locker is implemented by stores on the local filesystem, which are locked so
+// concurrent processes on the same host can't overwrite each other's state.
+// Network stores instead reject saves that would overwrite a newer state.
+type Locker interface {
+	Lock(ctx context.Context, timeout time.Duration) (*filelock.Lock, error)
+}
+
+// Open returns the store for location, which is either a file path or a URL:
+//
+//	./watch.json, file://./watch.json     JSON file
+//	bolt://./watch.db                     Embedded bbolt database
+//	consul://host:8500/path/to/key        Consul KV key
+//	nomad://host:4646/path/to/variable    Nomad Variable
+func Open(location string) (Store, error) {
+	scheme, rest, ok := strings.Cut(location, "://")
+	if !ok {
+		return NewFileStore(location), nil
+	}
+
+	switch scheme {
+	case "file":
+		return NewFileStore(rest), nil
+	case "bolt":
+		return NewBoltStore(rest), nil
+	case "consul":
+		return NewConsulStore(location)
+	case "nomad":
+		return NewNomadStore(location)
+	default:
+		return nil, fmt.Errorf("unsupported state store scheme %q", scheme)
+	}
+}
+
+// Redact returns location with the token in its query string masked, for logging
+func Redact(location string) string {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme == "" {
+		return location
+	}
+
+	query := u.Query()
+	if !query.Has("token") {
+		return location
+	}
+	query.Set("token", "xxxxx")
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// Lock takes the store's lock if it has one, returning a function that releases it
+func Lock(ctx context.Context, store Store, timeout time.Duration) (func(), error) {
+	locker, ok := store.(Locker)
+	if !ok {
+		return func() {}, nil
+	}
+
+	lock, err := locker.Lock(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { lock.Release() }, nil
+}
+
+// compress gzips state for network stores, which limit the size of stored values
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress state; %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress state; %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress reverses compress, returning uncompressed data as is so state
+// written by hand can still be loaded
+func decompress(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress state; %w", err)
+	}
+	defer zr.Close()
+
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress state; %w", err)
+	}
+	return out, nil
+}
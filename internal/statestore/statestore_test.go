@@ -0,0 +1,317 @@
+package statestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/nomad"
+)
+
+// fakeConsul is a stand-in for the Consul KV and transaction endpoints
+type fakeConsul struct {
+	mu     sync.Mutex
+	index  uint64
+	values map[string]consulKVPair
+	token  string
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.token = r.Header.Get("X-Consul-Token")
+
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/kv/"):
+		pair, ok := f.values[strings.TrimPrefix(r.URL.Path, "/v1/kv/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode([]consulKVPair{pair})
+	case r.Method == http.MethodPut && r.URL.Path == "/v1/txn":
+		var ops []struct {
+			KV struct {
+				Verb  string
+				Key   string
+				Value []byte
+				Index uint64
+			}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&ops); err != nil || len(ops) != 1 || ops[0].KV.Verb != "cas" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		op := ops[0].KV
+		if f.values[op.Key].ModifyIndex != op.Index {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"Results":null,"Errors":[{"OpIndex":0,"What":"index is stale"}]}`))
+			return
+		}
+		f.index++
+		f.values[op.Key] = consulKVPair{Key: op.Key, Value: op.Value, ModifyIndex: f.index}
+		json.NewEncoder(w).Encode(map[string]any{
+			"Results": []map[string]any{{"KV": map[string]any{"Key": op.Key, "ModifyIndex": f.index}}},
+		})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// fakeNomad is a stand-in for the Nomad Variables endpoints
+type fakeNomad struct {
+	mu        sync.Mutex
+	index     uint64
+	variables map[string]nomad.Variable
+	token     string
+	namespace string
+}
+
+func (f *fakeNomad) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.token = r.Header.Get("X-Nomad-Token")
+	f.namespace = r.URL.Query().Get("namespace")
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/var/")
+	switch r.Method {
+	case http.MethodGet:
+		v, ok := f.variables[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(v)
+	case http.MethodPut:
+		var v nomad.Variable
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		current := f.variables[path]
+		if cas := r.URL.Query().Get("cas"); cas != "" {
+			if index, _ := strconv.ParseUint(cas, 10, 64); index != current.ModifyIndex {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(current)
+				return
+			}
+		}
+		f.index++
+		v.ModifyIndex = f.index
+		f.variables[path] = v
+		json.NewEncoder(w).Encode(v)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// testRoundTrip checks a store starts empty and loads what it saved
+func testRoundTrip(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	data, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if data != nil {
+		t.Fatalf("Load() = %q, expected no state", data)
+	}
+
+	for _, state := range []string{`{"servers":{}}`, `{"servers":{"a":{}}}`} {
+		if err := store.Save(ctx, []byte(state)); err != nil {
+			t.Fatalf("Save() unexpected error = %v", err)
+		}
+
+		data, err = store.Load(ctx)
+		if err != nil {
+			t.Fatalf("Load() unexpected error = %v", err)
+		}
+		if string(data) != state {
+			t.Errorf("Load() = %q, expected %q", data, state)
+		}
+	}
+
+	if err := store.Check(ctx); err != nil {
+		t.Errorf("Check() unexpected error = %v", err)
+	}
+}
+
+// testConflict checks a save is rejected once another process saved after the state was loaded
+func testConflict(t *testing.T, first, second Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := first.Load(ctx); err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if _, err := second.Load(ctx); err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+
+	if err := second.Save(ctx, []byte(`{"servers":{"second":{}}}`)); err != nil {
+		t.Fatalf("Save() unexpected error = %v", err)
+	}
+
+	if err := first.Save(ctx, []byte(`{"servers":{"first":{}}}`)); !errors.Is(err, ErrConflict) {
+		t.Errorf("Save() error = %v, expected ErrConflict", err)
+	}
+
+	// Reloading picks up the other process's state, after which saves succeed again
+	data, err := first.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if !strings.Contains(string(data), "second") {
+		t.Errorf("Load() = %q, expected the second process's state", data)
+	}
+	if err := first.Save(ctx, []byte(`{"servers":{"first":{}}}`)); err != nil {
+		t.Errorf("Save() unexpected error after reload = %v", err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	testRoundTrip(t, NewFileStore(filepath.Join(t.TempDir(), "watch.json")))
+}
+
+func TestBoltStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.db")
+
+	store := NewBoltStore(path)
+	testRoundTrip(t, store)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() unexpected error = %v", err)
+	}
+
+	// State survives reopening the database
+	reopened := NewBoltStore(path)
+	defer reopened.Close()
+
+	data, err := reopened.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if string(data) != `{"servers":{"a":{}}}` {
+		t.Errorf("Load() = %q after reopening", data)
+	}
+}
+
+func TestConsulStore(t *testing.T) {
+	fake := &fakeConsul{values: make(map[string]consulKVPair)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	location := "consul://" + strings.TrimPrefix(srv.URL, "http://") + "/nomad-mcp-pack/state?token=secret"
+	open := func() Store {
+		store, err := Open(location)
+		if err != nil {
+			t.Fatalf("Open() unexpected error = %v", err)
+		}
+		return store
+	}
+
+	store := open()
+	testRoundTrip(t, store)
+
+	if fake.token != "secret" {
+		t.Errorf("request token = %q, expected secret", fake.token)
+	}
+	if strings.Contains(store.String(), "secret") {
+		t.Errorf("String() = %q, expected the token to be omitted", store.String())
+	}
+
+	// Values are stored compressed
+	if v := fake.values["nomad-mcp-pack/state"].Value; len(v) < 2 || v[0] != 0x1f || v[1] != 0x8b {
+		t.Errorf("stored value is not gzip compressed")
+	}
+
+	testConflict(t, open(), open())
+}
+
+func TestNomadStore(t *testing.T) {
+	fake := &fakeNomad{variables: make(map[string]nomad.Variable)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	location := "nomad://" + strings.TrimPrefix(srv.URL, "http://") + "/nomad/jobs/nomad-mcp-pack?token=secret&namespace=tools"
+	open := func() Store {
+		store, err := Open(location)
+		if err != nil {
+			t.Fatalf("Open() unexpected error = %v", err)
+		}
+		return store
+	}
+
+	testRoundTrip(t, open())
+
+	if fake.token != "secret" {
+		t.Errorf("request token = %q, expected secret", fake.token)
+	}
+	if fake.namespace != "tools" {
+		t.Errorf("request namespace = %q, expected tools", fake.namespace)
+	}
+	if _, ok := fake.variables["nomad/jobs/nomad-mcp-pack"].Items[nomadStateItem]; !ok {
+		t.Errorf("variable has no %q item", nomadStateItem)
+	}
+
+	testConflict(t, open(), open())
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		location string
+		expected string
+	}{
+		{location: "./watch.json", expected: "./watch.json"},
+		{location: "file:///var/lib/watch.json", expected: "/var/lib/watch.json"},
+		{location: "bolt://./watch.db", expected: "bolt://./watch.db"},
+		{location: "consul://consul.service:8500/nomad-mcp-pack/state", expected: "consul://consul.service:8500/nomad-mcp-pack/state"},
+		{location: "nomad://nomad.service:4646/nomad/jobs/watch", expected: "nomad://nomad.service:4646/nomad/jobs/watch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			store, err := Open(tt.location)
+			if err != nil {
+				t.Fatalf("Open() unexpected error = %v", err)
+			}
+			if store.String() != tt.expected {
+				t.Errorf("Open().String() = %q, expected %q", store.String(), tt.expected)
+			}
+		})
+	}
+
+	if _, err := Open("s3://bucket/state"); err == nil {
+		t.Error("Open() expected error for unsupported scheme")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		location string
+		expected string
+	}{
+		{location: "./watch.json", expected: "./watch.json"},
+		{location: "bolt://./watch.db", expected: "bolt://./watch.db"},
+		{location: "consul://consul.service:8500/nomad-mcp-pack/state?dc=dc1", expected: "consul://consul.service:8500/nomad-mcp-pack/state?dc=dc1"},
+		{location: "consul://consul.service:8500/nomad-mcp-pack/state?token=secret&dc=dc1", expected: "consul://consul.service:8500/nomad-mcp-pack/state?dc=dc1&token=xxxxx"},
+		{location: "nomad:///nomad/jobs/watch?token=secret", expected: "nomad:///nomad/jobs/watch?token=xxxxx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			if got := Redact(tt.location); got != tt.expected {
+				t.Errorf("Redact() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
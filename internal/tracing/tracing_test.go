@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareRequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		expectSame bool
+	}{
+		{name: "valid request ID is kept", header: "client-id.123", expectSame: true},
+		{name: "missing request ID is generated", header: ""},
+		{name: "invalid request ID is replaced", header: "bad id\nwith newline"},
+		{name: "overlong request ID is replaced", header: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen, _ = utils.GetRequestID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/packs", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			if got != seen {
+				t.Errorf("response request ID %q doesn't match the handler's %q", got, seen)
+			}
+			if !requestIDPattern.MatchString(got) {
+				t.Errorf("response request ID %q is not valid", got)
+			}
+			if tt.expectSame && got != tt.header {
+				t.Errorf("request ID = %q, expected %q", got, tt.header)
+			}
+			if !tt.expectSame && got == tt.header {
+				t.Errorf("request ID %q was not replaced", got)
+			}
+		})
+	}
+}
+
+func TestMiddlewareLogsUser(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(utils.SetupLoggerWithWriter(config.LogLevelInfo, config.EnvProd, &logs))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	// The user is set by the handler, as authentication happens after the middleware
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WithUserID(r.Context(), "ci-deployer")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/packs", nil))
+
+	var entry struct {
+		Msg     string `json:"msg"`
+		Context struct {
+			UserID string `json:"user_id"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to parse log entry %q: %v", logs.String(), err)
+	}
+	if entry.Msg != "handled request" || entry.Context.UserID != "ci-deployer" {
+		t.Errorf("log entry = %s, expected handled request with user_id ci-deployer", logs.String())
+	}
+}
+
+func TestTransportPropagation(t *testing.T) {
+	if _, err := Setup(t.Context(), config.TracingConfig{}, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	t.Cleanup(srv.Close)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	parent := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled, Remote: true})
+
+	ctx := utils.WithRequestID(t.Context(), "poll-123")
+	ctx = trace.ContextWithRemoteSpanContext(ctx, parent)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := header.Get(RequestIDHeader); got != "poll-123" {
+		t.Errorf("%s = %q, expected %q", RequestIDHeader, got, "poll-123")
+	}
+	if got := header.Get("traceparent"); !strings.Contains(got, traceID.String()) {
+		t.Errorf("traceparent = %q, expected trace ID %s", got, traceID)
+	}
+}
+
+func TestEnsureRequestID(t *testing.T) {
+	ctx := utils.WithRequestID(context.Background(), "existing")
+	if id, _ := utils.GetRequestID(EnsureRequestID(ctx)); id != "existing" {
+		t.Errorf("request ID = %q, expected existing ID to be kept", id)
+	}
+
+	if id, ok := utils.GetRequestID(EnsureRequestID(context.Background())); !ok || id == "" {
+		t.Errorf("expected a request ID to be generated")
+	}
+}
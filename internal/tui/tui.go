@@ -0,0 +1,244 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/watcher"
+)
+
+// refreshInterval is how often the dashboard refreshes the watcher status
+const refreshInterval = 500 * time.Millisecond
+
+// maxListItems is the number of entries shown in each list section
+const maxListItems = 5
+
+// Watcher is the subset of the watcher used by the dashboard
+type Watcher interface {
+	Status() watcher.Status
+	TriggerPoll()
+	RetryFailures()
+}
+
+// Options describes the watch configuration shown in the dashboard header
+type Options struct {
+	RegistryURL   string
+	OutputDir     string
+	MaxConcurrent int
+}
+
+var (
+	titleStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	headerStyle  = lipgloss.NewStyle().Bold(true)
+	faintStyle   = lipgloss.NewStyle().Faint(true)
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	failureStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	activeStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+)
+
+type tickMsg time.Time
+
+type model struct {
+	watcher Watcher
+	opts    Options
+	status  watcher.Status
+	width   int
+	notice  string
+}
+
+// Run shows the watch dashboard until the user quits or ctx is cancelled
+func Run(ctx context.Context, w Watcher, opts Options) error {
+	m := model{
+		watcher: w,
+		opts:    opts,
+		status:  w.Status(),
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))
+	if _, err := p.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		return fmt.Errorf("terminal UI failed; %w", err)
+	}
+
+	return nil
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+func (m model) Init() tea.Cmd {
+	return tick()
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		case "p":
+			m.watcher.TriggerPoll()
+			m.notice = "Immediate poll requested"
+		case "r":
+			m.watcher.RetryFailures()
+			m.notice = "Retrying failed generations"
+		}
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		return m, nil
+	case tickMsg:
+		m.status = m.watcher.Status()
+		if m.status.Polling {
+			m.notice = ""
+		}
+		return m, tick()
+	}
+
+	return m, nil
+}
+
+func (m model) View() string {
+	s := m.status
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Nomad MCP Pack Watch"))
+	b.WriteString("  ")
+	if s.Polling {
+		b.WriteString(activeStyle.Render("polling..."))
+	} else if !s.NextPoll.IsZero() {
+		b.WriteString(fmt.Sprintf("next poll in %s", formatCountdown(time.Until(s.NextPoll))))
+	}
+	if !s.Polling && s.QuietUntil.After(time.Now()) {
+		b.WriteString(faintStyle.Render(fmt.Sprintf("  (quiet until %s)", s.QuietUntil.Format("Mon 15:04"))))
+	}
+	b.WriteString("\n")
+	b.WriteString(faintStyle.Render(fmt.Sprintf("Registry: %s  Output: %s", m.opts.RegistryURL, m.opts.OutputDir)))
+	b.WriteString("\n\n")
+
+	// Poll summary
+	lastPoll := "never"
+	if !s.LastPoll.IsZero() {
+		lastPoll = s.LastPoll.Format(time.TimeOnly)
+	}
+	b.WriteString(fmt.Sprintf("Last poll: %s  Servers fetched: %d  Needing generation: %d\n", lastPoll, s.ServersFetched, s.TasksNeeded))
+	if s.LastError != "" {
+		b.WriteString(failureStyle.Render(m.truncate("Last error: " + s.LastError)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	// Workers
+	b.WriteString(headerStyle.Render("Workers"))
+	b.WriteString("\n")
+	running := make(map[int]watcher.TaskProgress)
+	for _, t := range s.Running {
+		running[t.Worker] = t
+	}
+	for i := range m.opts.MaxConcurrent {
+		t, ok := running[i]
+		if !ok {
+			b.WriteString(faintStyle.Render(fmt.Sprintf("  #%d  idle", i+1)))
+			b.WriteString("\n")
+			continue
+		}
+		line := fmt.Sprintf("  #%d  %s  %s  %s", i+1, taskLabel(t), activeStyle.Render(string(t.Phase)), time.Since(t.StartedAt).Round(time.Second))
+		b.WriteString(m.truncate(line))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	// Queue
+	b.WriteString(headerStyle.Render(fmt.Sprintf("Queue (%d pending)", len(s.Queued))))
+	b.WriteString("\n")
+	queued := s.Queued
+	sort.Slice(queued, func(i, j int) bool { return queued[i].Key < queued[j].Key })
+	for i, t := range queued {
+		if i == maxListItems {
+			b.WriteString(faintStyle.Render(fmt.Sprintf("  ... and %d more", len(queued)-maxListItems)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString(m.truncate("  " + taskLabel(t)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	// Recent results
+	b.WriteString(headerStyle.Render("Recent successes"))
+	b.WriteString("\n")
+	for i, r := range s.RecentSuccesses {
+		if i == maxListItems {
+			break
+		}
+		line := fmt.Sprintf("  %s  %s", r.FinishedAt.Format(time.TimeOnly), successStyle.Render(taskLabel(r.TaskProgress)))
+		if r.Skipped {
+			line += faintStyle.Render("  skipped, pack exists")
+		}
+		b.WriteString(m.truncate(line))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString(headerStyle.Render(fmt.Sprintf("Recent failures (%d)", len(s.RecentFailures))))
+	b.WriteString("\n")
+	for i, r := range s.RecentFailures {
+		if i == maxListItems {
+			break
+		}
+		b.WriteString(m.truncate(fmt.Sprintf("  %s  %s", r.FinishedAt.Format(time.TimeOnly), failureStyle.Render(taskLabel(r.TaskProgress)))))
+		b.WriteString("\n")
+		b.WriteString(faintStyle.Render(m.truncate("      " + r.Error)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	// State file summary
+	b.WriteString(headerStyle.Render("State"))
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("  %s: %d packs tracked, %d failed, %d quarantined\n", s.StateFile, s.StateServers, s.StateFailed, s.StateQuarantined))
+	b.WriteString("\n")
+
+	if m.notice != "" {
+		b.WriteString(activeStyle.Render(m.notice))
+		b.WriteString("\n")
+	}
+	b.WriteString(faintStyle.Render("p poll now • r retry failures • q quit"))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// truncate shortens a line to the terminal width
+func (m model) truncate(line string) string {
+	if m.width <= 0 || lipgloss.Width(line) <= m.width {
+		return line
+	}
+
+	runes := []rune(line)
+	for len(runes) > 0 && lipgloss.Width(string(runes)) > m.width-1 {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "…"
+}
+
+func taskLabel(t watcher.TaskProgress) string {
+	return fmt.Sprintf("%s@%s (%s, %s)", t.ServerName, t.Version, t.PackageType, utils.MapFromRegistryTransportType(t.TransportType))
+}
+
+func formatCountdown(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return d.Round(time.Second).String()
+}
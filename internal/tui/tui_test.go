@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/watcher"
+)
+
+// fakeWatcher records the dashboard's requests and serves a fixed status
+type fakeWatcher struct {
+	status  watcher.Status
+	polls   int
+	retries int
+}
+
+func (f *fakeWatcher) Status() watcher.Status { return f.status }
+func (f *fakeWatcher) TriggerPoll()           { f.polls++ }
+func (f *fakeWatcher) RetryFailures()         { f.retries++ }
+
+func TestModelUpdate(t *testing.T) {
+	w := &fakeWatcher{}
+	var m tea.Model = model{watcher: w, opts: Options{MaxConcurrent: 2}}
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if w.polls != 1 || cmd != nil || m.(model).notice != "Immediate poll requested" {
+		t.Errorf("p: polls = %d, notice = %q", w.polls, m.(model).notice)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if w.retries != 1 || m.(model).notice != "Retrying failed generations" {
+		t.Errorf("r: retries = %d, notice = %q", w.retries, m.(model).notice)
+	}
+
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	if m.(model).width != 80 {
+		t.Errorf("WindowSizeMsg: width = %d, expected 80", m.(model).width)
+	}
+
+	// A refresh reads the status, clearing the notice once the requested poll starts
+	w.status = watcher.Status{Polling: true, ServersFetched: 3}
+	m, cmd = m.Update(tickMsg(time.Now()))
+	if cmd == nil || m.(model).status.ServersFetched != 3 || m.(model).notice != "" {
+		t.Errorf("tick: status = %+v, notice = %q, cmd = %v", m.(model).status, m.(model).notice, cmd)
+	}
+
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("q")}, {Type: tea.KeyCtrlC}, {Type: tea.KeyEsc}} {
+		if _, cmd := m.Update(key); cmd == nil {
+			t.Errorf("%s: expected a quit command", key)
+		} else if _, ok := cmd().(tea.QuitMsg); !ok {
+			t.Errorf("%s: expected a quit command", key)
+		}
+	}
+}
+
+func TestModelView(t *testing.T) {
+	now := time.Now()
+	task := watcher.TaskProgress{ServerName: "io.github.example/weather-mcp", Version: "1.0.0", PackageType: "oci", TransportType: "streamable-http"}
+	m := model{
+		opts: Options{RegistryURL: "https://registry.example.com", OutputDir: "./packs", MaxConcurrent: 2},
+		status: watcher.Status{
+			Running:         []watcher.TaskProgress{{ServerName: "io.github.example/news-mcp", Version: "2.0.0", PackageType: "npm", TransportType: "stdio", Worker: 1, Phase: watcher.TaskPhaseGenerating, StartedAt: now}},
+			RecentSuccesses: []watcher.TaskResult{{TaskProgress: task, FinishedAt: now, Skipped: true}},
+			RecentFailures:  []watcher.TaskResult{{TaskProgress: task, FinishedAt: now, Error: "template failed"}},
+		},
+	}
+
+	view := m.View()
+	for _, expected := range []string{
+		"#1  idle",
+		"#2  io.github.example/news-mcp@2.0.0 (npm, stdio)",
+		"io.github.example/weather-mcp@1.0.0 (oci, http)",
+		"skipped, pack exists",
+		"Recent failures (1)",
+		"template failed",
+	} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("View() does not contain %q:\n%s", expected, view)
+		}
+	}
+}
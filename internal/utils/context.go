@@ -14,10 +14,32 @@ func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, RequestIDKey, requestID)
 }
 
+// userIDHolderKey holds the user ID set further down a request's handlers, see WithUserIDHolder
+const userIDHolderKey contextKey = "user_id_holder"
+
+// WithUserID sets the user ID, also recording it in the context's user ID holder if any
 func WithUserID(ctx context.Context, userID string) context.Context {
+	if holder, ok := ctx.Value(userIDHolderKey).(*string); ok {
+		*holder = userID
+	}
 	return context.WithValue(ctx, UserIDKey, userID)
 }
 
+// WithUserIDHolder returns a context recording the user ID set by handlers it's passed to,
+// so middleware can log the user after the handler authenticates the request
+func WithUserIDHolder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, userIDHolderKey, new(string))
+}
+
+// HeldUserID returns the user ID recorded in the context's user ID holder
+func HeldUserID(ctx context.Context) (string, bool) {
+	holder, ok := ctx.Value(userIDHolderKey).(*string)
+	if !ok || *holder == "" {
+		return "", false
+	}
+	return *holder, true
+}
+
 func WithTraceID(ctx context.Context, traceID string) context.Context {
 	return context.WithValue(ctx, TraceIDKey, traceID)
 }
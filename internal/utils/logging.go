@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
 
@@ -24,6 +25,11 @@ func ToSlogLevel(l config.LogLevel) slog.Level {
 }
 
 func SetupLogger(ll config.LogLevel, env config.Env) *slog.Logger {
+	return SetupLoggerWithWriter(ll, env, os.Stderr)
+}
+
+// SetupLoggerWithWriter builds the application logger writing to w
+func SetupLoggerWithWriter(ll config.LogLevel, env config.Env, w io.Writer) *slog.Logger {
 	opts := &slog.HandlerOptions{
 		Level: ToSlogLevel(ll),
 	}
@@ -32,11 +38,11 @@ func SetupLogger(ll config.LogLevel, env config.Env) *slog.Logger {
 	switch env {
 	case config.EnvDev:
 		opts.AddSource = true
-		handler = slog.NewTextHandler(os.Stderr, opts)
+		handler = slog.NewTextHandler(w, opts)
 	case config.EnvProd:
-		handler = slog.NewJSONHandler(os.Stderr, opts)
+		handler = slog.NewJSONHandler(w, opts)
 	default:
-		handler = slog.NewJSONHandler(os.Stderr, opts)
+		handler = slog.NewJSONHandler(w, opts)
 	}
 
 	handler = &ContextHandler{Handler: handler}
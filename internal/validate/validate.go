@@ -2,10 +2,17 @@ package validate
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/schedule"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/server"
 )
 
@@ -133,11 +140,73 @@ func PollInterval(interval int) error {
 	return nil
 }
 
+// Schedule validates the optional cron schedule, poll jitter in seconds and quiet windows
+func Schedule(cronExpr string, jitter int, quietWindows []string) error {
+	if cronExpr != "" {
+		if _, err := schedule.ParseCron(cronExpr); err != nil {
+			return err
+		}
+	}
+
+	if jitter < 0 {
+		return fmt.Errorf("poll jitter must not be negative, got %d", jitter)
+	}
+
+	for _, window := range quietWindows {
+		if _, err := schedule.ParseQuietWindow(window); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func StateFile(path string) error {
 	path = strings.TrimSpace(path)
 	if path == "" {
 		return fmt.Errorf("state file path cannot be empty")
 	}
+
+	scheme, rest, ok := strings.Cut(path, "://")
+	if !ok {
+		return nil
+	}
+	if !slices.Contains(config.ValidStateStoreSchemes, scheme) {
+		return fmt.Errorf("invalid state store scheme %q, must be one of: %s", scheme, strings.Join(config.ValidStateStoreSchemes, ", "))
+	}
+
+	// Network stores take an optional host before the key or variable path
+	if scheme == "consul" || scheme == "nomad" {
+		rest, _, _ = strings.Cut(rest, "?")
+		if _, p, found := strings.Cut(rest, "/"); found {
+			rest = p
+		} else {
+			rest = ""
+		}
+	}
+	if strings.Trim(rest, "/") == "" {
+		return fmt.Errorf("state store URL %q must include a path", path)
+	}
+
+	return nil
+}
+
+// OnceMode validates the one-shot watch options; a summary file is only written
+// in one-shot mode, which cannot show the terminal UI
+func OnceMode(once, enableTUI bool, summaryFile string) error {
+	if strings.TrimSpace(summaryFile) != "" && !once {
+		return fmt.Errorf("summary file requires once mode")
+	}
+	if once && enableTUI {
+		return fmt.Errorf("once mode cannot be combined with the terminal UI")
+	}
+	return nil
+}
+
+func LockTimeout(timeout int) error {
+	if timeout < 0 {
+		return fmt.Errorf("lock timeout must not be negative, got %d", timeout)
+	}
 	return nil
 }
 
@@ -147,3 +216,294 @@ func MaxConcurrent(max int) error {
 	}
 	return nil
 }
+
+func ReadyMaxMissedPolls(n int) error {
+	if n < config.MinReadyMaxMissedPolls {
+		return fmt.Errorf("ready max missed polls must be at least %d, got %d", config.MinReadyMaxMissedPolls, n)
+	}
+	return nil
+}
+
+// RegistryClient validates the registry request timeout, retry and rate limit settings
+func RegistryClient(cfg config.RegistryConfig) error {
+	if cfg.Timeout < 1 {
+		return fmt.Errorf("registry timeout must be at least 1 second, got %d", cfg.Timeout)
+	}
+	if cfg.MaxRetries < 0 {
+		return fmt.Errorf("registry max retries must not be negative, got %d", cfg.MaxRetries)
+	}
+	if cfg.InitialBackoff < 0 {
+		return fmt.Errorf("registry initial backoff must not be negative, got %d", cfg.InitialBackoff)
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		return fmt.Errorf("registry max backoff must be at least the initial backoff (%d), got %d", cfg.InitialBackoff, cfg.MaxBackoff)
+	}
+	if cfg.RateLimit < 0 {
+		return fmt.Errorf("registry rate limit must not be negative, got %g", cfg.RateLimit)
+	}
+	if cfg.RateLimit > 0 && cfg.RateBurst < 1 {
+		return fmt.Errorf("registry rate burst must be at least 1 when a rate limit is set, got %d", cfg.RateBurst)
+	}
+	return nil
+}
+
+// RetryPolicy validates the backoff, in seconds, and quarantine threshold for failed generations
+func RetryPolicy(initialBackoff, maxBackoff, quarantineAfter int) error {
+	if initialBackoff < 0 {
+		return fmt.Errorf("retry initial backoff must not be negative, got %d", initialBackoff)
+	}
+	if maxBackoff < initialBackoff {
+		return fmt.Errorf("retry max backoff must be at least the initial backoff (%d), got %d", initialBackoff, maxBackoff)
+	}
+	if quarantineAfter < 0 {
+		return fmt.Errorf("quarantine after must not be negative, got %d", quarantineAfter)
+	}
+	return nil
+}
+
+// ListenAddr validates an optional host:port listen address; empty disables the listener
+func ListenAddr(addr string) error {
+	if addr == "" {
+		return nil
+	}
+
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q; %w", addr, err)
+	}
+
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid listen address %q; port must be between 0 and 65535", addr)
+	}
+
+	return nil
+}
+
+func Hooks(hooks config.HooksConfig) error {
+	events := []struct {
+		name  string
+		hooks []config.HookConfig
+	}{
+		{"pre_generate", hooks.PreGenerate},
+		{"post_generate", hooks.PostGenerate},
+		{"on_failure", hooks.OnFailure},
+		{"post_poll", hooks.PostPoll},
+	}
+
+	for _, event := range events {
+		for i, hook := range event.hooks {
+			if strings.TrimSpace(hook.Command) == "" {
+				return fmt.Errorf("%s hook %d command must not be empty", event.name, i)
+			}
+			if hook.Timeout < 0 || hook.Timeout > config.MaxHookTimeout {
+				return fmt.Errorf("%s hook %d timeout must be between 0 and %d seconds, got %d", event.name, i, config.MaxHookTimeout, hook.Timeout)
+			}
+		}
+	}
+
+	return nil
+}
+
+// registryNamePattern matches registry names, which prefix state keys and so must not contain ':'
+var registryNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Registries validates the configured registries, which must have unique names and http(s) URLs
+func Registries(registries []config.RegistrySourceConfig) error {
+	seen := make(map[string]bool, len(registries))
+
+	for i, reg := range registries {
+		if !registryNamePattern.MatchString(reg.Name) {
+			return fmt.Errorf("registry %d name %q is invalid; must be lowercase letters, digits, '-' or '_'", i, reg.Name)
+		}
+		if seen[reg.Name] {
+			return fmt.Errorf("registry name %q is used more than once", reg.Name)
+		}
+		seen[reg.Name] = true
+
+		u, err := url.Parse(reg.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("registry %q URL %q is invalid; must be an http or https URL", reg.Name, reg.URL)
+		}
+
+		if reg.Token != "" && reg.TokenEnv != "" {
+			return fmt.Errorf("registry %q must set only one of token and token_env", reg.Name)
+		}
+
+		if err := ServerNames(reg.FilterServerNames); err != nil {
+			return fmt.Errorf("registry %q server name filter is invalid; %w", reg.Name, err)
+		}
+		if err := PackageTypes(reg.FilterPackageTypes, false); err != nil {
+			return fmt.Errorf("registry %q package type filter is invalid; %w", reg.Name, err)
+		}
+		if err := TransportTypes(reg.FilterTransportTypes, false); err != nil {
+			return fmt.Errorf("registry %q transport type filter is invalid; %w", reg.Name, err)
+		}
+	}
+
+	return nil
+}
+
+var (
+	driverNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	envVarNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Overrides validates the per-server generation overrides
+func Overrides(overrides []config.OverrideConfig) error {
+	for i, o := range overrides {
+		if len(o.Servers) == 0 || slices.Contains(o.Servers, "") {
+			return fmt.Errorf("override %d must match at least one non-empty server name or pattern", i)
+		}
+
+		if err := PackageTypes(o.PackageTypes, false); err != nil {
+			return fmt.Errorf("override %d package types are invalid; %w", i, err)
+		}
+		if err := TransportTypes(o.TransportTypes, false); err != nil {
+			return fmt.Errorf("override %d transport types are invalid; %w", i, err)
+		}
+
+		if o.Driver != "" && !driverNamePattern.MatchString(o.Driver) {
+			return fmt.Errorf("override %d driver %q is invalid; must be a Nomad task driver name", i, o.Driver)
+		}
+		if slices.Contains(o.Datacenters, "") {
+			return fmt.Errorf("override %d datacenters must not be empty", i)
+		}
+		if o.Count < 0 || o.CPU < 0 || o.Memory < 0 {
+			return fmt.Errorf("override %d count, cpu and memory must not be negative", i)
+		}
+		if o.ContainerPort < 0 || o.ContainerPort > 65535 {
+			return fmt.Errorf("override %d container port %d is invalid; must be between 1 and 65535", i, o.ContainerPort)
+		}
+
+		for _, env := range o.Env {
+			if !envVarNamePattern.MatchString(env.Name) {
+				return fmt.Errorf("override %d environment variable name %q is invalid", i, env.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ServerAuth validates the server's TLS files, API tokens and client certificate rules
+func ServerAuth(cfg config.ServerConfig) error {
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return fmt.Errorf("tls_cert_file and tls_key_file must be set together")
+	}
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		return fmt.Errorf("tls_client_ca_file requires tls_cert_file and tls_key_file")
+	}
+	if cfg.TLSClientCAFile != "" && len(cfg.ClientCerts) == 0 {
+		return fmt.Errorf("tls_client_ca_file requires client_certs to grant scopes to client certificates")
+	}
+	if len(cfg.ClientCerts) > 0 && cfg.TLSClientCAFile == "" {
+		return fmt.Errorf("client_certs requires tls_client_ca_file")
+	}
+
+	seen := make(map[string]bool, len(cfg.Tokens))
+	for i, token := range cfg.Tokens {
+		if token.Name == "" {
+			return fmt.Errorf("token %d must have a name", i)
+		}
+		if seen[token.Name] {
+			return fmt.Errorf("token name %q is used more than once", token.Name)
+		}
+		seen[token.Name] = true
+
+		if (token.Token == "") == (token.TokenEnv == "") {
+			return fmt.Errorf("token %q must set exactly one of token and token_env", token.Name)
+		}
+		if err := ServerScopes(token.Scopes); err != nil {
+			return fmt.Errorf("token %q scopes are invalid; %w", token.Name, err)
+		}
+	}
+
+	for i, cert := range cfg.ClientCerts {
+		if cert.CommonName == "" {
+			return fmt.Errorf("client certificate rule %d must have a common name", i)
+		}
+		if _, err := path.Match(cert.CommonName, ""); err != nil {
+			return fmt.Errorf("client certificate rule %d common name %q is an invalid pattern", i, cert.CommonName)
+		}
+		if err := ServerScopes(cert.Scopes); err != nil {
+			return fmt.Errorf("client certificate rule %q scopes are invalid; %w", cert.CommonName, err)
+		}
+	}
+
+	return nil
+}
+
+func ServerScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope must be granted")
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(config.ValidServerScopes, scope) {
+			return fmt.Errorf("invalid scope %q; must be one of %v", scope, config.ValidServerScopes)
+		}
+	}
+
+	return nil
+}
+
+// Tracing validates the OTLP exporter settings, whose endpoint is a host:port or an http(s) URL
+func Tracing(cfg config.TracingConfig) error {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return fmt.Errorf("invalid sample ratio %v; must be between 0 and 1", cfg.SampleRatio)
+	}
+
+	if cfg.Enabled && strings.TrimSpace(cfg.ServiceName) == "" {
+		return fmt.Errorf("service name must not be empty")
+	}
+
+	if cfg.Endpoint == "" {
+		return nil
+	}
+
+	if strings.Contains(cfg.Endpoint, "://") {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid endpoint %q; must be host:port or an http or https URL", cfg.Endpoint)
+		}
+		return nil
+	}
+
+	if _, _, err := net.SplitHostPort(cfg.Endpoint); err != nil {
+		return fmt.Errorf("invalid endpoint %q; must be host:port or an http or https URL", cfg.Endpoint)
+	}
+
+	return nil
+}
+
+func GitCommitMode(mode string) error {
+	if mode == "" {
+		return fmt.Errorf("invalid git commit mode format; git commit mode must not be empty")
+	}
+
+	modeLower := strings.ToLower(mode)
+	if !slices.Contains(config.ValidGitCommitModes, modeLower) {
+		return fmt.Errorf("invalid git commit mode %q; must be one of %v", modeLower, config.ValidGitCommitModes)
+	}
+
+	return nil
+}
+
+// VarFiles validates that each deploy var file is a readable regular file
+func VarFiles(paths []string) error {
+	for _, file := range paths {
+		if strings.TrimSpace(file) == "" {
+			return fmt.Errorf("var file path cannot be empty")
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("invalid var file %q; %w", file, err)
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("invalid var file %q; not a regular file", file)
+		}
+	}
+
+	return nil
+}
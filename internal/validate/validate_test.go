@@ -2,6 +2,8 @@ package validate
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
@@ -394,6 +396,16 @@ func TestOutputType(t *testing.T) {
 			outputType: "archive",
 			expectErr:  false,
 		},
+		{
+			name:       "valid jobspec",
+			outputType: "jobspec",
+			expectErr:  false,
+		},
+		{
+			name:       "valid jobspec-json",
+			outputType: "jobspec-json",
+			expectErr:  false,
+		},
 		{
 			name:       "valid uppercase",
 			outputType: "PACKDIR",
@@ -582,6 +594,67 @@ func TestPollInterval(t *testing.T) {
 	}
 }
 
+func TestSchedule(t *testing.T) {
+	tests := []struct {
+		name         string
+		cronExpr     string
+		jitter       int
+		quietWindows []string
+		expectError  bool
+		errorSubstr  string
+	}{
+		{
+			name:        "no schedule",
+			expectError: false,
+		},
+		{
+			name:         "valid schedule",
+			cronExpr:     "*/10 8-18 * * mon-fri",
+			jitter:       60,
+			quietWindows: []string{"22:00-06:00", "sat,sun 00:00-24:00"},
+			expectError:  false,
+		},
+		{
+			name:        "invalid cron expression",
+			cronExpr:    "every ten minutes",
+			expectError: true,
+			errorSubstr: `invalid cron schedule "every ten minutes"`,
+		},
+		{
+			name:        "negative jitter",
+			jitter:      -1,
+			expectError: true,
+			errorSubstr: "poll jitter must not be negative",
+		},
+		{
+			name:         "invalid quiet window",
+			quietWindows: []string{"22:00-06:00", "nights"},
+			expectError:  true,
+			errorSubstr:  `invalid quiet window "nights"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Schedule(tt.cronExpr, tt.jitter, tt.quietWindows)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Schedule() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("Schedule() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Schedule() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
 func TestStateFile(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -616,6 +689,44 @@ func TestStateFile(t *testing.T) {
 			expectError: true,
 			errorSubstr: "state file path cannot be empty",
 		},
+		{
+			name:        "file URL",
+			path:        "file://./state.json",
+			expectError: false,
+		},
+		{
+			name:        "bolt URL",
+			path:        "bolt:///var/lib/nomad-mcp-pack/state.db",
+			expectError: false,
+		},
+		{
+			name:        "consul URL",
+			path:        "consul://127.0.0.1:8500/nomad-mcp-pack/state?dc=dc1",
+			expectError: false,
+		},
+		{
+			name:        "nomad URL without host",
+			path:        "nomad:///nomad/jobs/nomad-mcp-pack",
+			expectError: false,
+		},
+		{
+			name:        "unsupported scheme",
+			path:        "s3://bucket/state.json",
+			expectError: true,
+			errorSubstr: "invalid state store scheme",
+		},
+		{
+			name:        "consul URL without key",
+			path:        "consul://127.0.0.1:8500/",
+			expectError: true,
+			errorSubstr: "must include a path",
+		},
+		{
+			name:        "bolt URL without path",
+			path:        "bolt://",
+			expectError: true,
+			errorSubstr: "must include a path",
+		},
 	}
 
 	for _, tt := range tests {
@@ -691,6 +802,850 @@ func TestMaxConcurrent(t *testing.T) {
 	}
 }
 
+func TestReadyMaxMissedPolls(t *testing.T) {
+	tests := []struct {
+		name        string
+		n           int
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "valid minimum",
+			n:           1,
+			expectError: false,
+		},
+		{
+			name:        "valid default",
+			n:           3,
+			expectError: false,
+		},
+		{
+			name:        "zero",
+			n:           0,
+			expectError: true,
+			errorSubstr: "ready max missed polls must be at least 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ReadyMaxMissedPolls(tt.n)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("ReadyMaxMissedPolls() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("ReadyMaxMissedPolls() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("ReadyMaxMissedPolls() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name            string
+		initialBackoff  int
+		maxBackoff      int
+		quarantineAfter int
+		expectError     bool
+		errorSubstr     string
+	}{
+		{
+			name:            "defaults",
+			initialBackoff:  60,
+			maxBackoff:      3600,
+			quarantineAfter: 5,
+			expectError:     false,
+		},
+		{
+			name:            "no backoff and never quarantine",
+			initialBackoff:  0,
+			maxBackoff:      0,
+			quarantineAfter: 0,
+			expectError:     false,
+		},
+		{
+			name:            "negative initial backoff",
+			initialBackoff:  -1,
+			maxBackoff:      60,
+			quarantineAfter: 5,
+			expectError:     true,
+			errorSubstr:     "retry initial backoff must not be negative",
+		},
+		{
+			name:            "max below initial",
+			initialBackoff:  120,
+			maxBackoff:      60,
+			quarantineAfter: 5,
+			expectError:     true,
+			errorSubstr:     "retry max backoff must be at least the initial backoff",
+		},
+		{
+			name:            "negative quarantine after",
+			initialBackoff:  60,
+			maxBackoff:      3600,
+			quarantineAfter: -1,
+			expectError:     true,
+			errorSubstr:     "quarantine after must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RetryPolicy(tt.initialBackoff, tt.maxBackoff, tt.quarantineAfter)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("RetryPolicy() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("RetryPolicy() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("RetryPolicy() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryClient(t *testing.T) {
+	valid := config.RegistryConfig{
+		Timeout:        30,
+		MaxRetries:     3,
+		InitialBackoff: 1,
+		MaxBackoff:     30,
+		RateLimit:      5,
+		RateBurst:      5,
+	}
+
+	tests := []struct {
+		name        string
+		modify      func(cfg *config.RegistryConfig)
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "defaults",
+			modify:      func(cfg *config.RegistryConfig) {},
+			expectError: false,
+		},
+		{
+			name: "retries and rate limit disabled",
+			modify: func(cfg *config.RegistryConfig) {
+				cfg.MaxRetries = 0
+				cfg.RateLimit = 0
+				cfg.RateBurst = 0
+			},
+			expectError: false,
+		},
+		{
+			name:        "zero timeout",
+			modify:      func(cfg *config.RegistryConfig) { cfg.Timeout = 0 },
+			expectError: true,
+			errorSubstr: "registry timeout must be at least 1 second",
+		},
+		{
+			name:        "negative max retries",
+			modify:      func(cfg *config.RegistryConfig) { cfg.MaxRetries = -1 },
+			expectError: true,
+			errorSubstr: "registry max retries must not be negative",
+		},
+		{
+			name:        "negative initial backoff",
+			modify:      func(cfg *config.RegistryConfig) { cfg.InitialBackoff = -1 },
+			expectError: true,
+			errorSubstr: "registry initial backoff must not be negative",
+		},
+		{
+			name:        "max below initial",
+			modify:      func(cfg *config.RegistryConfig) { cfg.MaxBackoff = 0 },
+			expectError: true,
+			errorSubstr: "registry max backoff must be at least the initial backoff",
+		},
+		{
+			name:        "negative rate limit",
+			modify:      func(cfg *config.RegistryConfig) { cfg.RateLimit = -1 },
+			expectError: true,
+			errorSubstr: "registry rate limit must not be negative",
+		},
+		{
+			name:        "zero burst with rate limit",
+			modify:      func(cfg *config.RegistryConfig) { cfg.RateBurst = 0 },
+			expectError: true,
+			errorSubstr: "registry rate burst must be at least 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			err := RegistryClient(cfg)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("RegistryClient() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("RegistryClient() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("RegistryClient() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestOnceMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		once        bool
+		enableTUI   bool
+		summaryFile string
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "daemon mode",
+			expectError: false,
+		},
+		{
+			name:        "once with summary on stdout",
+			once:        true,
+			summaryFile: "-",
+			expectError: false,
+		},
+		{
+			name:        "once with summary file",
+			once:        true,
+			summaryFile: "./summary.json",
+			expectError: false,
+		},
+		{
+			name:        "summary file without once",
+			summaryFile: "./summary.json",
+			expectError: true,
+			errorSubstr: "summary file requires once mode",
+		},
+		{
+			name:        "once with terminal UI",
+			once:        true,
+			enableTUI:   true,
+			expectError: true,
+			errorSubstr: "once mode cannot be combined with the terminal UI",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := OnceMode(tt.once, tt.enableTUI, tt.summaryFile)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("OnceMode() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("OnceMode() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("OnceMode() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     int
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "fail fast",
+			timeout:     0,
+			expectError: false,
+		},
+		{
+			name:        "wait",
+			timeout:     60,
+			expectError: false,
+		},
+		{
+			name:        "negative",
+			timeout:     -1,
+			expectError: true,
+			errorSubstr: "lock timeout must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := LockTimeout(tt.timeout)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("LockTimeout() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("LockTimeout() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("LockTimeout() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestListenAddr(t *testing.T) {
+	tests := []struct {
+		name        string
+		addr        string
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "empty disables listener",
+			addr:        "",
+			expectError: false,
+		},
+		{
+			name:        "port only",
+			addr:        ":9090",
+			expectError: false,
+		},
+		{
+			name:        "host and port",
+			addr:        "127.0.0.1:9090",
+			expectError: false,
+		},
+		{
+			name:        "missing port",
+			addr:        "localhost",
+			expectError: true,
+			errorSubstr: "invalid listen address",
+		},
+		{
+			name:        "port out of range",
+			addr:        ":70000",
+			expectError: true,
+			errorSubstr: "port must be between 0 and 65535",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ListenAddr(tt.addr)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("ListenAddr() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("ListenAddr() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("ListenAddr() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestHooks(t *testing.T) {
+	tests := []struct {
+		name        string
+		hooks       config.HooksConfig
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "no hooks",
+			hooks:       config.HooksConfig{},
+			expectError: false,
+		},
+		{
+			name: "valid hooks",
+			hooks: config.HooksConfig{
+				PreGenerate:  []config.HookConfig{{Command: "echo pre"}},
+				PostGenerate: []config.HookConfig{{Command: "nomad-pack render $NOMAD_MCP_PACK_HOOK_PACK_PATH", Timeout: 120, FailGeneration: true}},
+				PostPoll:     []config.HookConfig{{Command: "echo done"}},
+			},
+			expectError: false,
+		},
+		{
+			name: "empty command",
+			hooks: config.HooksConfig{
+				OnFailure: []config.HookConfig{{Command: "  "}},
+			},
+			expectError: true,
+			errorSubstr: "on_failure hook 0 command must not be empty",
+		},
+		{
+			name: "negative timeout",
+			hooks: config.HooksConfig{
+				PostGenerate: []config.HookConfig{{Command: "true"}, {Command: "true", Timeout: -1}},
+			},
+			expectError: true,
+			errorSubstr: "post_generate hook 1 timeout must be between 0 and 3600 seconds",
+		},
+		{
+			name: "timeout too large",
+			hooks: config.HooksConfig{
+				PostPoll: []config.HookConfig{{Command: "true", Timeout: 3601}},
+			},
+			expectError: true,
+			errorSubstr: "post_poll hook 0 timeout must be between 0 and 3600 seconds",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Hooks(tt.hooks)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Hooks() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("Hooks() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Hooks() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistries(t *testing.T) {
+	tests := []struct {
+		name        string
+		registries  []config.RegistrySourceConfig
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "no registries",
+			registries:  nil,
+			expectError: false,
+		},
+		{
+			name: "valid registries",
+			registries: []config.RegistrySourceConfig{
+				{Name: "internal", URL: "https://mcp.internal.example.com/", TokenEnv: "INTERNAL_REGISTRY_TOKEN", FilterPackageTypes: []string{"oci"}},
+				{Name: "default", URL: "https://registry.modelcontextprotocol.io/", FilterServerNames: []string{"io.github.example/weather-mcp"}},
+			},
+			expectError: false,
+		},
+		{
+			name:        "invalid name",
+			registries:  []config.RegistrySourceConfig{{Name: "Internal:1", URL: "https://mcp.example.com"}},
+			expectError: true,
+			errorSubstr: `registry 0 name "Internal:1" is invalid`,
+		},
+		{
+			name: "duplicate name",
+			registries: []config.RegistrySourceConfig{
+				{Name: "internal", URL: "https://a.example.com"},
+				{Name: "internal", URL: "https://b.example.com"},
+			},
+			expectError: true,
+			errorSubstr: `registry name "internal" is used more than once`,
+		},
+		{
+			name:        "invalid URL",
+			registries:  []config.RegistrySourceConfig{{Name: "internal", URL: "mcp.example.com"}},
+			expectError: true,
+			errorSubstr: `registry "internal" URL "mcp.example.com" is invalid`,
+		},
+		{
+			name:        "token and token env",
+			registries:  []config.RegistrySourceConfig{{Name: "internal", URL: "https://mcp.example.com", Token: "secret", TokenEnv: "TOKEN"}},
+			expectError: true,
+			errorSubstr: "must set only one of token and token_env",
+		},
+		{
+			name:        "invalid filter",
+			registries:  []config.RegistrySourceConfig{{Name: "internal", URL: "https://mcp.example.com", FilterTransportTypes: []string{"carrier-pigeon"}}},
+			expectError: true,
+			errorSubstr: `registry "internal" transport type filter is invalid`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Registries(tt.registries)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Registries() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("Registries() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Registries() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	tests := []struct {
+		name        string
+		overrides   []config.OverrideConfig
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "no overrides",
+			overrides:   nil,
+			expectError: false,
+		},
+		{
+			name: "valid overrides",
+			overrides: []config.OverrideConfig{
+				{Servers: []string{"io.github.example/*"}, CPU: 1000, Memory: 1024, Driver: "podman"},
+				{
+					Servers:        []string{"io.github.example/weather-mcp"},
+					PackageTypes:   []string{"npm", "oci"},
+					TransportTypes: []string{"http", "stdio"},
+					ContainerPort:  8000,
+					ServiceTags:    []string{"traefik.enable=true"},
+					Env:            []config.EnvVarConfig{{Name: "LOG_LEVEL", Value: "debug"}},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name:        "no servers",
+			overrides:   []config.OverrideConfig{{CPU: 1000}},
+			expectError: true,
+			errorSubstr: "override 0 must match at least one",
+		},
+		{
+			name:        "invalid package type",
+			overrides:   []config.OverrideConfig{{Servers: []string{"*"}, PackageTypes: []string{"cargo"}}},
+			expectError: true,
+			errorSubstr: "override 0 package types are invalid",
+		},
+		{
+			name:        "invalid driver",
+			overrides:   []config.OverrideConfig{{Servers: []string{"*"}, Driver: "Docker Engine"}},
+			expectError: true,
+			errorSubstr: `driver "Docker Engine" is invalid`,
+		},
+		{
+			name:        "negative memory",
+			overrides:   []config.OverrideConfig{{Servers: []string{"*"}, Memory: -1}},
+			expectError: true,
+			errorSubstr: "must not be negative",
+		},
+		{
+			name:        "invalid container port",
+			overrides:   []config.OverrideConfig{{Servers: []string{"*"}, ContainerPort: 70000}},
+			expectError: true,
+			errorSubstr: "container port 70000 is invalid",
+		},
+		{
+			name:        "invalid env name",
+			overrides:   []config.OverrideConfig{{Servers: []string{"*"}, Env: []config.EnvVarConfig{{Name: "LOG-LEVEL", Value: "debug"}}}},
+			expectError: true,
+			errorSubstr: `environment variable name "LOG-LEVEL" is invalid`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Overrides(tt.overrides)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Overrides() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("Overrides() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Overrides() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestServerAuth(t *testing.T) {
+	readToken := config.ServerTokenConfig{Name: "ci", Token: "secret", Scopes: []string{"read"}}
+
+	tests := []struct {
+		name        string
+		cfg         config.ServerConfig
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "no authentication",
+			cfg:         config.ServerConfig{},
+			expectError: false,
+		},
+		{
+			name: "tokens and client certificates",
+			cfg: config.ServerConfig{
+				TLSCertFile:     "server.crt",
+				TLSKeyFile:      "server.key",
+				TLSClientCAFile: "ca.crt",
+				Tokens: []config.ServerTokenConfig{
+					readToken,
+					{Name: "ops", TokenEnv: "OPS_TOKEN", Scopes: []string{"read", "generate"}},
+				},
+				ClientCerts: []config.ServerClientCertConfig{{CommonName: "*.ops.example.com", Scopes: []string{"read"}}},
+			},
+			expectError: false,
+		},
+		{
+			name:        "certificate without key",
+			cfg:         config.ServerConfig{TLSCertFile: "server.crt"},
+			expectError: true,
+			errorSubstr: "must be set together",
+		},
+		{
+			name:        "client CA without TLS",
+			cfg:         config.ServerConfig{TLSClientCAFile: "ca.crt"},
+			expectError: true,
+			errorSubstr: "tls_client_ca_file requires tls_cert_file",
+		},
+		{
+			name:        "client CA without client certificate rules",
+			cfg:         config.ServerConfig{TLSCertFile: "server.crt", TLSKeyFile: "server.key", TLSClientCAFile: "ca.crt"},
+			expectError: true,
+			errorSubstr: "requires client_certs",
+		},
+		{
+			name:        "client certificate rules without client CA",
+			cfg:         config.ServerConfig{ClientCerts: []config.ServerClientCertConfig{{CommonName: "ci", Scopes: []string{"read"}}}},
+			expectError: true,
+			errorSubstr: "client_certs requires tls_client_ca_file",
+		},
+		{
+			name:        "duplicate token name",
+			cfg:         config.ServerConfig{Tokens: []config.ServerTokenConfig{readToken, readToken}},
+			expectError: true,
+			errorSubstr: `token name "ci" is used more than once`,
+		},
+		{
+			name:        "token and token env",
+			cfg:         config.ServerConfig{Tokens: []config.ServerTokenConfig{{Name: "ci", Token: "secret", TokenEnv: "CI_TOKEN", Scopes: []string{"read"}}}},
+			expectError: true,
+			errorSubstr: "exactly one of token and token_env",
+		},
+		{
+			name:        "token without scopes",
+			cfg:         config.ServerConfig{Tokens: []config.ServerTokenConfig{{Name: "ci", Token: "secret"}}},
+			expectError: true,
+			errorSubstr: "at least one scope",
+		},
+		{
+			name:        "invalid scope",
+			cfg:         config.ServerConfig{Tokens: []config.ServerTokenConfig{{Name: "ci", Token: "secret", Scopes: []string{"admin"}}}},
+			expectError: true,
+			errorSubstr: `invalid scope "admin"`,
+		},
+		{
+			name: "invalid common name pattern",
+			cfg: config.ServerConfig{
+				TLSCertFile:     "server.crt",
+				TLSKeyFile:      "server.key",
+				TLSClientCAFile: "ca.crt",
+				ClientCerts:     []config.ServerClientCertConfig{{CommonName: "[ci", Scopes: []string{"read"}}},
+			},
+			expectError: true,
+			errorSubstr: "invalid pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ServerAuth(tt.cfg)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("ServerAuth() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("ServerAuth() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("ServerAuth() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestTracing(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.TracingConfig
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "disabled",
+			cfg:         config.TracingConfig{SampleRatio: 1},
+			expectError: false,
+		},
+		{
+			name:        "host and port endpoint",
+			cfg:         config.TracingConfig{Enabled: true, Endpoint: "otel-collector:4318", SampleRatio: 0.5, ServiceName: "nomad-mcp-pack"},
+			expectError: false,
+		},
+		{
+			name:        "URL endpoint",
+			cfg:         config.TracingConfig{Enabled: true, Endpoint: "https://otlp.example.com/v1/traces", SampleRatio: 1, ServiceName: "nomad-mcp-pack"},
+			expectError: false,
+		},
+		{
+			name:        "sample ratio above one",
+			cfg:         config.TracingConfig{Enabled: true, SampleRatio: 1.5, ServiceName: "nomad-mcp-pack"},
+			expectError: true,
+			errorSubstr: "invalid sample ratio",
+		},
+		{
+			name:        "empty service name",
+			cfg:         config.TracingConfig{Enabled: true, SampleRatio: 1},
+			expectError: true,
+			errorSubstr: "service name must not be empty",
+		},
+		{
+			name:        "endpoint without port",
+			cfg:         config.TracingConfig{Enabled: true, Endpoint: "otel-collector", SampleRatio: 1, ServiceName: "nomad-mcp-pack"},
+			expectError: true,
+			errorSubstr: `invalid endpoint "otel-collector"`,
+		},
+		{
+			name:        "grpc URL endpoint",
+			cfg:         config.TracingConfig{Enabled: true, Endpoint: "grpc://otel-collector:4317", SampleRatio: 1, ServiceName: "nomad-mcp-pack"},
+			expectError: true,
+			errorSubstr: "invalid endpoint",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Tracing(tt.cfg)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Tracing() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("Tracing() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Tracing() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestVarFiles(t *testing.T) {
+	dir := t.TempDir()
+	varFile := filepath.Join(dir, "prod.hcl")
+	if err := os.WriteFile(varFile, []byte("count = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		paths       []string
+		expectError bool
+		errorSubstr string
+	}{
+		{
+			name:        "no var files",
+			paths:       nil,
+			expectError: false,
+		},
+		{
+			name:        "existing var file",
+			paths:       []string{varFile},
+			expectError: false,
+		},
+		{
+			name:        "empty path",
+			paths:       []string{varFile, " "},
+			expectError: true,
+			errorSubstr: "var file path cannot be empty",
+		},
+		{
+			name:        "missing var file",
+			paths:       []string{filepath.Join(dir, "missing.hcl")},
+			expectError: true,
+			errorSubstr: "invalid var file",
+		},
+		{
+			name:        "directory",
+			paths:       []string{dir},
+			expectError: true,
+			errorSubstr: "not a regular file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VarFiles(tt.paths)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("VarFiles() expected error but got none")
+					return
+				}
+				if tt.errorSubstr != "" && !containsString(err.Error(), tt.errorSubstr) {
+					t.Errorf("VarFiles() error = %q, expected to contain %q", err.Error(), tt.errorSubstr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("VarFiles() unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
 // Helper function to check if a string contains a substring
 func containsString(s, substr string) bool {
 	return len(s) >= len(substr) &&
@@ -0,0 +1,57 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+)
+
+// ReadState loads the watch state from the store at location for reading, see ParseState for legacyRegistry
+func ReadState(ctx context.Context, location, legacyRegistry string) (*WatchState, error) {
+	store, err := statestore.Open(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state store: %w", err)
+	}
+	defer store.Close()
+
+	state, err := LoadState(ctx, store, legacyRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state file: %w", err)
+	}
+
+	return state, nil
+}
+
+// EditState loads the watch state from the store at location while holding its lock,
+// waiting up to lockTimeout for it, applies edit and saves the state if edit reports a change
+func EditState(ctx context.Context, location, legacyRegistry string, lockTimeout time.Duration, edit func(state *WatchState) bool) error {
+	store, err := statestore.Open(location)
+	if err != nil {
+		return fmt.Errorf("failed to open state store: %w", err)
+	}
+	defer store.Close()
+
+	// A running watch process would overwrite the edited entries when it next saves state
+	unlock, err := statestore.Lock(ctx, store, lockTimeout)
+	if err != nil {
+		return fmt.Errorf("could not lock state file, stop any watch process using it and try again: %w", err)
+	}
+	defer unlock()
+
+	state, err := LoadState(ctx, store, legacyRegistry)
+	if err != nil {
+		return fmt.Errorf("failed to load state file: %w", err)
+	}
+
+	if !edit(state) {
+		return nil
+	}
+
+	if err := state.SaveState(ctx, store); err != nil {
+		return fmt.Errorf("failed to save state file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/filelock"
+)
+
+func TestEditState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+
+	// An edit reporting no change doesn't write the state
+	err := EditState(context.Background(), path, "", 0, func(state *WatchState) bool { return false })
+	if err != nil {
+		t.Fatalf("EditState() unexpected error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("EditState() saved state without a change")
+	}
+
+	err = EditState(context.Background(), path, "", 0, func(state *WatchState) bool {
+		state.SetServer(testServerState(time.Now()))
+		return true
+	})
+	if err != nil {
+		t.Fatalf("EditState() unexpected error = %v", err)
+	}
+	state, err := ReadState(context.Background(), path, "")
+	if err != nil {
+		t.Fatalf("ReadState() unexpected error = %v", err)
+	}
+	if _, ok := state.GetServer(testServerState(time.Time{}).Key()); !ok {
+		t.Error("EditState() did not save the edited state")
+	}
+
+	// Edits wait for, or fail on, the lock held by a running watch process
+	lock, err := filelock.Acquire(context.Background(), path, 0)
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error = %v", err)
+	}
+	defer lock.Release()
+
+	var lockedErr *filelock.LockedError
+	err = EditState(context.Background(), path, "", 0, func(state *WatchState) bool {
+		t.Error("EditState() applied the edit without the lock")
+		return false
+	})
+	if !errors.As(err, &lockedErr) {
+		t.Errorf("EditState() error = %v, expected *filelock.LockedError", err)
+	}
+}
@@ -7,6 +7,11 @@ import (
 
 var ErrGracefulShutdown = errors.New("graceful shutdown")
 
+// ErrPollIncomplete is returned when some servers could not be fetched from the
+// registry and the poll cycle only processed the servers that were, or when generated
+// packs could not be committed to git
+var ErrPollIncomplete = errors.New("poll cycle incomplete")
+
 type PackGenerationErrors struct {
 	CriticalErrs []error
 }
@@ -0,0 +1,63 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/metrics"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/schedule"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordGeneration(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedResult string
+	}{
+		{name: "success", expectedResult: metrics.ResultSuccess},
+		{name: "failure", err: errors.New("render failed"), expectedResult: metrics.ResultFailure},
+		{name: "pack directory exists", err: fmt.Errorf("generate pack; %w", generator.ErrPackDirectoryExists), expectedResult: metrics.ResultSkipped},
+		{name: "pack archive exists", err: generator.ErrPackArchiveExists, expectedResult: metrics.ResultSkipped},
+		{name: "jobspec exists", err: generator.ErrJobspecExists, expectedResult: metrics.ResultSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := make(map[string]float64)
+			for _, result := range []string{metrics.ResultSuccess, metrics.ResultFailure, metrics.ResultSkipped} {
+				before[result] = testutil.ToFloat64(metrics.Generations.WithLabelValues(result, "oci", "http"))
+			}
+
+			recordGeneration(testTask("io.github.example/weather-mcp"), tt.err)
+
+			for result, count := range before {
+				expected := count
+				if result == tt.expectedResult {
+					expected++
+				}
+				if got := testutil.ToFloat64(metrics.Generations.WithLabelValues(result, "oci", "http")); got != expected {
+					t.Errorf("generations{result=%q} = %v, expected %v", result, got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduledPollSkippedMetric(t *testing.T) {
+	window, err := schedule.ParseQuietWindow("00:00-24:00")
+	if err != nil {
+		t.Fatalf("ParseQuietWindow() unexpected error = %v", err)
+	}
+
+	w := &Watcher{config: &WatcherConfig{QuietWindows: []schedule.QuietWindow{window}}, status: newStatusTracker()}
+
+	before := testutil.ToFloat64(metrics.PollsSkipped)
+	w.scheduledPoll(context.Background())
+	if got := testutil.ToFloat64(metrics.PollsSkipped); got != before+1 {
+		t.Errorf("polls skipped = %v, expected %v", got, before+1)
+	}
+}
@@ -0,0 +1,162 @@
+package watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// StateSchemaVersion is the version of the state format written by SaveState.
+// Changes to the format bump it and append a migration to stateMigrations.
+const StateSchemaVersion = 3
+
+// legacySchemaVersion is the version of state saved before schema_version was recorded
+const legacySchemaVersion = 1
+
+// stateMigration upgrades serialized state by one schema version. Migrations work on
+// the raw JSON so they don't depend on the current shape of WatchState. legacyRegistry
+// names the registry that servers tracked before multiple registries were supported came from.
+type stateMigration func(state map[string]json.RawMessage, legacyRegistry string) error
+
+// stateMigrations[i] upgrades state from schema version i+1 to i+2
+var stateMigrations = []stateMigration{
+	migrateV1ToV2,
+	migrateV2ToV3,
+}
+
+// ErrLegacyRegistryUnknown is returned when state tracking servers from before multiple
+// registries were supported is migrated without the registry those servers came from
+var ErrLegacyRegistryUnknown = errors.New("can't determine the registry of servers tracked before multiple registries were supported, add the registry at registry_url to registries")
+
+// StateVersionError is returned for state saved by a newer release with a schema this release can't read
+type StateVersionError struct {
+	Version int
+}
+
+func (e *StateVersionError) Error() string {
+	return fmt.Sprintf("state schema version %d is newer than version %d supported by this release, upgrade nomad-mcp-pack to use this state",
+		e.Version, StateSchemaVersion)
+}
+
+// stateSchemaVersion returns the schema version of serialized state
+func stateSchemaVersion(data []byte) (int, error) {
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
+	if header.SchemaVersion == 0 {
+		return legacySchemaVersion, nil
+	}
+
+	return header.SchemaVersion, nil
+}
+
+// migrateState upgrades serialized state to StateSchemaVersion
+func migrateState(data []byte, legacyRegistry string) ([]byte, error) {
+	version, err := stateSchemaVersion(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if version > StateSchemaVersion {
+		return nil, &StateVersionError{Version: version}
+	}
+	if version == StateSchemaVersion {
+		return data, nil
+	}
+
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
+	for v := version; v < StateSchemaVersion; v++ {
+		if err := stateMigrations[v-1](state, legacyRegistry); err != nil {
+			return nil, fmt.Errorf("failed to migrate state from schema version %d to %d: %w", v, v+1, err)
+		}
+		state["schema_version"] = json.RawMessage(strconv.Itoa(v + 1))
+	}
+
+	return json.Marshal(state)
+}
+
+// migrateV1ToV2 rebuilds the keys of the servers map from each entry's fields.
+// Version 1 state could be edited by hand, leaving keys that no longer match their
+// entry, whereas from version 2 the key is always derived from the entry.
+func migrateV1ToV2(state map[string]json.RawMessage, _ string) error {
+	raw, ok := state["servers"]
+	if !ok {
+		return nil
+	}
+
+	var servers map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &servers); err != nil {
+		return fmt.Errorf("failed to unmarshal servers: %w", err)
+	}
+
+	field := func(entry map[string]json.RawMessage, name string) string {
+		var v string
+		json.Unmarshal(entry[name], &v)
+		return v
+	}
+
+	rekeyed := make(map[string]map[string]json.RawMessage, len(servers))
+	for key, entry := range servers {
+		if entry == nil {
+			continue
+		}
+		if name := field(entry, "name"); name != "" {
+			key = fmt.Sprintf("%s/%s@%s:%s:%s", field(entry, "namespace"), name, field(entry, "version"),
+				field(entry, "package_type"), field(entry, "transport_type"))
+		}
+		rekeyed[key] = entry
+	}
+
+	data, err := json.Marshal(rekeyed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal servers: %w", err)
+	}
+	state["servers"] = data
+
+	return nil
+}
+
+// migrateV2ToV3 namespaces servers by registry, assigning the servers tracked before
+// multiple registries were supported to legacyRegistry
+func migrateV2ToV3(state map[string]json.RawMessage, legacyRegistry string) error {
+	raw, ok := state["servers"]
+	if !ok {
+		return nil
+	}
+
+	var servers map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &servers); err != nil {
+		return fmt.Errorf("failed to unmarshal servers: %w", err)
+	}
+
+	if len(servers) > 0 && legacyRegistry == "" {
+		return ErrLegacyRegistryUnknown
+	}
+	registry, _ := json.Marshal(legacyRegistry)
+
+	rekeyed := make(map[string]map[string]json.RawMessage, len(servers))
+	for key, entry := range servers {
+		if entry == nil {
+			continue
+		}
+		entry["registry"] = registry
+		rekeyed[legacyRegistry+":"+key] = entry
+	}
+
+	data, err := json.Marshal(rekeyed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal servers: %w", err)
+	}
+	state["servers"] = data
+
+	return nil
+}
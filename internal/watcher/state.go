@@ -1,15 +1,23 @@
 package watcher
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/metrics"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
 )
 
 type ServerState struct {
+	Registry      string    `json:"registry"`
 	Namespace     string    `json:"namespace"`
 	Name          string    `json:"name"`
 	Version       string    `json:"version"`
@@ -18,32 +26,87 @@ type ServerState struct {
 	UpdatedAt     time.Time `json:"updated_at"`
 	GeneratedAt   time.Time `json:"generated_at"`
 	Checksum      string    `json:"checksum,omitempty"`
+	Failed        bool      `json:"failed,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+	Attempts      int       `json:"attempts,omitempty"`
+	NextRetryAt   time.Time `json:"next_retry_at,omitzero"`
+	Quarantined   bool      `json:"quarantined,omitempty"`
+	CommitPending bool      `json:"commit_pending,omitempty"` // Generated but not yet committed, tagged and pushed to the git repository
 }
 
+// Key identifies the server in state, namespaced by the registry it was fetched from
 func (s *ServerState) Key() string {
-	return fmt.Sprintf("%s/%s@%s:%s:%s", s.Namespace, s.Name, s.Version, s.PackageType, s.TransportType)
+	return fmt.Sprintf("%s:%s/%s@%s:%s:%s", s.Registry, s.Namespace, s.Name, s.Version, s.PackageType, s.TransportType)
 }
 
 type WatchState struct {
-	LastPoll time.Time               `json:"last_poll"`
-	Servers  map[string]*ServerState `json:"servers"`
-	mu       sync.RWMutex
+	SchemaVersion  int                     `json:"schema_version"`
+	LastPoll       time.Time               `json:"last_poll"`
+	Servers        map[string]*ServerState `json:"servers"`
+	changed        map[string]bool         // Keys of servers set or removed since the state was last loaded or saved
+	migratedFrom   []byte                  // State loaded with an older schema, backed up before the migrated state is first saved
+	legacyVersion  int                     // Schema version of migratedFrom
+	legacyRegistry string                  // Registry of servers in state saved before multiple registries were supported, see ParseState
+	mu             sync.RWMutex
 }
 
 func NewWatchState() *WatchState {
 	return &WatchState{
-		Servers: make(map[string]*ServerState),
+		SchemaVersion: StateSchemaVersion,
+		Servers:       make(map[string]*ServerState),
 	}
 }
 
-func LoadState(path string) (*WatchState, error) {
-	data, err := os.ReadFile(path)
+// LoadState loads the watch state from store, starting with an empty state if none has been saved.
+// State saved with an older schema is migrated, and backed up in the store when the migrated
+// state is first saved, so commands that only read the state leave the store untouched.
+func LoadState(ctx context.Context, store statestore.Store, legacyRegistry string) (*WatchState, error) {
+	data, err := store.Load(ctx)
 	if err != nil {
-		if os.IsNotExist(err) {
-			slog.Debug("state file does not exist, starting with empty state", "path", path)
-			return NewWatchState(), nil
-		}
-		return nil, fmt.Errorf("failed to read state file: %w", err)
+		return nil, err
+	}
+	if data == nil {
+		slog.Debug("no saved state, starting with empty state", "store", store.String())
+		state := NewWatchState()
+		state.legacyRegistry = legacyRegistry
+		return state, nil
+	}
+
+	version, err := stateSchemaVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	state, err := ParseState(data, legacyRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	if version < StateSchemaVersion {
+		slog.Info("migrating state to current schema version",
+			"store", store.String(),
+			"from_schema_version", version,
+			"to_schema_version", StateSchemaVersion,
+		)
+		state.migratedFrom = data
+		state.legacyVersion = version
+	}
+
+	slog.Debug("state loaded",
+		"store", store.String(),
+		"servers_count", len(state.Servers),
+		"last_poll", state.LastPoll,
+	)
+
+	return state, nil
+}
+
+// ParseState parses a serialized watch state, migrating it from older schema versions.
+// Servers in state saved before multiple registries were supported are assigned to
+// legacyRegistry, and migrating such state fails with ErrLegacyRegistryUnknown when it's empty.
+func ParseState(data []byte, legacyRegistry string) (*WatchState, error) {
+	data, err := migrateState(data, legacyRegistry)
+	if err != nil {
+		return nil, err
 	}
 
 	var state WatchState
@@ -54,43 +117,133 @@ func LoadState(path string) (*WatchState, error) {
 	if state.Servers == nil {
 		state.Servers = make(map[string]*ServerState)
 	}
-
-	slog.Debug("state loaded from disk",
-		"path", path,
-		"servers_count", len(state.Servers),
-		"last_poll", state.LastPoll,
-	)
+	state.legacyRegistry = legacyRegistry
 
 	return &state, nil
 }
 
-func (s *WatchState) SaveState(path string) error {
+// Marshal serializes the watch state as indented JSON
+func (s *WatchState) Marshal() ([]byte, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	slog.Debug("saving state to disk",
-		"path", path,
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	return data, nil
+}
+
+// maxSaveAttempts bounds how often saving is retried after the stored state was changed by another process
+const maxSaveAttempts = 3
+
+// SaveState saves the watch state to store. When the stored state was changed by another
+// process since it was loaded, the stored state is reloaded, the servers changed in this
+// state are merged into it and the save is retried.
+func (s *WatchState) SaveState(ctx context.Context, store statestore.Store) error {
+	for attempt := 1; ; attempt++ {
+		err := s.save(ctx, store)
+		if !errors.Is(err, statestore.ErrConflict) || attempt == maxSaveAttempts {
+			return err
+		}
+
+		slog.Warn("state was modified by another process, merging before saving again",
+			"store", store.String(),
+			"attempt", attempt,
+		)
+		if err := s.reload(ctx, store); err != nil {
+			return fmt.Errorf("failed to reload state after a conflicting save: %w", err)
+		}
+	}
+}
+
+// reload replaces the state with the stored state, keeping the servers set or removed
+// in this state since it was last loaded or saved
+func (s *WatchState) reload(ctx context.Context, store statestore.Store) error {
+	data, err := store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	stored := NewWatchState()
+	if data != nil {
+		if stored, err = ParseState(data, s.legacyRegistry); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.changed {
+		if server, exists := s.Servers[key]; exists {
+			stored.Servers[key] = server
+		} else {
+			delete(stored.Servers, key)
+		}
+	}
+
+	s.Servers = stored.Servers
+	if stored.LastPoll.After(s.LastPoll) {
+		s.LastPoll = stored.LastPoll
+	}
+
+	slog.Debug("state reloaded",
+		"store", store.String(),
 		"servers_count", len(s.Servers),
+		"changed_count", len(s.changed),
 	)
 
+	return nil
+}
+
+// markChanged records that the server with the key was set or removed. The caller must hold the write lock.
+func (s *WatchState) markChanged(key string) {
+	if s.changed == nil {
+		s.changed = make(map[string]bool)
+	}
+	s.changed[key] = true
+}
+
+func (s *WatchState) save(ctx context.Context, store statestore.Store) error {
+	// The write lock is held until the save completes, so no change is marked as saved without being written
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
-	// Write to temp file first to ensure atomic operation
-	tmpPath := path + ".tmp"
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write state file: %w", err)
+	// The state as saved with its older schema is kept before it is replaced
+	if s.migratedFrom != nil {
+		backup, err := store.Backup(ctx, s.migratedFrom, fmt.Sprintf("v%d", s.legacyVersion))
+		if err != nil {
+			return fmt.Errorf("failed to back up state before migration: %w", err)
+		}
+		slog.Info("backed up state before saving it migrated",
+			"store", store.String(),
+			"from_schema_version", s.legacyVersion,
+			"backup", backup,
+		)
+		s.migratedFrom = nil
 	}
 
-	// Move temp file to final location
-	if err := os.Rename(tmpPath, path); err != nil {
-		return fmt.Errorf("failed to rename state file: %w", err)
+	slog.Debug("saving state",
+		"store", store.String(),
+		"servers_count", len(s.Servers),
+	)
+
+	if err := store.Save(ctx, data); err != nil {
+		return err
 	}
+	s.changed = nil
+
+	metrics.ObserveState(len(s.Servers), len(data))
 
 	slog.Debug("state saved successfully",
-		"path", path,
+		"store", store.String(),
 		"servers_count", len(s.Servers),
 	)
 
@@ -111,8 +264,10 @@ func (s *WatchState) SetServer(server *ServerState) {
 
 	key := server.Key()
 	s.Servers[key] = server
+	s.markChanged(key)
 	slog.Debug("state updated",
 		"key", key,
+		"registry", server.Registry,
 		"namespace", server.Namespace,
 		"name", server.Name,
 		"version", server.Version,
@@ -123,8 +278,15 @@ func (s *WatchState) SetServer(server *ServerState) {
 }
 
 // TODO: Update logic to use some form of checksum or hash of the actual Pack data
-func (s *WatchState) NeedsGeneration(namespace, name, version, packageType, transportType string, updatedAt time.Time) bool {
-	key := fmt.Sprintf("%s/%s@%s:%s:%s", namespace, name, version, packageType, transportType)
+func (s *WatchState) NeedsGeneration(registry, namespace, name, version, packageType, transportType string, updatedAt time.Time) bool {
+	key := (&ServerState{
+		Registry:      registry,
+		Namespace:     namespace,
+		Name:          name,
+		Version:       version,
+		PackageType:   packageType,
+		TransportType: transportType,
+	}).Key()
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -139,6 +301,34 @@ func (s *WatchState) NeedsGeneration(namespace, name, version, packageType, tran
 		return true
 	}
 
+	// Quarantined packs are not retried until the quarantine is cleared
+	if existing.Quarantined {
+		slog.Debug("state check: pack quarantined, skipping",
+			"key", key,
+			"attempts", existing.Attempts,
+			"last_error", existing.LastError,
+		)
+		return false
+	}
+
+	// If the last generation was marked as failed, we need to regenerate once the backoff has elapsed
+	if existing.Failed {
+		if time.Now().Before(existing.NextRetryAt) {
+			slog.Debug("state check: pack failed, waiting for retry backoff",
+				"key", key,
+				"attempts", existing.Attempts,
+				"next_retry_at", existing.NextRetryAt,
+			)
+			return false
+		}
+		slog.Debug("state check: pack needs generation (last generation failed)",
+			"key", key,
+			"attempts", existing.Attempts,
+			"last_error", existing.LastError,
+		)
+		return true
+	}
+
 	// If server exists, but has a newer UpdatedAt, we need to regenerate
 	needsRegen := updatedAt.After(existing.GeneratedAt)
 	slog.Debug("state check: pack in state",
@@ -151,6 +341,290 @@ func (s *WatchState) NeedsGeneration(namespace, name, version, packageType, tran
 	return needsRegen
 }
 
+// RecordFailure marks the server as failed, incrementing its consecutive attempt count
+// and scheduling the next retry, or quarantining it once the policy's limit is reached
+func (s *WatchState) RecordFailure(server *ServerState, genErr error, policy RetryPolicy) *ServerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A failed generation keeps the time of the last successful one, if any
+	key := server.Key()
+	server.GeneratedAt = time.Time{}
+	if previous, exists := s.Servers[key]; exists {
+		server.GeneratedAt = previous.GeneratedAt
+		if previous.Failed {
+			server.Attempts = previous.Attempts
+		}
+	}
+
+	server.Failed = true
+	server.LastError = genErr.Error()
+	server.Attempts++
+	server.Quarantined = policy.QuarantineAfter > 0 && server.Attempts >= policy.QuarantineAfter
+
+	// Without a backoff the pack is retried on the next scheduled poll
+	server.NextRetryAt = time.Time{}
+	if backoff := policy.Backoff(server.Attempts); backoff > 0 && !server.Quarantined {
+		server.NextRetryAt = server.UpdatedAt.Add(backoff)
+	}
+
+	s.Servers[key] = server
+	s.markChanged(key)
+	slog.Debug("state failure recorded",
+		"key", key,
+		"attempts", server.Attempts,
+		"next_retry_at", server.NextRetryAt,
+		"quarantined", server.Quarantined,
+	)
+
+	return server
+}
+
+// NextRetry returns the earliest retry time of failed servers still backing off
+func (s *WatchState) NextRetry() (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var next time.Time
+	for _, server := range s.Servers {
+		if !server.Failed || server.Quarantined || server.NextRetryAt.IsZero() {
+			continue
+		}
+		if next.IsZero() || server.NextRetryAt.Before(next) {
+			next = server.NextRetryAt
+		}
+	}
+
+	return next, !next.IsZero()
+}
+
+// ResetBackoff makes failed servers that are not quarantined eligible for retry on the next poll
+func (s *WatchState) ResetBackoff() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reset := 0
+	for key, server := range s.Servers {
+		if server.Failed && !server.Quarantined && !server.NextRetryAt.IsZero() {
+			server.NextRetryAt = time.Time{}
+			s.markChanged(key)
+			reset++
+		}
+	}
+
+	return reset
+}
+
+// Quarantined returns the quarantined servers sorted by key
+func (s *WatchState) Quarantined() []*ServerState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var servers []*ServerState
+	for _, server := range s.Servers {
+		if server.Quarantined {
+			servers = append(servers, server)
+		}
+	}
+
+	slices.SortFunc(servers, func(a, b *ServerState) int {
+		return strings.Compare(a.Key(), b.Key())
+	})
+
+	return servers
+}
+
+// ClearQuarantine releases the quarantined servers with the given keys, or all
+// quarantined servers when no keys are given, so they are retried on the next poll.
+// It returns the keys that were cleared.
+func (s *WatchState) ClearQuarantine(keys []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cleared []string
+	for key, server := range s.Servers {
+		if !server.Quarantined || (len(keys) > 0 && !slices.Contains(keys, key)) {
+			continue
+		}
+		server.Quarantined = false
+		server.Attempts = 0
+		server.NextRetryAt = time.Time{}
+		s.markChanged(key)
+		cleared = append(cleared, key)
+	}
+
+	slices.Sort(cleared)
+
+	return cleared
+}
+
+// StateFilter selects servers in the watch state. Empty fields match every server.
+type StateFilter struct {
+	Registries     []string
+	ServerNames    []string
+	PackageTypes   []string
+	TransportTypes []string
+}
+
+// Matches reports whether the server matches the filter
+func (f StateFilter) Matches(server *ServerState) bool {
+	if len(f.Registries) > 0 && !slices.Contains(f.Registries, server.Registry) {
+		return false
+	}
+
+	if len(f.ServerNames) > 0 && !slices.Contains(f.ServerNames, server.Namespace+"/"+server.Name) {
+		return false
+	}
+
+	if len(f.PackageTypes) > 0 && !slices.ContainsFunc(f.PackageTypes, func(t string) bool {
+		return strings.EqualFold(t, server.PackageType)
+	}) {
+		return false
+	}
+
+	transportType := utils.MapFromRegistryTransportType(server.TransportType)
+	if len(f.TransportTypes) > 0 && !slices.ContainsFunc(f.TransportTypes, func(t string) bool {
+		return strings.EqualFold(t, transportType)
+	}) {
+		return false
+	}
+
+	return true
+}
+
+// Select returns the servers matching the filter sorted by key
+func (s *WatchState) Select(filter StateFilter) []*ServerState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var servers []*ServerState
+	for _, server := range s.Servers {
+		if filter.Matches(server) {
+			servers = append(servers, server)
+		}
+	}
+
+	slices.SortFunc(servers, func(a, b *ServerState) int {
+		return strings.Compare(a.Key(), b.Key())
+	})
+
+	return servers
+}
+
+// PendingCommits returns the servers whose packs are waiting to be committed, sorted by key
+func (s *WatchState) PendingCommits() []*ServerState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var servers []*ServerState
+	for _, server := range s.Servers {
+		if server.CommitPending {
+			servers = append(servers, server)
+		}
+	}
+
+	slices.SortFunc(servers, func(a, b *ServerState) int {
+		return strings.Compare(a.Key(), b.Key())
+	})
+
+	return servers
+}
+
+// MarkCommitted records that the packs of the servers with the given keys are committed
+func (s *WatchState) MarkCommitted(keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if server, exists := s.Servers[key]; exists && server.CommitPending {
+			committed := *server
+			committed.CommitPending = false
+			s.Servers[key] = &committed
+			s.markChanged(key)
+		}
+	}
+}
+
+// OtherRegistries returns the keys of servers from other registries whose pack has the
+// same name as server's, in which case the pack on disk may have come from any of them
+func (s *WatchState) OtherRegistries(server *ServerState) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []string
+	for key, other := range s.Servers {
+		if other.Registry != server.Registry &&
+			other.Namespace == server.Namespace &&
+			other.Name == server.Name &&
+			other.Version == server.Version &&
+			other.PackageType == server.PackageType &&
+			other.TransportType == server.TransportType {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// Forget removes the servers with the given keys so their packs are generated
+// again on the next poll. It returns the keys that were removed.
+func (s *WatchState) Forget(keys []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removed []string
+	for _, key := range keys {
+		if _, exists := s.Servers[key]; exists {
+			delete(s.Servers, key)
+			s.markChanged(key)
+			removed = append(removed, key)
+		}
+	}
+
+	slices.Sort(removed)
+
+	return removed
+}
+
+// Merge copies the servers of other into the state, replacing servers with the same key.
+// It returns the number of servers copied.
+func (s *WatchState) Merge(other *WatchState) int {
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, server := range other.Servers {
+		s.Servers[key] = server
+		s.markChanged(key)
+	}
+	if other.LastPoll.After(s.LastPoll) {
+		s.LastPoll = other.LastPoll
+	}
+
+	return len(other.Servers)
+}
+
+// Summary returns the number of servers tracked in state and how many are marked as failed or quarantined
+func (s *WatchState) Summary() (total, failed, quarantined int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, server := range s.Servers {
+		if server.Failed {
+			failed++
+		}
+		if server.Quarantined {
+			quarantined++
+		}
+	}
+
+	return len(s.Servers), failed, quarantined
+}
+
 func (s *WatchState) UpdateLastPoll(t time.Time) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -173,6 +647,7 @@ func (s *WatchState) CleanupOldServers(maxAge time.Duration) int {
 	for key, server := range s.Servers {
 		if server.UpdatedAt.Before(cutoff) {
 			delete(s.Servers, key)
+			s.markChanged(key)
 			removed++
 		}
 	}
@@ -0,0 +1,586 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+)
+
+const legacyState = `{
+  "last_poll": "2026-10-01T00:00:00Z",
+  "servers": {
+    "io.github.example/weather-mcp@1.0.0:npm:stdio": {
+      "namespace": "io.github.example",
+      "name": "weather-mcp",
+      "version": "1.0.0",
+      "package_type": "npm",
+      "transport_type": "stdio",
+      "updated_at": "2026-10-01T00:00:00Z",
+      "generated_at": "2026-10-01T00:00:00Z"
+    },
+    "hand-edited": {
+      "namespace": "ai.waystation",
+      "name": "gmail",
+      "version": "0.3.0",
+      "package_type": "pypi",
+      "transport_type": "stdio",
+      "updated_at": "2026-10-01T00:00:00Z",
+      "generated_at": "2026-10-01T00:00:00Z"
+    }
+  }
+}`
+
+func TestLoadStateMigratesLegacyState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+	if err := os.WriteFile(path, []byte(legacyState), 0644); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+	store := statestore.NewFileStore(path)
+
+	state, err := LoadState(context.Background(), store, config.DefaultRegistryName)
+	if err != nil {
+		t.Fatalf("LoadState() unexpected error = %v", err)
+	}
+
+	if state.SchemaVersion != StateSchemaVersion {
+		t.Errorf("LoadState() schema version = %d, expected %d", state.SchemaVersion, StateSchemaVersion)
+	}
+	for _, key := range []string{
+		"default:io.github.example/weather-mcp@1.0.0:npm:stdio",
+		"default:ai.waystation/gmail@0.3.0:pypi:stdio",
+	} {
+		server, ok := state.GetServer(key)
+		if !ok {
+			t.Errorf("LoadState() state has no entry for %s", key)
+			continue
+		}
+		if server.Registry != "default" {
+			t.Errorf("LoadState() entry %s registry = %q, expected default", key, server.Registry)
+		}
+	}
+
+	// Reading the state leaves the store untouched
+	if _, err := os.Stat(path + ".v1.bak"); !os.IsNotExist(err) {
+		t.Errorf("LoadState() backed up state that was only read")
+	}
+	if data, _ := os.ReadFile(path); string(data) != legacyState {
+		t.Errorf("LoadState() rewrote the legacy state")
+	}
+
+	// Saving the migrated state backs up the legacy state first
+	if err := state.SaveState(context.Background(), store); err != nil {
+		t.Fatalf("SaveState() unexpected error = %v", err)
+	}
+	backup, err := os.ReadFile(path + ".v1.bak")
+	if err != nil {
+		t.Fatalf("expected a backup of the legacy state: %v", err)
+	}
+	if string(backup) != legacyState {
+		t.Errorf("backup does not match the legacy state")
+	}
+
+	// Saving again, or saving state at the current schema version, needs no further backup
+	os.Remove(path + ".v1.bak")
+	if err := state.SaveState(context.Background(), store); err != nil {
+		t.Fatalf("SaveState() unexpected error = %v", err)
+	}
+	if _, err := os.Stat(path + ".v1.bak"); !os.IsNotExist(err) {
+		t.Errorf("SaveState() backed up the legacy state twice")
+	}
+
+	if _, err := LoadState(context.Background(), store, config.DefaultRegistryName); err != nil {
+		t.Fatalf("LoadState() unexpected error = %v", err)
+	}
+	if _, err := os.Stat(path + ".v1.bak"); !os.IsNotExist(err) {
+		t.Errorf("expected no backup of state at the current schema version")
+	}
+}
+
+func TestParseStateAssignsLegacyServersToLegacyRegistry(t *testing.T) {
+	state, err := ParseState([]byte(legacyState), "internal")
+	if err != nil {
+		t.Fatalf("ParseState() unexpected error = %v", err)
+	}
+
+	server, ok := state.GetServer("internal:io.github.example/weather-mcp@1.0.0:npm:stdio")
+	if !ok {
+		t.Fatalf("ParseState() state has no entry in the internal registry, keys = %v", slices.Collect(maps.Keys(state.Servers)))
+	}
+	if server.Registry != "internal" {
+		t.Errorf("ParseState() entry registry = %q, expected internal", server.Registry)
+	}
+
+	if _, err := ParseState([]byte(legacyState), ""); !errors.Is(err, ErrLegacyRegistryUnknown) {
+		t.Errorf("ParseState() without legacy registry error = %v, expected ErrLegacyRegistryUnknown", err)
+	}
+	if _, err := ParseState([]byte(`{"servers": {}}`), ""); err != nil {
+		t.Errorf("ParseState() legacy state without servers unexpected error = %v", err)
+	}
+}
+
+func TestLoadStateRejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+	data, _ := json.Marshal(map[string]any{"schema_version": StateSchemaVersion + 1, "servers": map[string]any{}})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+
+	_, err := LoadState(context.Background(), statestore.NewFileStore(path), config.DefaultRegistryName)
+
+	var versionErr *StateVersionError
+	if !errors.As(err, &versionErr) {
+		t.Fatalf("LoadState() error = %v, expected *StateVersionError", err)
+	}
+	if versionErr.Version != StateSchemaVersion+1 {
+		t.Errorf("LoadState() error version = %d, expected %d", versionErr.Version, StateSchemaVersion+1)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		attempts int
+		expected time.Duration
+	}{
+		{name: "no backoff", policy: RetryPolicy{}, attempts: 3, expected: 0},
+		{name: "no attempts", policy: RetryPolicy{InitialBackoff: time.Minute}, attempts: 0, expected: 0},
+		{name: "first attempt", policy: RetryPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Hour}, attempts: 1, expected: time.Minute},
+		{name: "doubles", policy: RetryPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Hour}, attempts: 4, expected: 8 * time.Minute},
+		{name: "capped", policy: RetryPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Hour}, attempts: 10, expected: time.Hour},
+		{name: "initial above max", policy: RetryPolicy{InitialBackoff: 2 * time.Hour, MaxBackoff: time.Hour}, attempts: 1, expected: time.Hour},
+		{name: "uncapped", policy: RetryPolicy{InitialBackoff: time.Minute}, attempts: 11, expected: 1024 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Backoff(tt.attempts); got != tt.expected {
+				t.Errorf("Backoff(%d) = %v, expected %v", tt.attempts, got, tt.expected)
+			}
+		})
+	}
+}
+
+// testServerState returns the state of the weather-mcp server updated at the given time
+func testServerState(updatedAt time.Time) *ServerState {
+	return &ServerState{
+		Registry:      "default",
+		Namespace:     "io.github.example",
+		Name:          "weather-mcp",
+		Version:       "1.0.0",
+		PackageType:   "oci",
+		TransportType: "streamable-http",
+		UpdatedAt:     updatedAt,
+		GeneratedAt:   updatedAt,
+	}
+}
+
+// needsGeneration reports whether the weather-mcp server needs generation
+func needsGeneration(state *WatchState) bool {
+	return state.NeedsGeneration("default", "io.github.example", "weather-mcp", "1.0.0", "oci", "streamable-http", time.Time{})
+}
+
+func TestRecordFailure(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Minute, MaxBackoff: 4 * time.Minute, QuarantineAfter: 4}
+	state := NewWatchState()
+
+	generated := time.Now().Add(-time.Hour)
+	state.SetServer(testServerState(generated))
+
+	expectedBackoffs := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, backoff := range expectedBackoffs {
+		now := time.Now()
+		failed := state.RecordFailure(testServerState(now), errors.New("render failed"), policy)
+
+		if !failed.Failed || failed.Attempts != i+1 || failed.LastError != "render failed" || failed.Quarantined {
+			t.Fatalf("RecordFailure() attempt %d = %+v", i+1, failed)
+		}
+		if !failed.NextRetryAt.Equal(now.Add(backoff)) {
+			t.Errorf("RecordFailure() attempt %d next retry = %v, expected %v", i+1, failed.NextRetryAt, now.Add(backoff))
+		}
+		if !failed.GeneratedAt.Equal(generated) {
+			t.Errorf("RecordFailure() generated at = %v, expected the last successful generation %v", failed.GeneratedAt, generated)
+		}
+	}
+
+	quarantined := state.RecordFailure(testServerState(time.Now()), errors.New("render failed"), policy)
+	if !quarantined.Quarantined || quarantined.Attempts != 4 || !quarantined.NextRetryAt.IsZero() {
+		t.Errorf("RecordFailure() after %d failures = %+v, expected quarantine", policy.QuarantineAfter, quarantined)
+	}
+
+	// A success resets the attempt count
+	state.SetServer(testServerState(time.Now()))
+	if failed := state.RecordFailure(testServerState(time.Now()), errors.New("render failed"), policy); failed.Attempts != 1 {
+		t.Errorf("RecordFailure() after a success attempts = %d, expected 1", failed.Attempts)
+	}
+}
+
+func TestNeedsGenerationRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   *ServerState
+		expected bool
+	}{
+		{name: "not in state", expected: true},
+		{name: "generated", server: testServerState(time.Now()), expected: false},
+		{name: "failed without backoff", server: &ServerState{Failed: true, Attempts: 1}, expected: true},
+		{name: "failed backing off", server: &ServerState{Failed: true, Attempts: 1, NextRetryAt: time.Now().Add(time.Minute)}, expected: false},
+		{name: "failed backoff elapsed", server: &ServerState{Failed: true, Attempts: 2, NextRetryAt: time.Now().Add(-time.Second)}, expected: true},
+		{name: "quarantined", server: &ServerState{Failed: true, Attempts: 5, Quarantined: true}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewWatchState()
+			if tt.server != nil {
+				server := testServerState(time.Now())
+				server.Failed = tt.server.Failed
+				server.Attempts = tt.server.Attempts
+				server.NextRetryAt = tt.server.NextRetryAt
+				server.Quarantined = tt.server.Quarantined
+				state.SetServer(server)
+			}
+
+			if got := needsGeneration(state); got != tt.expected {
+				t.Errorf("NeedsGeneration() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClearQuarantine(t *testing.T) {
+	state := NewWatchState()
+	policy := RetryPolicy{InitialBackoff: time.Minute, QuarantineAfter: 1}
+
+	weather := state.RecordFailure(testServerState(time.Now()), errors.New("render failed"), policy)
+	news := testServerState(time.Now())
+	news.Name = "news-mcp"
+	news = state.RecordFailure(news, errors.New("render failed"), policy)
+
+	if cleared := state.ClearQuarantine([]string{weather.Key(), "default:io.github.example/missing@1.0.0:oci:stdio"}); !slices.Equal(cleared, []string{weather.Key()}) {
+		t.Errorf("ClearQuarantine() = %v, expected %v", cleared, []string{weather.Key()})
+	}
+	if !needsGeneration(state) || weather.Attempts != 0 || weather.Quarantined {
+		t.Errorf("ClearQuarantine() left %+v, expected it to be retried", weather)
+	}
+	if !news.Quarantined {
+		t.Error("ClearQuarantine() cleared a server that wasn't selected")
+	}
+
+	// Without keys every quarantined server is cleared
+	if cleared := state.ClearQuarantine(nil); !slices.Equal(cleared, []string{news.Key()}) {
+		t.Errorf("ClearQuarantine() = %v, expected %v", cleared, []string{news.Key()})
+	}
+}
+
+func TestResetBackoff(t *testing.T) {
+	state := NewWatchState()
+
+	state.RecordFailure(testServerState(time.Now()), errors.New("render failed"), RetryPolicy{InitialBackoff: time.Hour})
+	quarantined := testServerState(time.Now())
+	quarantined.Name = "news-mcp"
+	state.RecordFailure(quarantined, errors.New("render failed"), RetryPolicy{QuarantineAfter: 1})
+
+	if needsGeneration(state) {
+		t.Fatal("NeedsGeneration() = true while backing off")
+	}
+	if reset := state.ResetBackoff(); reset != 1 {
+		t.Errorf("ResetBackoff() = %d, expected 1", reset)
+	}
+	if !needsGeneration(state) {
+		t.Error("NeedsGeneration() = false after the backoff was reset")
+	}
+	if !quarantined.Quarantined {
+		t.Error("ResetBackoff() released a quarantined server")
+	}
+}
+
+// casData is state shared by casStore handles, versioned like a Consul key or Nomad Variable
+type casData struct {
+	mu      sync.Mutex
+	data    []byte
+	version int
+}
+
+// casStore is a handle to casData that rejects saves over a version it hasn't loaded
+type casStore struct {
+	shared *casData
+	index  int
+}
+
+func (s *casStore) Load(ctx context.Context) ([]byte, error) {
+	s.shared.mu.Lock()
+	defer s.shared.mu.Unlock()
+
+	s.index = s.shared.version
+	return s.shared.data, nil
+}
+
+func (s *casStore) Save(ctx context.Context, data []byte) error {
+	s.shared.mu.Lock()
+	defer s.shared.mu.Unlock()
+
+	if s.index != s.shared.version {
+		return statestore.ErrConflict
+	}
+	s.shared.data = data
+	s.shared.version++
+	s.index = s.shared.version
+	return nil
+}
+
+func (s *casStore) Backup(ctx context.Context, data []byte, name string) (string, error) {
+	return "", nil
+}
+
+func (s *casStore) Check(ctx context.Context) error { return nil }
+
+func (s *casStore) String() string { return "cas://test" }
+
+func (s *casStore) Close() error { return nil }
+
+func TestSaveStateMergesConflictingChanges(t *testing.T) {
+	shared := &casData{}
+	watch, cli := &casStore{shared: shared}, &casStore{shared: shared}
+
+	// The stored state starts with two servers
+	initial := NewWatchState()
+	old, forgotten := testServerState(time.Now()), testServerState(time.Now())
+	old.Name, forgotten.Name = "old-mcp", "forgotten-mcp"
+	initial.SetServer(old)
+	initial.SetServer(forgotten)
+	if err := initial.SaveState(context.Background(), watch); err != nil {
+		t.Fatalf("SaveState() unexpected error = %v", err)
+	}
+
+	watchState, err := LoadState(context.Background(), watch, config.DefaultRegistryName)
+	if err != nil {
+		t.Fatalf("LoadState() unexpected error = %v", err)
+	}
+	cliState, err := LoadState(context.Background(), cli, config.DefaultRegistryName)
+	if err != nil {
+		t.Fatalf("LoadState() unexpected error = %v", err)
+	}
+
+	// Another process forgets a server and adds one while the watcher generates a pack
+	news := testServerState(time.Now())
+	news.Name = "news-mcp"
+	cliState.Forget([]string{forgotten.Key()})
+	cliState.SetServer(news)
+	if err := cliState.SaveState(context.Background(), cli); err != nil {
+		t.Fatalf("SaveState() unexpected error = %v", err)
+	}
+
+	weather := testServerState(time.Now())
+	watchState.SetServer(weather)
+	if err := watchState.SaveState(context.Background(), watch); err != nil {
+		t.Fatalf("SaveState() after a conflicting save error = %v", err)
+	}
+
+	stored, err := ParseState(shared.data, config.DefaultRegistryName)
+	if err != nil {
+		t.Fatalf("ParseState() unexpected error = %v", err)
+	}
+	var keys []string
+	for key := range stored.Servers {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	expected := []string{news.Key(), old.Key(), weather.Key()}
+	slices.Sort(expected)
+	if !slices.Equal(keys, expected) {
+		t.Errorf("stored servers = %v, expected %v", keys, expected)
+	}
+
+	// The watcher saves against the reloaded version from then on
+	watchState.UpdateLastPoll(time.Now())
+	if err := watchState.SaveState(context.Background(), watch); err != nil {
+		t.Errorf("SaveState() after merging error = %v", err)
+	}
+}
+
+// testStateServers returns servers across two registries, package types and transports
+func testStateServers() []*ServerState {
+	var servers []*ServerState
+	for _, s := range []struct{ registry, name, packageType, transportType string }{
+		{"default", "weather-mcp", "oci", "streamable-http"},
+		{"default", "weather-mcp", "npm", "stdio"},
+		{"default", "news-mcp", "pypi", "stdio"},
+		{"internal", "weather-mcp", "oci", "streamable-http"},
+	} {
+		server := testServerState(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+		server.Registry = s.registry
+		server.Name = s.name
+		server.PackageType = s.packageType
+		server.TransportType = s.transportType
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+func TestSelect(t *testing.T) {
+	state := NewWatchState()
+	for _, server := range testStateServers() {
+		state.SetServer(server)
+	}
+
+	tests := []struct {
+		name     string
+		filter   StateFilter
+		expected []string
+	}{
+		{
+			name:   "all",
+			filter: StateFilter{},
+			expected: []string{
+				"default:io.github.example/news-mcp@1.0.0:pypi:stdio",
+				"default:io.github.example/weather-mcp@1.0.0:npm:stdio",
+				"default:io.github.example/weather-mcp@1.0.0:oci:streamable-http",
+				"internal:io.github.example/weather-mcp@1.0.0:oci:streamable-http",
+			},
+		},
+		{
+			name:     "registry",
+			filter:   StateFilter{Registries: []string{"internal"}},
+			expected: []string{"internal:io.github.example/weather-mcp@1.0.0:oci:streamable-http"},
+		},
+		{
+			name:   "server name",
+			filter: StateFilter{ServerNames: []string{"io.github.example/weather-mcp"}, Registries: []string{"default"}},
+			expected: []string{
+				"default:io.github.example/weather-mcp@1.0.0:npm:stdio",
+				"default:io.github.example/weather-mcp@1.0.0:oci:streamable-http",
+			},
+		},
+		{
+			name:     "package type ignores case",
+			filter:   StateFilter{PackageTypes: []string{"PyPI"}},
+			expected: []string{"default:io.github.example/news-mcp@1.0.0:pypi:stdio"},
+		},
+		{
+			name:   "user transport type",
+			filter: StateFilter{TransportTypes: []string{"http"}},
+			expected: []string{
+				"default:io.github.example/weather-mcp@1.0.0:oci:streamable-http",
+				"internal:io.github.example/weather-mcp@1.0.0:oci:streamable-http",
+			},
+		},
+		{
+			name:   "no match",
+			filter: StateFilter{ServerNames: []string{"io.github.example/missing-mcp"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			for _, server := range state.Select(tt.filter) {
+				keys = append(keys, server.Key())
+			}
+			if !slices.Equal(keys, tt.expected) {
+				t.Errorf("Select() = %v, expected %v", keys, tt.expected)
+			}
+		})
+	}
+}
+
+func TestForget(t *testing.T) {
+	state := NewWatchState()
+	servers := testStateServers()
+	for _, server := range servers {
+		state.SetServer(server)
+	}
+
+	removed := state.Forget([]string{servers[2].Key(), "default:io.github.example/missing-mcp@1.0.0:oci:stdio", servers[0].Key()})
+	if expected := []string{servers[2].Key(), servers[0].Key()}; !slices.Equal(removed, expected) {
+		t.Errorf("Forget() = %v, expected %v", removed, expected)
+	}
+	if total, _, _ := state.Summary(); total != 2 {
+		t.Errorf("Forget() left %d servers, expected 2", total)
+	}
+	if !needsGeneration(state) {
+		t.Error("NeedsGeneration() = false for a forgotten server")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	servers := testStateServers()
+
+	state := NewWatchState()
+	state.SetServer(servers[0])
+	state.SetServer(servers[1])
+	state.UpdateLastPoll(time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC))
+
+	replacement := *servers[1]
+	replacement.Failed = true
+	other := NewWatchState()
+	other.SetServer(&replacement)
+	other.SetServer(servers[2])
+	other.UpdateLastPoll(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+
+	if count := state.Merge(other); count != 2 {
+		t.Errorf("Merge() = %d, expected 2", count)
+	}
+	if total, failed, _ := state.Summary(); total != 3 || failed != 1 {
+		t.Errorf("Merge() summary = %d servers, %d failed, expected 3 and 1", total, failed)
+	}
+	if server, _ := state.GetServer(servers[1].Key()); !server.Failed {
+		t.Error("Merge() did not replace the server with the same key")
+	}
+	if lastPoll := state.GetLastPoll(); !lastPoll.Equal(time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Merge() last poll = %v, expected the later poll to be kept", lastPoll)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	source := NewWatchState()
+	servers := testStateServers()
+	for _, server := range servers {
+		source.SetServer(server)
+	}
+	source.RecordFailure(servers[2], errors.New("render failed"), RetryPolicy{InitialBackoff: time.Minute, QuarantineAfter: 1})
+	source.UpdateLastPoll(time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC))
+
+	data, err := source.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() unexpected error = %v", err)
+	}
+
+	imported, err := ParseState(data, config.DefaultRegistryName)
+	if err != nil {
+		t.Fatalf("ParseState() unexpected error = %v", err)
+	}
+
+	// Importing into an empty store reproduces the exported state
+	path := filepath.Join(t.TempDir(), "watch.json")
+	err = EditState(context.Background(), path, "", 0, func(state *WatchState) bool {
+		state.UpdateLastPoll(imported.GetLastPoll())
+		state.Merge(imported)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("EditState() unexpected error = %v", err)
+	}
+
+	restored, err := ReadState(context.Background(), path, "")
+	if err != nil {
+		t.Fatalf("ReadState() unexpected error = %v", err)
+	}
+	restoredData, err := restored.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() unexpected error = %v", err)
+	}
+	if string(restoredData) != string(data) {
+		t.Errorf("imported state differs from the export\n got: %s\nwant: %s", restoredData, data)
+	}
+}
@@ -0,0 +1,233 @@
+package watcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/schedule"
+)
+
+// maxRecentResults is the number of recent successes and failures kept for reporting
+const maxRecentResults = 20
+
+type TaskPhase string
+
+const (
+	TaskPhaseQueued       TaskPhase = "queued"
+	TaskPhasePreHooks     TaskPhase = "pre-generate hooks"
+	TaskPhaseGenerating   TaskPhase = "generating"
+	TaskPhasePostHooks    TaskPhase = "post-generate hooks"
+	TaskPhaseCommitting   TaskPhase = "committing"
+	TaskPhaseFailureHooks TaskPhase = "on-failure hooks"
+)
+
+// TaskProgress describes a queued or running pack generation task
+type TaskProgress struct {
+	Key           string
+	ServerName    string
+	Version       string
+	PackageType   string
+	TransportType string
+	Worker        int
+	Phase         TaskPhase
+	StartedAt     time.Time
+}
+
+// TaskResult describes a finished pack generation task
+type TaskResult struct {
+	TaskProgress
+	FinishedAt time.Time
+	Error      string
+	Skipped    bool // Generation was skipped because the pack already exists
+}
+
+// Status is a point in time snapshot of the watcher's activity
+type Status struct {
+	StartedAt          time.Time
+	PollInterval       time.Duration
+	Schedule           schedule.Schedule
+	PollJitter         time.Duration
+	Polling            bool
+	LastPoll           time.Time
+	LastSuccessfulPoll time.Time
+	NextPoll           time.Time
+	LastQuietPoll      time.Time // Last poll skipped during a quiet window
+	QuietUntil         time.Time // End of the current quiet window, if in one
+	LastError          string
+	ServersFetched     int
+	TasksNeeded        int
+	Queued             []TaskProgress
+	Running            []TaskProgress
+	RecentSuccesses    []TaskResult
+	RecentFailures     []TaskResult
+	StateFile          string
+	StateServers       int
+	StateFailed        int
+	StateQuarantined   int
+}
+
+// statusTracker records watcher activity for status reporting
+type statusTracker struct {
+	mu     sync.Mutex
+	status Status
+	tasks  map[string]*TaskProgress
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{
+		tasks: make(map[string]*TaskProgress),
+	}
+}
+
+func (t *statusTracker) started(start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.StartedAt = start
+}
+
+func (t *statusTracker) pollStarted(start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.Polling = true
+	t.status.LastPoll = start
+	t.status.QuietUntil = time.Time{}
+	t.status.ServersFetched = 0
+	t.status.TasksNeeded = 0
+}
+
+func (t *statusTracker) pollFinished(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.Polling = false
+	if err != nil {
+		t.status.LastError = err.Error()
+		return
+	}
+
+	t.status.LastError = ""
+	t.status.LastSuccessfulPoll = t.status.LastPoll
+}
+
+// pollSkipped records a poll skipped during a quiet window ending at until
+func (t *statusTracker) pollSkipped(at, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.LastQuietPoll = at
+	t.status.QuietUntil = until
+}
+
+func (t *statusTracker) setNextPoll(next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.NextPoll = next
+}
+
+func (t *statusTracker) setFetched(servers, tasks int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.ServersFetched = servers
+	t.status.TasksNeeded = tasks
+}
+
+func (t *statusTracker) taskQueued(task ServerGenerateTask) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := task.String()
+	t.tasks[key] = &TaskProgress{
+		Key:           key,
+		ServerName:    task.Server.Name,
+		Version:       task.Server.Version,
+		PackageType:   task.Package.RegistryType,
+		TransportType: task.Package.Transport.Type,
+		Worker:        -1,
+		Phase:         TaskPhaseQueued,
+	}
+}
+
+func (t *statusTracker) taskStarted(task ServerGenerateTask, worker int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.tasks[task.String()]; ok {
+		p.Worker = worker
+		p.Phase = TaskPhaseGenerating
+		p.StartedAt = time.Now()
+	}
+}
+
+func (t *statusTracker) taskPhase(task ServerGenerateTask, phase TaskPhase) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.tasks[task.String()]; ok {
+		p.Phase = phase
+	}
+}
+
+func (t *statusTracker) taskFinished(task ServerGenerateTask, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := task.String()
+	p, ok := t.tasks[key]
+	if !ok {
+		return
+	}
+	delete(t.tasks, key)
+
+	result := TaskResult{TaskProgress: *p, FinishedAt: time.Now()}
+
+	// Packs that already exist aren't failures, they're reported with the successes
+	if packExists(err) {
+		result.Skipped = true
+		err = nil
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		t.status.RecentFailures = appendRecent(t.status.RecentFailures, result)
+		return
+	}
+
+	t.status.RecentSuccesses = appendRecent(t.status.RecentSuccesses, result)
+}
+
+func (t *statusTracker) clearFailures() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.RecentFailures = nil
+}
+
+func (t *statusTracker) snapshot() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.status
+	s.Queued = nil
+	s.Running = nil
+	for _, p := range t.tasks {
+		if p.Phase == TaskPhaseQueued {
+			s.Queued = append(s.Queued, *p)
+		} else {
+			s.Running = append(s.Running, *p)
+		}
+	}
+	s.RecentSuccesses = append([]TaskResult(nil), t.status.RecentSuccesses...)
+	s.RecentFailures = append([]TaskResult(nil), t.status.RecentFailures...)
+
+	return s
+}
+
+// appendRecent prepends a result, keeping at most maxRecentResults, newest first
+func appendRecent(results []TaskResult, result TaskResult) []TaskResult {
+	results = append([]TaskResult{result}, results...)
+	if len(results) > maxRecentResults {
+		results = results[:maxRecentResults]
+	}
+	return results
+}
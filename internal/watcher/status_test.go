@@ -0,0 +1,118 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
+	"github.com/modelcontextprotocol/registry/pkg/model"
+)
+
+func testTask(name string) ServerGenerateTask {
+	return ServerGenerateTask{
+		Server:  v0.ServerJSON{Name: name, Version: "1.0.0"},
+		Package: &model.Package{RegistryType: "oci", Transport: model.Transport{Type: "streamable-http"}},
+	}
+}
+
+func TestStatusTrackerTasks(t *testing.T) {
+	tracker := newStatusTracker()
+
+	succeeded := testTask("io.github.example/succeeded")
+	failed := testTask("io.github.example/failed")
+	exists := testTask("io.github.example/exists")
+	for _, task := range []ServerGenerateTask{succeeded, failed, exists} {
+		tracker.taskQueued(task)
+	}
+
+	s := tracker.snapshot()
+	if len(s.Queued) != 3 || len(s.Running) != 0 {
+		t.Fatalf("snapshot() queued = %d, running = %d; expected 3 queued", len(s.Queued), len(s.Running))
+	}
+
+	tracker.taskStarted(succeeded, 1)
+	tracker.taskPhase(succeeded, TaskPhasePostHooks)
+	s = tracker.snapshot()
+	if len(s.Queued) != 2 || len(s.Running) != 1 || s.Running[0].Worker != 1 || s.Running[0].Phase != TaskPhasePostHooks {
+		t.Fatalf("snapshot() running = %+v, expected the task on worker 1 running post hooks", s.Running)
+	}
+
+	tracker.taskFinished(succeeded, nil)
+	tracker.taskFinished(failed, errors.New("template failed"))
+	tracker.taskFinished(exists, fmt.Errorf("pack directory exists: %w", generator.ErrPackDirectoryExists))
+
+	s = tracker.snapshot()
+	if len(s.Queued) != 0 || len(s.Running) != 0 {
+		t.Errorf("snapshot() has unfinished tasks: queued %+v, running %+v", s.Queued, s.Running)
+	}
+	if len(s.RecentFailures) != 1 || s.RecentFailures[0].ServerName != failed.Server.Name || s.RecentFailures[0].Error != "template failed" {
+		t.Errorf("snapshot() failures = %+v, expected only the failed task", s.RecentFailures)
+	}
+	if len(s.RecentSuccesses) != 2 || !s.RecentSuccesses[0].Skipped || s.RecentSuccesses[0].ServerName != exists.Server.Name || s.RecentSuccesses[1].Skipped {
+		t.Errorf("snapshot() successes = %+v, expected the existing pack skipped, newest first", s.RecentSuccesses)
+	}
+
+	// Finishing an unknown task is ignored
+	tracker.taskFinished(testTask("io.github.example/unknown"), errors.New("failed"))
+	if s := tracker.snapshot(); len(s.RecentFailures) != 1 {
+		t.Errorf("snapshot() failures = %d after an unknown task, expected 1", len(s.RecentFailures))
+	}
+
+	tracker.clearFailures()
+	if s := tracker.snapshot(); len(s.RecentFailures) != 0 {
+		t.Errorf("snapshot() failures = %d after clearFailures()", len(s.RecentFailures))
+	}
+}
+
+func TestStatusTrackerPolls(t *testing.T) {
+	tracker := newStatusTracker()
+	first := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker.pollStarted(first)
+	tracker.setFetched(10, 2)
+	if s := tracker.snapshot(); !s.Polling || s.ServersFetched != 10 || s.TasksNeeded != 2 {
+		t.Errorf("snapshot() = %+v during the poll", s)
+	}
+
+	tracker.pollFinished(nil)
+	if s := tracker.snapshot(); s.Polling || !s.LastSuccessfulPoll.Equal(first) || s.LastError != "" {
+		t.Errorf("snapshot() = %+v after a successful poll", s)
+	}
+
+	second := first.Add(time.Hour)
+	tracker.pollStarted(second)
+	if s := tracker.snapshot(); s.ServersFetched != 0 || s.TasksNeeded != 0 {
+		t.Errorf("pollStarted() kept the previous poll's counts: %+v", s)
+	}
+	tracker.pollFinished(errors.New("registry unavailable"))
+	if s := tracker.snapshot(); s.LastError != "registry unavailable" || !s.LastSuccessfulPoll.Equal(first) || !s.LastPoll.Equal(second) {
+		t.Errorf("snapshot() = %+v after a failed poll", s)
+	}
+
+	quietUntil := second.Add(30 * time.Minute)
+	tracker.pollSkipped(second, quietUntil)
+	if s := tracker.snapshot(); !s.QuietUntil.Equal(quietUntil) || !s.LastQuietPoll.Equal(second) {
+		t.Errorf("snapshot() = %+v after a skipped poll", s)
+	}
+	tracker.pollStarted(quietUntil)
+	if s := tracker.snapshot(); !s.QuietUntil.IsZero() {
+		t.Errorf("pollStarted() kept the quiet window: %+v", s)
+	}
+}
+
+func TestAppendRecent(t *testing.T) {
+	var results []TaskResult
+	for i := range maxRecentResults + 5 {
+		results = appendRecent(results, TaskResult{Error: fmt.Sprint(i)})
+	}
+
+	if len(results) != maxRecentResults {
+		t.Fatalf("appendRecent() kept %d results, expected %d", len(results), maxRecentResults)
+	}
+	if results[0].Error != fmt.Sprint(maxRecentResults+4) {
+		t.Errorf("appendRecent() newest = %s, expected %d", results[0].Error, maxRecentResults+4)
+	}
+}
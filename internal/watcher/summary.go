@@ -0,0 +1,127 @@
+package watcher
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
+)
+
+// PollOutcome classifies the result of a poll cycle
+type PollOutcome string
+
+const (
+	// PollOutcomeUnchanged means no packs needed generation and no errors occurred
+	PollOutcomeUnchanged PollOutcome = "unchanged"
+	// PollOutcomeGenerated means packs were generated without errors
+	PollOutcomeGenerated PollOutcome = "generated"
+	// PollOutcomeNonCritical means the poll completed with non-critical errors, such as
+	// packs that already exist, servers that could not all be fetched or packs that could
+	// not be committed to git
+	PollOutcomeNonCritical PollOutcome = "non_critical_errors"
+	// PollOutcomeCritical means the poll failed or packs could not be generated
+	PollOutcomeCritical PollOutcome = "critical_errors"
+)
+
+// PackResult describes a pack generation attempted during a poll cycle
+type PackResult struct {
+	Registry      string `json:"registry"`
+	Server        string `json:"server"`
+	Version       string `json:"version"`
+	PackageType   string `json:"package_type"`
+	TransportType string `json:"transport_type"`
+	PackPath      string `json:"pack_path"`
+	Error         string `json:"error,omitempty"`
+}
+
+// PollSummary is a machine-readable report of a poll cycle
+type PollSummary struct {
+	Outcome        PollOutcome  `json:"outcome"`
+	RequestID      string       `json:"request_id,omitempty"`
+	StartedAt      time.Time    `json:"started_at"`
+	DurationMillis int64        `json:"duration_ms"`
+	DryRun         bool         `json:"dry_run"`
+	ServersFetched int          `json:"servers_fetched"`
+	Attempted      int          `json:"attempted"`
+	Generated      []PackResult `json:"generated"`
+	Skipped        []PackResult `json:"skipped"`
+	Failed         []PackResult `json:"failed"`
+	Quarantined    []string     `json:"quarantined"`
+	GitError       string       `json:"git_error,omitempty"`
+	Error          string       `json:"error,omitempty"`
+}
+
+func newPollSummary(start time.Time, dryRun bool) *PollSummary {
+	return &PollSummary{
+		StartedAt:   start,
+		DryRun:      dryRun,
+		Generated:   []PackResult{},
+		Skipped:     []PackResult{},
+		Failed:      []PackResult{},
+		Quarantined: []string{},
+	}
+}
+
+// record adds the result of a generation task to the summary
+func (s *PollSummary) record(task ServerGenerateTask, opts generator.Options, err error) {
+	result := PackResult{
+		Registry:      task.Registry.Name,
+		Server:        task.Server.Name,
+		Version:       task.Server.Version,
+		PackageType:   task.Package.RegistryType,
+		TransportType: utils.MapFromRegistryTransportType(task.Package.Transport.Type),
+		PackPath:      generator.PackPath(&task.Server, task.Package, opts),
+	}
+
+	switch {
+	case err == nil:
+		s.Generated = append(s.Generated, result)
+	case packExists(err):
+		result.Error = err.Error()
+		s.Skipped = append(s.Skipped, result)
+	default:
+		result.Error = err.Error()
+		s.Failed = append(s.Failed, result)
+	}
+}
+
+// finish completes the summary once the poll cycle has ended, recording packs
+// quarantined during the cycle and classifying its outcome
+func (s *PollSummary) finish(pollErr error, quarantinedBefore, quarantinedAfter []*ServerState) {
+	s.DurationMillis = time.Since(s.StartedAt).Milliseconds()
+
+	before := make(map[string]bool, len(quarantinedBefore))
+	for _, q := range quarantinedBefore {
+		before[q.Key()] = true
+	}
+	for _, q := range quarantinedAfter {
+		if !before[q.Key()] {
+			s.Quarantined = append(s.Quarantined, q.Key())
+		}
+	}
+
+	// Results are collected as generations finish, so sort them for stable output
+	for _, results := range [][]PackResult{s.Generated, s.Skipped, s.Failed} {
+		slices.SortFunc(results, func(a, b PackResult) int {
+			return strings.Compare(a.PackPath, b.PackPath)
+		})
+	}
+
+	if pollErr != nil {
+		s.Error = pollErr.Error()
+	}
+
+	switch {
+	case pollErr != nil && !errors.Is(pollErr, ErrPollIncomplete):
+		s.Outcome = PollOutcomeCritical
+	case pollErr != nil || len(s.Skipped) > 0:
+		s.Outcome = PollOutcomeNonCritical
+	case len(s.Generated) > 0:
+		s.Outcome = PollOutcomeGenerated
+	default:
+		s.Outcome = PollOutcomeUnchanged
+	}
+}
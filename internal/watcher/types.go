@@ -1,31 +1,97 @@
 package watcher
 
 import (
+	"fmt"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/leefowlercu/go-mcp-registry/mcp"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/gitrepo"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/hooks"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/registry"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/schedule"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
 	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
 	"github.com/modelcontextprotocol/registry/pkg/model"
 )
 
 type WatcherConfig struct {
-	PollInterval    int
-	StateFilePath   string
-	MaxConcurrent   int
-	AllowDeprecated bool
+	PollInterval      int
+	Schedule          schedule.Schedule // Polls at scheduled times instead of every PollInterval when set
+	PollJitter        time.Duration     // Random delay of up to PollJitter added to every poll
+	QuietWindows      []schedule.QuietWindow
+	Store             statestore.Store
+	LegacyRegistry    string // Registry of servers in state saved before multiple registries were supported
+	MaxConcurrent     int
+	AllowDeprecated   bool
+	Registries        []*RegistrySource // Registries to poll, in precedence order
+	Overrides         []config.OverrideConfig
+	Hooks             *hooks.Runner
+	Git               *gitrepo.Repo
+	GitCommitMode     config.GitCommitMode
+	Retry             RetryPolicy
+	OutputLockTimeout time.Duration // Time to wait for another process generating packs into the output directory
+}
+
+// pollSchedule returns the cron schedule if set, otherwise a schedule polling every PollInterval
+func (c *WatcherConfig) pollSchedule() schedule.Schedule {
+	if c.Schedule != nil {
+		return c.Schedule
+	}
+
+	return schedule.Every(time.Duration(c.PollInterval) * time.Second)
+}
+
+// RegistrySource is a registry polled by the watcher, with the filters applied to its servers
+type RegistrySource struct {
+	Name            string
+	URL             string
+	Client          *registry.Client
 	NameFilter      *ServerNameFilter
 	PackageFilter   *PackageTypeFilter
 	TransportFilter *TransportTypeFilter
 }
 
+// RetryPolicy controls the backoff between retries of failed generations and when they are quarantined
+type RetryPolicy struct {
+	InitialBackoff  time.Duration
+	MaxBackoff      time.Duration
+	QuarantineAfter int
+}
+
+// Backoff returns the delay before retrying after the given number of consecutive failures
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	if p.InitialBackoff <= 0 || attempts <= 0 {
+		return 0
+	}
+
+	backoff := p.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+
+	return backoff
+}
+
 type Watcher struct {
-	client       *mcp.Client
-	config       *WatcherConfig
-	state        *WatchState
-	generateOpts generator.Options
+	config        *WatcherConfig
+	pendingConfig *WatcherConfig
+	configMu      sync.RWMutex
+	state         *WatchState
+	generateOpts  generator.Options
+	status        *statusTracker
+	trigger       chan struct{}
 }
 
 type ServerNameFilter struct {
@@ -72,24 +138,47 @@ func (f *TransportTypeFilter) Matches(registryTransportType string) bool {
 }
 
 type ServerGenerateTask struct {
-	Server  v0.ServerJSON
-	Package *model.Package
+	Registry *RegistrySource
+	Server   v0.ServerJSON
+	Package  *model.Package
+}
+
+func (t ServerGenerateTask) String() string {
+	return fmt.Sprintf("%s@%s:%s:%s", t.Server.Name, t.Server.Version, t.Package.RegistryType, t.Package.Transport.Type)
 }
 
+// registryServer is a server fetched from one of the watched registries
+type registryServer struct {
+	source   *RegistrySource
+	response v0.ServerResponse
+}
+
+// generateResult is the outcome of a single pack generation task
+type generateResult struct {
+	task ServerGenerateTask
+	err  error
+}
+
+// packGenSemaphore limits concurrent pack generations, handing out numbered worker slots
 type packGenSemaphore struct {
-	sem chan struct{}
+	sem chan int
 }
 
 func newPackGenSemaphore(maxConcurrent int) *packGenSemaphore {
+	sem := make(chan int, maxConcurrent)
+	for i := range maxConcurrent {
+		sem <- i
+	}
+
 	return &packGenSemaphore{
-		sem: make(chan struct{}, maxConcurrent),
+		sem: sem,
 	}
 }
 
-func (p *packGenSemaphore) Acquire() {
-	p.sem <- struct{}{}
+func (p *packGenSemaphore) Acquire() int {
+	return <-p.sem
 }
 
-func (p *packGenSemaphore) Release() {
-	<-p.sem
+func (p *packGenSemaphore) Release(worker int) {
+	p.sem <- worker
 }
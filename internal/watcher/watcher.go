@@ -2,6 +2,9 @@ package watcher
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -9,46 +12,77 @@ import (
 	"time"
 
 	"github.com/leefowlercu/go-mcp-registry/mcp"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/gitrepo"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/hooks"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/metrics"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/output"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/registry"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/schedule"
 	"github.com/leefowlercu/nomad-mcp-pack/internal/server"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/tracing"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/utils"
 	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
+	"github.com/modelcontextprotocol/registry/pkg/model"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func NewWatcher(client *mcp.Client, cfg *WatcherConfig, generateOpts generator.Options) (*Watcher, error) {
-	state, err := LoadState(cfg.StateFilePath)
+func NewWatcher(ctx context.Context, cfg *WatcherConfig, generateOpts generator.Options) (*Watcher, error) {
+	state, err := LoadState(ctx, cfg.Store, cfg.LegacyRegistry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load state: %w", err)
 	}
 
 	return &Watcher{
-		client:       client,
 		config:       cfg,
 		state:        state,
 		generateOpts: generateOpts,
+		status:       newStatusTracker(),
+		trigger:      make(chan struct{}, 1),
 	}, nil
 }
 
 func (w *Watcher) Run(ctx context.Context) error {
 	slog.Info("starting watch mode",
 		"poll_interval", w.config.PollInterval,
-		"state_file", w.config.StateFilePath,
-		"filter_server_names", w.config.NameFilter.Names,
-		"filter_package_types", w.config.PackageFilter.Types,
-		"filter_transport_types", w.config.TransportFilter.Types,
+		"schedule", w.config.pollSchedule().String(),
+		"poll_jitter", w.config.PollJitter,
+		"quiet_windows", fmt.Sprint(w.config.QuietWindows),
+		"state_store", w.config.Store.String(),
+		"registries", registryNames(w.config.Registries),
 	)
 
-	// Start the polling timer
-	ticker := time.NewTicker(time.Duration(w.config.PollInterval) * time.Second)
-	defer ticker.Stop()
+	w.status.started(time.Now())
 
-	// Initial poll before entering the loop
-	if err := w.poll(ctx); err != nil {
-		slog.Error("initial poll failed", "error", err)
+	// Retries of failed generations due before the next scheduled poll get an extra poll
+	retryTimer := time.NewTimer(0)
+	retryTimer.Stop()
+	defer retryTimer.Stop()
+
+	// Polls are scheduled from the previous scheduled time rather than the end of the poll, so
+	// they don't drift, with jitter added to each. Without a cron schedule the first poll is
+	// on startup.
+	scheduled := time.Now()
+	if w.config.Schedule != nil {
+		scheduled = w.config.Schedule.Next(scheduled)
+	}
+	nextPoll := scheduled.Add(schedule.Jitter(w.config.PollJitter))
+	pollTimer := time.NewTimer(time.Until(nextPoll))
+	defer pollTimer.Stop()
+	w.status.setNextPoll(nextPoll)
+
+	reschedule := func(from time.Time) {
+		scheduled = w.config.pollSchedule().Next(from)
+		nextPoll = scheduled.Add(schedule.Jitter(w.config.PollJitter))
+		pollTimer.Reset(time.Until(nextPoll))
+		w.status.setNextPoll(nextPoll)
 	}
 
 	// Main polling loop, exits on context cancellation
 	for {
+		pollConfig := w.config
+
 		select {
 		case <-ctx.Done():
 			slog.Info("watch mode stopped")
@@ -56,60 +90,308 @@ func (w *Watcher) Run(ctx context.Context) error {
 				return ErrGracefulShutdown
 			}
 			return ctx.Err()
-		case <-ticker.C:
-			if err := w.poll(ctx); err != nil {
-				slog.Error("poll failed", "error", err)
+		case <-pollTimer.C:
+			w.scheduledPoll(ctx)
+			// Polls missed while this one ran are skipped rather than run back to back
+			from := scheduled
+			if w.config.pollSchedule().Next(from).Before(time.Now()) {
+				from = time.Now()
+			}
+			reschedule(from)
+		case <-retryTimer.C:
+			slog.Info("retrying failed pack generations")
+			w.scheduledPoll(ctx)
+		case <-w.trigger:
+			slog.Info("immediate poll requested")
+			w.scheduledPoll(ctx)
+			// Restart the interval from the end of the requested poll; cron schedules are unaffected
+			if w.config.Schedule == nil {
+				reschedule(time.Now())
+			}
+		}
+
+		// A reloaded schedule applies from the end of the poll that applied it
+		if previous := pollConfig.pollSchedule().String(); previous != w.config.pollSchedule().String() || pollConfig.PollJitter != w.config.PollJitter {
+			slog.Info("poll schedule changed",
+				"previous", previous,
+				"schedule", w.config.pollSchedule().String(),
+				"poll_jitter", w.config.PollJitter,
+			)
+			reschedule(time.Now())
+		}
+
+		w.scheduleRetry(retryTimer, nextPoll)
+	}
+}
+
+// scheduledPoll runs a poll cycle of the watch daemon, unless it falls in a quiet window
+func (w *Watcher) scheduledPoll(ctx context.Context) {
+	w.applyPendingConfig()
+
+	now := time.Now()
+	if until, ok := w.quietUntil(now); ok {
+		output.Info("Skipping poll during quiet window until %s", until.Format(time.DateTime))
+		slog.Info("skipping poll during quiet window", "quiet_until", until)
+		w.status.pollSkipped(now, until)
+		metrics.PollsSkipped.Inc()
+		return
+	}
+
+	// Every poll cycle of the daemon gets its own correlation ID
+	ctx = utils.WithRequestID(ctx, tracing.NewRequestID())
+	if _, err := w.runPoll(ctx); err != nil {
+		slog.ErrorContext(ctx, "poll failed", "error", err)
+	}
+}
+
+// quietUntil returns when the quiet windows containing t end, following on through
+// windows that start as an earlier one ends
+func (w *Watcher) quietUntil(t time.Time) (time.Time, bool) {
+	until := t
+
+	// Bounded, as windows covering the whole week never end
+	for range 7 * (len(w.config.QuietWindows) + 1) {
+		var quiet bool
+		for _, window := range w.config.QuietWindows {
+			if window.Contains(until) {
+				until = window.End(until)
+				quiet = true
 			}
 		}
+		if !quiet {
+			break
+		}
+	}
+
+	return until, until.After(t)
+}
+
+// Reload replaces the watcher's configuration from its next poll cycle. The state store
+// and git settings are kept, as they can't change while the watcher is running.
+func (w *Watcher) Reload(cfg *WatcherConfig) {
+	w.configMu.Lock()
+	defer w.configMu.Unlock()
+
+	w.pendingConfig = cfg
+}
+
+// applyPendingConfig switches to the configuration passed to Reload, if any. It is only
+// called between poll cycles, so a cycle never sees a mix of old and new settings.
+func (w *Watcher) applyPendingConfig() {
+	w.configMu.Lock()
+	defer w.configMu.Unlock()
+
+	cfg := w.pendingConfig
+	if cfg == nil {
+		return
+	}
+
+	cfg.Store = w.config.Store
+	cfg.Git = w.config.Git
+	cfg.GitCommitMode = w.config.GitCommitMode
+	w.config = cfg
+	w.pendingConfig = nil
+
+	slog.Info("applied reloaded configuration",
+		"poll_interval", cfg.PollInterval,
+		"max_concurrent", cfg.MaxConcurrent,
+		"allow_deprecated", cfg.AllowDeprecated,
+		"registries", registryNames(cfg.Registries),
+	)
+}
+
+// RunOnce performs a single poll cycle, saving state before returning a summary of the cycle
+func (w *Watcher) RunOnce(ctx context.Context) (*PollSummary, error) {
+	slog.Info("starting one-shot poll",
+		"state_store", w.config.Store.String(),
+		"registries", registryNames(w.config.Registries),
+	)
+
+	w.status.started(time.Now())
+
+	return w.runPoll(ctx)
+}
+
+// scheduleRetry arms the retry timer for the earliest pending retry if it falls before the next poll
+func (w *Watcher) scheduleRetry(timer *time.Timer, nextPoll time.Time) {
+	timer.Stop()
+
+	next, ok := w.state.NextRetry()
+	if !ok {
+		return
+	}
+
+	// Retries wait for the end of a quiet window the last poll was skipped in
+	if quietUntil := w.status.snapshot().QuietUntil; next.Before(quietUntil) {
+		next = quietUntil
+	}
+	if !next.Before(nextPoll) {
+		return
+	}
+
+	delay := max(time.Until(next), 0)
+	slog.Debug("scheduling retry of failed pack generations", "retry_at", next)
+	timer.Reset(delay)
+}
+
+// TriggerPoll requests an immediate poll cycle; requests made while a poll is pending are coalesced
+func (w *Watcher) TriggerPoll() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// RetryFailures clears recently failed generations, resets their retry backoff
+// and requests an immediate poll to retry them. Quarantined packs are not retried.
+func (w *Watcher) RetryFailures() {
+	w.status.clearFailures()
+	if n := w.state.ResetBackoff(); n > 0 {
+		slog.Info("reset retry backoff for failed packs", "count", n)
 	}
+	w.TriggerPoll()
+}
+
+// CheckState verifies the state store is usable
+func (w *Watcher) CheckState(ctx context.Context) error {
+	w.configMu.RLock()
+	store := w.config.Store
+	w.configMu.RUnlock()
+
+	return store.Check(ctx)
+}
+
+// Status returns a snapshot of the watcher's activity and state
+func (w *Watcher) Status() Status {
+	w.configMu.RLock()
+	cfg := w.config
+	w.configMu.RUnlock()
+
+	status := w.status.snapshot()
+	status.PollInterval = time.Duration(cfg.PollInterval) * time.Second
+	status.Schedule = cfg.pollSchedule()
+	status.PollJitter = cfg.PollJitter
+	status.StateFile = cfg.Store.String()
+	status.StateServers, status.StateFailed, status.StateQuarantined = w.state.Summary()
+	return status
 }
 
-func (w *Watcher) poll(ctx context.Context) error {
+func (w *Watcher) runPoll(ctx context.Context) (*PollSummary, error) {
+	w.applyPendingConfig()
+
+	ctx = tracing.EnsureRequestID(ctx)
+	ctx, span := tracing.Start(ctx, "watch poll", attribute.Bool("dry_run", w.generateOpts.DryRun))
+
+	start := time.Now()
+	w.status.pollStarted(start)
+	summary := newPollSummary(start, w.generateOpts.DryRun)
+	summary.RequestID, _ = utils.GetRequestID(ctx)
+	quarantined := w.state.Quarantined()
+	err := w.poll(ctx, summary)
+	summary.finish(err, quarantined, w.state.Quarantined())
+	w.status.pollFinished(err)
+	metrics.ObservePoll(start, err)
+
+	span.SetAttributes(
+		attribute.Int("servers_fetched", summary.ServersFetched),
+		attribute.Int("attempted", summary.Attempted),
+		attribute.Int("generated", len(summary.Generated)),
+		attribute.Int("failed", len(summary.Failed)),
+	)
+	tracing.End(span, err)
+
+	return summary, err
+}
+
+func (w *Watcher) poll(ctx context.Context, summary *PollSummary) error {
 	startTime := time.Now()
 	output.Progress("Starting poll cycle...")
-	slog.Info("starting poll cycle", "start_time", startTime.Format(time.RFC3339))
-	slog.Debug("poll cycle starting", "state_servers_count", len(w.state.Servers))
-
-	// Fetch servers from the registry, applying name filters if provided
-	servers, err := w.fetchServers(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to fetch servers: %w", err)
+	slog.InfoContext(ctx, "starting poll cycle", "start_time", startTime.Format(time.RFC3339))
+	slog.DebugContext(ctx, "poll cycle starting", "state_servers_count", len(w.state.Servers))
+
+	// Fetch servers from the registries, applying name filters if provided. An interrupted
+	// pagination still lets the poll process the servers fetched so far, if there are any.
+	servers, fetchErr := w.fetchServers(ctx)
+	var pageErr *registry.PaginationError
+	if fetchErr != nil && (!errors.As(fetchErr, &pageErr) || len(servers) == 0) {
+		return fmt.Errorf("failed to fetch servers: %w", fetchErr)
+	}
+	if fetchErr != nil {
+		output.Warning("Could not fetch all servers from registry, continuing with %d fetched: %v", len(servers), fetchErr)
+		slog.WarnContext(ctx, "could not fetch all servers from registry, continuing with partial results", "count", len(servers), "error", fetchErr)
 	}
 
 	output.Info("Fetched %d servers from registry", len(servers))
-	slog.Info("watcher poll cycle; fetched servers from registry", "count", len(servers))
+	slog.InfoContext(ctx, "watcher poll cycle; fetched servers from registry", "count", len(servers))
 
 	// Figure out which servers need packs generated based on filters and state
-	toGenerate := w.filterServers(servers)
+	toGenerate := w.filterServers(ctx, servers)
+	w.status.setFetched(len(servers), len(toGenerate))
+	summary.ServersFetched = len(servers)
+	summary.Attempted = len(toGenerate)
+	metrics.ServersFetched.Set(float64(len(servers)))
+	metrics.TasksNeeded.Set(float64(len(toGenerate)))
 	if len(toGenerate) == 0 {
 		output.Info("No packs need generation")
-		slog.Debug("no packs need generation")
+		slog.DebugContext(ctx, "no packs need generation")
+
+		// Packs whose commit failed, in an earlier cycle or before a restart, are still committed
+		var commitErr error
+		if w.commitsPending() {
+			lock, err := generator.LockOutputDir(ctx, w.generateOpts.OutputDir, w.config.OutputLockTimeout)
+			if err != nil {
+				return err
+			}
+			commitErr = w.commitPendingPacks(ctx, summary)
+			lock.Release()
+		}
+
 		w.state.UpdateLastPoll(startTime)
-		return w.state.SaveState(w.config.StateFilePath)
+		if err := w.state.SaveState(context.WithoutCancel(ctx), w.config.Store); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		w.runPostPollHooks(ctx, startTime, len(servers), 0, 0)
+
+		return incompleteError(fetchErr, commitErr)
 	}
 
 	output.Info("%d packs need generation", len(toGenerate))
-	slog.Info("watcher poll cycle; packs need generation", "count", len(toGenerate))
+	slog.InfoContext(ctx, "watcher poll cycle; packs need generation", "count", len(toGenerate))
+
+	// A manual generate writing into the output directory is waited for, and holds off
+	// until the packs generated here are committed
+	if !w.generateOpts.DryRun {
+		lock, err := generator.LockOutputDir(ctx, w.generateOpts.OutputDir, w.config.OutputLockTimeout)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+	}
 
 	// Generate packs
-	successCount, generateErr := w.generatePacks(ctx, toGenerate)
+	successCount, generateErr := w.generatePacks(ctx, toGenerate, summary)
 
-	// Always update and save state, even if some generations failed
+	// Committed before the state is saved, so packs whose commit fails stay pending in state
+	commitErr := w.commitPendingPacks(ctx, summary)
+
+	// Always update and save state, even if some generations failed or the watcher is shutting down
 	w.state.UpdateLastPoll(startTime)
-	slog.Debug("poll cycle saving state", "state_servers_count", len(w.state.Servers))
-	if err := w.state.SaveState(w.config.StateFilePath); err != nil {
+	slog.DebugContext(ctx, "poll cycle saving state", "state_servers_count", len(w.state.Servers))
+	if err := w.state.SaveState(context.WithoutCancel(ctx), w.config.Store); err != nil {
 		return fmt.Errorf("failed to save state: %w", err)
 	}
-	slog.Debug("poll cycle state saved", "state_servers_count", len(w.state.Servers))
+	slog.DebugContext(ctx, "poll cycle state saved", "state_servers_count", len(w.state.Servers))
+
+	w.runPostPollHooks(ctx, startTime, len(servers), len(toGenerate), successCount)
 
 	// If there were critical errors during generation, log, wrap, and return them
 	var packGenerationErrors *PackGenerationErrors
 	if errors.As(generateErr, &packGenerationErrors) {
 		for _, genErr := range packGenerationErrors.CriticalErrs {
-			slog.Error("pack generation failed", "error", genErr)
+			slog.ErrorContext(ctx, "pack generation failed", "error", genErr)
 		}
 
-		return fmt.Errorf("failure during poll cycle; %w", packGenerationErrors)
+		return fmt.Errorf("failure during poll cycle; %w", errors.Join(packGenerationErrors, commitErr))
 	}
 
 	// Report summary of the poll cycle
@@ -118,39 +400,131 @@ func (w *Watcher) poll(ctx context.Context) error {
 	} else {
 		output.Info("Poll cycle completed (%v, %d packs generated)", time.Since(startTime).Round(time.Second), successCount)
 	}
-	slog.Info("poll cycle completed", "duration", time.Since(startTime), "generated", successCount, "total_attempted", len(toGenerate))
+	slog.InfoContext(ctx, "poll cycle completed", "duration", time.Since(startTime), "generated", successCount, "total_attempted", len(toGenerate))
 
+	return incompleteError(fetchErr, commitErr)
+}
+
+// incompleteError wraps the errors that left the poll cycle incomplete in ErrPollIncomplete,
+// returning nil when there are none
+func incompleteError(errs ...error) error {
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w; %w", ErrPollIncomplete, err)
+	}
 	return nil
 }
 
-func (w *Watcher) fetchServers(ctx context.Context) ([]v0.ServerResponse, error) {
+func (w *Watcher) runPostPollHooks(ctx context.Context, startTime time.Time, fetched, attempted, succeeded int) {
+	if w.generateOpts.DryRun {
+		return
+	}
+
+	payload := hooks.Payload{
+		Poll: &hooks.PollInfo{
+			StartedAt:      startTime,
+			DurationMillis: time.Since(startTime).Milliseconds(),
+			ServersFetched: fetched,
+			Attempted:      attempted,
+			Succeeded:      succeeded,
+			Failed:         attempted - succeeded,
+		},
+	}
+
+	if err := w.config.Hooks.Run(ctx, hooks.EventPostPoll, payload); err != nil {
+		slog.WarnContext(ctx, "post_poll hook failed", "error", err)
+	}
+}
+
+// fetchServers fetches the servers matching each registry's name filter, in precedence order.
+// A server name served by a registry shadows the same name in registries after it. As a
+// shadowed server could otherwise be taken from the wrong registry, registries after one
+// that could not be fetched completely are skipped.
+func (w *Watcher) fetchServers(ctx context.Context) ([]registryServer, error) {
+	var allServers []registryServer
+
+	// Server names mapped to the registry that serves them
+	owners := make(map[string]string)
+
+	for i, source := range w.config.Registries {
+		servers, err := w.fetchRegistryServers(ctx, source)
+		var pageErr *registry.PaginationError
+		if err != nil && !errors.As(err, &pageErr) {
+			return nil, fmt.Errorf("failed to fetch servers from registry %s: %w", source.Name, err)
+		}
+
+		served := make(map[string]bool)
+		for _, serverResp := range servers {
+			name := serverResp.Server.Name
+			if !source.NameFilter.Matches(name) {
+				continue
+			}
+			if owner, ok := owners[name]; ok {
+				slog.DebugContext(ctx, "server shadowed by a registry with higher precedence, skipping",
+					"server", name,
+					"registry", source.Name,
+					"served_by", owner,
+				)
+				continue
+			}
+			served[name] = true
+			allServers = append(allServers, registryServer{source: source, response: serverResp})
+		}
+		for name := range served {
+			owners[name] = source.Name
+		}
+
+		slog.DebugContext(ctx, "fetched servers from registry", "registry", source.Name, "count", len(servers))
+
+		if err != nil {
+			if skipped := w.config.Registries[i+1:]; len(skipped) > 0 {
+				slog.WarnContext(ctx, "skipping registries with lower precedence after incomplete fetch",
+					"registry", source.Name,
+					"skipped", registryNames(skipped),
+				)
+			}
+			return allServers, fmt.Errorf("failed to fetch all servers from registry %s: %w", source.Name, err)
+		}
+	}
+
+	return allServers, nil
+}
+
+// fetchRegistryServers fetches the servers of a single registry, by name if it has a name filter
+func (w *Watcher) fetchRegistryServers(ctx context.Context, source *RegistrySource) ([]v0.ServerResponse, error) {
 	// Fetch by name if name filter provided
-	if len(w.config.NameFilter.Names) > 0 {
-		return w.fetchServersByName(ctx)
+	if len(source.NameFilter.Names) > 0 {
+		return w.fetchServersByName(ctx, source)
 	}
 
 	// Fetch all servers if no name filter provided
 	opts := &mcp.ServerListOptions{}
-	return w.listAllServers(ctx, opts)
+	return source.Client.ListAllServers(ctx, opts)
 }
 
-func (w *Watcher) fetchServersByName(ctx context.Context) ([]v0.ServerResponse, error) {
+func (w *Watcher) fetchServersByName(ctx context.Context, source *RegistrySource) ([]v0.ServerResponse, error) {
 	var allServers []v0.ServerResponse
 
+	var fetchErr error
+
 	// Track seen servers to manage deduplication
 	seenServers := make(map[string]bool)
 
 	// Fetch servers by exact name for each name filter
-	for _, nameFilter := range w.config.NameFilter.Names {
-		slog.Debug("fetching servers by name", "name", nameFilter)
+	for _, nameFilter := range source.NameFilter.Names {
+		slog.DebugContext(ctx, "fetching servers by name", "registry", source.Name, "name", nameFilter)
 
 		opts := &mcp.ServerListOptions{
 			Search: nameFilter,
 		}
 
-		servers, err := w.listAllServers(ctx, opts)
+		servers, err := source.Client.ListAllServers(ctx, opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get servers by name %s: %w", nameFilter, err)
+			// Keep partial results from an interrupted pagination and carry on with the remaining names
+			var pageErr *registry.PaginationError
+			if !errors.As(err, &pageErr) {
+				return nil, fmt.Errorf("failed to get servers by name %s: %w", nameFilter, err)
+			}
+			fetchErr = errors.Join(fetchErr, fmt.Errorf("failed to get all servers by name %s: %w", nameFilter, err))
 		}
 
 		// Deduplicate server entries in case user provided duplicate name filters
@@ -164,76 +538,67 @@ func (w *Watcher) fetchServersByName(ctx context.Context) ([]v0.ServerResponse,
 		}
 	}
 
-	slog.Debug("fetch by name completed", "total_servers", len(allServers))
-
-	return allServers, nil
-}
-
-// listAllServers fetches all servers using pagination, returning ServerResponse objects
-func (w *Watcher) listAllServers(ctx context.Context, opts *mcp.ServerListOptions) ([]v0.ServerResponse, error) {
-	var allServers []v0.ServerResponse
-
-	for {
-		listResp, resp, err := w.client.Servers.List(ctx, opts)
-		if err != nil {
-			return nil, err
-		}
-
-		if listResp != nil {
-			allServers = append(allServers, listResp.Servers...)
-		}
-
-		// Check if there are more pages
-		if resp == nil || resp.NextCursor == "" {
-			break
-		}
+	slog.DebugContext(ctx, "fetch by name completed", "registry", source.Name, "total_servers", len(allServers))
 
-		// Set up for next page
-		if opts.ListOptions.Cursor == "" {
-			opts.ListOptions.Cursor = resp.NextCursor
-		} else {
-			opts.Cursor = resp.NextCursor
-		}
-	}
-
-	return allServers, nil
+	return allServers, fetchErr
 }
 
-func (w *Watcher) filterServers(servers []v0.ServerResponse) []ServerGenerateTask {
+func (w *Watcher) filterServers(ctx context.Context, servers []registryServer) []ServerGenerateTask {
 	var tasks []ServerGenerateTask
 
-	for _, serverResp := range servers {
-		srv := serverResp.Server
+	for _, fetched := range servers {
+		source := fetched.source
+		srv := fetched.response.Server
 
 		nameSpec, err := server.ParseNameSpec(srv.Name)
 		if err != nil {
-			slog.Warn("invalid server name format found during watcher filtering, skipping", "name", srv.Name, "error", err)
+			slog.WarnContext(ctx, "invalid server name format found during watcher filtering, skipping", "name", srv.Name, "error", err)
 			continue
 		}
 
 		namespace := nameSpec.Namespace
 		name := nameSpec.Name
 
-		// Check against provided name filter
-		if !w.config.NameFilter.Matches(srv.Name) {
-			slog.Debug("polled server does not match name filter, skipping", "server", srv.Name)
-			continue
-		}
-
 		// Skip remote-only servers
 		if len(srv.Packages) == 0 {
-			slog.Debug("polled server matched name filter but is remote-only (no packages defined), skipping",
+			slog.DebugContext(ctx, "polled server matched name filter but is remote-only (no packages defined), skipping",
 				"server", srv.Name,
 			)
 			continue
 		}
 
+		// Overridden package and transport preferences replace the filters, and only the most
+		// preferred package is generated
+		packages := srv.Packages
+		overrides := config.ResolveOverrides(w.config.Overrides, srv.Name)
+		preferred := len(overrides.PackageTypes) > 0 || len(overrides.TransportTypes) > 0
+		if preferred {
+			packageTypes := overrides.PackageTypes
+			if len(packageTypes) == 0 {
+				packageTypes = source.PackageFilter.Types
+			}
+			transportTypes := overrides.TransportTypes
+			if len(transportTypes) == 0 {
+				transportTypes = source.TransportFilter.Types
+			}
+
+			pkg, err := server.FindPreferredPackage(&srv, packageTypes, transportTypes)
+			if err != nil {
+				slog.DebugContext(ctx, "polled server has no package matching overridden preferences, skipping",
+					"server", srv.Name,
+					"error", err,
+				)
+				continue
+			}
+			packages = []model.Package{*pkg}
+		}
+
 		// Check against provided package type and transport type filters
 		// Then check if generation is needed based on state
-		for _, pkg := range srv.Packages {
+		for _, pkg := range packages {
 			// Check against provided package type filter
-			if !w.config.PackageFilter.Matches(pkg.RegistryType) {
-				slog.Debug("polled server does not match package type filter, skipping",
+			if !preferred && !source.PackageFilter.Matches(pkg.RegistryType) {
+				slog.DebugContext(ctx, "polled server does not match package type filter, skipping",
 					"server", srv.Name,
 					"type", pkg.RegistryType,
 				)
@@ -241,8 +606,8 @@ func (w *Watcher) filterServers(servers []v0.ServerResponse) []ServerGenerateTas
 			}
 
 			// Check against provided transport type filter
-			if !w.config.TransportFilter.Matches(pkg.Transport.Type) {
-				slog.Debug("polled server does not match transport type filter, skipping",
+			if !preferred && !source.TransportFilter.Matches(pkg.Transport.Type) {
+				slog.DebugContext(ctx, "polled server does not match transport type filter, skipping",
 					"server", srv.Name,
 					"package_type", pkg.RegistryType,
 					"transport_type", pkg.Transport.Type,
@@ -251,13 +616,15 @@ func (w *Watcher) filterServers(servers []v0.ServerResponse) []ServerGenerateTas
 			}
 
 			// Check if generation is needed based on state (or if force-overwrite is enabled)
-			if w.generateOpts.ForceOverwrite || w.state.NeedsGeneration(namespace, name, srv.Version, pkg.RegistryType, pkg.Transport.Type, time.Time{}) {
+			if w.generateOpts.ForceOverwrite || w.state.NeedsGeneration(source.Name, namespace, name, srv.Version, pkg.RegistryType, pkg.Transport.Type, time.Time{}) {
 				pkgCopy := pkg
 				tasks = append(tasks, ServerGenerateTask{
-					Server:  srv,
-					Package: &pkgCopy,
+					Registry: source,
+					Server:   srv,
+					Package:  &pkgCopy,
 				})
-				slog.Debug("server needs generation",
+				slog.DebugContext(ctx, "server needs generation",
+					"registry", source.Name,
 					"server", srv.Name,
 					"version", srv.Version,
 					"package_type", pkg.RegistryType,
@@ -270,14 +637,17 @@ func (w *Watcher) filterServers(servers []v0.ServerResponse) []ServerGenerateTas
 	return tasks
 }
 
-func (w *Watcher) generatePacks(ctx context.Context, tasks []ServerGenerateTask) (int, error) {
+func (w *Watcher) generatePacks(ctx context.Context, tasks []ServerGenerateTask, summary *PollSummary) (int, error) {
 	// Use semaphore for concurrency control
 	sem := newPackGenSemaphore(w.config.MaxConcurrent)
 	var wg sync.WaitGroup
 
-	// Channels to collect results from goroutines
-	failureChan := make(chan error, len(tasks))
-	successChan := make(chan string, len(tasks))
+	// Channel to collect results from goroutines
+	resultChan := make(chan generateResult, len(tasks))
+
+	for _, task := range tasks {
+		w.status.taskQueued(task)
+	}
 
 	// Start goroutines for each task
 	for _, task := range tasks {
@@ -285,45 +655,51 @@ func (w *Watcher) generatePacks(ctx context.Context, tasks []ServerGenerateTask)
 		go func(t ServerGenerateTask) {
 			defer wg.Done()
 
-			sem.Acquire()
-			defer sem.Release()
+			worker := sem.Acquire()
+			defer sem.Release(worker)
 
 			if ctx.Err() != nil {
+				w.status.taskFinished(t, ctx.Err())
 				return
 			}
 
+			w.status.taskStarted(t, worker)
+
 			// Generate the pack and send result to appropriate channel
-			if err := w.generatePack(ctx, t); err != nil {
-				failureChan <- fmt.Errorf("failed to generate %s@%s:%s:%s; %w",
-					t.Server.Name, t.Server.Version, t.Package.RegistryType, t.Package.Transport.Type, err)
-			} else {
-				successChan <- fmt.Sprintf("%s@%s:%s:%s", t.Server.Name, t.Server.Version, t.Package.RegistryType, t.Package.Transport.Type)
-			}
+			taskCtx, span := tracing.Start(ctx, "watch generation task",
+				attribute.String("registry", t.Registry.Name),
+				attribute.String("server", t.Server.Name),
+				attribute.String("version", t.Server.Version),
+				attribute.Int("worker", worker),
+			)
+			err := w.generatePack(taskCtx, t)
+			tracing.End(span, err)
+			w.status.taskFinished(t, err)
+			recordGeneration(t, err)
+			resultChan <- generateResult{task: t, err: err}
 		}(task)
 	}
 
-	// Wait for all goroutines to finish and then close result collection channels
+	// Wait for all goroutines to finish and then close the result collection channel
 	wg.Wait()
-	close(failureChan)
-	close(successChan)
+	close(resultChan)
 
 	// Store non-critical and critical (unexpected) errors separately for failure reporting
 	var nonCriticalErrs []error
 	var criticalErrs []error
+	var successCount int
 
-	for err := range failureChan {
-		if errors.Is(err, generator.ErrPackDirectoryExists) ||
-			errors.Is(err, generator.ErrPackArchiveExists) {
-			nonCriticalErrs = append(nonCriticalErrs, err)
-		} else {
-			criticalErrs = append(criticalErrs, err)
-		}
-	}
+	for r := range resultChan {
+		summary.record(r.task, w.taskOptions(r.task), r.err)
 
-	// Count successful generations for reporting
-	var successCount int
-	for range successChan {
-		successCount++
+		switch {
+		case r.err == nil:
+			successCount++
+		case packExists(r.err):
+			nonCriticalErrs = append(nonCriticalErrs, fmt.Errorf("failed to generate %s; %w", r.task, r.err))
+		default:
+			criticalErrs = append(criticalErrs, fmt.Errorf("failed to generate %s; %w", r.task, r.err))
+		}
 	}
 
 	// Determine if we return an error based on presence of critical errors
@@ -332,7 +708,7 @@ func (w *Watcher) generatePacks(ctx context.Context, tasks []ServerGenerateTask)
 	}
 
 	if len(nonCriticalErrs) > 0 {
-		slog.Warn("pack generation completed with noncritical errors", "errors", len(nonCriticalErrs))
+		slog.WarnContext(ctx, "pack generation completed with noncritical errors", "errors", len(nonCriticalErrs))
 	}
 
 	return successCount, nil
@@ -341,17 +717,15 @@ func (w *Watcher) generatePacks(ctx context.Context, tasks []ServerGenerateTask)
 func (w *Watcher) generatePack(ctx context.Context, task ServerGenerateTask) error {
 	serverName := task.Server.Name
 
-	slog.Info("generating pack",
+	slog.InfoContext(ctx, "generating pack",
+		"registry", task.Registry.Name,
 		"server", serverName,
 		"version", task.Server.Version,
 		"package_type", task.Package.RegistryType,
 		"transport_type", task.Package.Transport.Type,
 	)
 
-	genErr := generator.Run(ctx, &task.Server, task.Package, w.generateOpts)
-
 	// Parse server name for state tracking
-	now := time.Now()
 	nameSpec, err := server.ParseNameSpec(task.Server.Name)
 	if err != nil {
 		return fmt.Errorf("failed to parse server name: %w", err)
@@ -359,13 +733,61 @@ func (w *Watcher) generatePack(ctx context.Context, task ServerGenerateTask) err
 	namespace := nameSpec.Namespace
 	name := nameSpec.Name
 
-	// Update state even if generation failed with ErrPackDirectoryExists
-	// This prevents repeated attempts to generate the same pack
-	if genErr != nil && !errors.Is(genErr, generator.ErrPackDirectoryExists) {
-		return genErr
+	pack := &ServerState{
+		Registry:      task.Registry.Name,
+		Namespace:     namespace,
+		Name:          name,
+		Version:       task.Server.Version,
+		PackageType:   task.Package.RegistryType,
+		TransportType: task.Package.Transport.Type,
+	}
+	key := pack.Key()
+	previous, _ := w.state.GetServer(key)
+	previouslyFailed := previous != nil && previous.Failed
+
+	generateOpts := w.taskOptions(task)
+
+	// Packs aren't named by registry, so a pack generated from a registry that no longer
+	// takes precedence for this server is overwritten rather than left in place
+	replacedKeys := w.state.OtherRegistries(pack)
+	if len(replacedKeys) > 0 {
+		generateOpts.ForceOverwrite = true
+		slog.InfoContext(ctx, "pack was generated from another registry, regenerating it",
+			"registry", task.Registry.Name,
+			"server", serverName,
+			"replaced", replacedKeys,
+		)
+	}
+
+	// Hooks are skipped in dry run mode as no pack is written
+	runHooks := !generateOpts.DryRun
+	payload := hooks.Payload{
+		PackPath: generator.PackPath(&task.Server, task.Package, generateOpts),
+		Server:   hookServerInfo(task),
+	}
+
+	var genErr error
+	if runHooks && w.config.Hooks.Has(hooks.EventPreGenerate) {
+		w.status.taskPhase(task, TaskPhasePreHooks)
+		genErr = w.config.Hooks.Run(ctx, hooks.EventPreGenerate, payload)
+	}
+
+	if genErr == nil {
+		w.status.taskPhase(task, TaskPhaseGenerating)
+		genErr = generator.Run(ctx, &task.Server, task.Package, generateOpts)
 	}
 
+	// Run post-generate hooks for new packs, and retry them for existing packs whose hooks previously failed
+	if runHooks && (genErr == nil || (packExists(genErr) && previouslyFailed)) && w.config.Hooks.Has(hooks.EventPostGenerate) {
+		w.status.taskPhase(task, TaskPhasePostHooks)
+		if hookErr := w.config.Hooks.Run(ctx, hooks.EventPostGenerate, payload); hookErr != nil {
+			genErr = hookErr
+		}
+	}
+
+	now := time.Now()
 	state := &ServerState{
+		Registry:      task.Registry.Name,
 		Namespace:     namespace,
 		Name:          name,
 		Version:       task.Server.Version,
@@ -373,11 +795,45 @@ func (w *Watcher) generatePack(ctx context.Context, task ServerGenerateTask) err
 		TransportType: task.Package.Transport.Type,
 		UpdatedAt:     now,
 		GeneratedAt:   now,
+		Checksum:      serverChecksum(&task.Server),
+		// An existing pack keeps a commit that is still pending
+		CommitPending: w.config.Git != nil && !generateOpts.DryRun && (genErr == nil || (previous != nil && previous.CommitPending)),
+	}
+
+	// Update state even if generation failed because the pack exists
+	// This prevents repeated attempts to generate the same pack
+	if genErr != nil && !packExists(genErr) {
+		// Failures are recorded so the pack is retried with backoff, unless the watcher is shutting down
+		if ctx.Err() == nil {
+			failed := w.state.RecordFailure(state, genErr, w.config.Retry)
+			if failed.Quarantined {
+				output.Warning("Quarantined %s after %d consecutive failures", task, failed.Attempts)
+				slog.WarnContext(ctx, "pack quarantined after repeated failures",
+					"key", key,
+					"attempts", failed.Attempts,
+					"error", genErr,
+				)
+			}
+		}
+
+		if runHooks && w.config.Hooks.Has(hooks.EventOnFailure) {
+			w.status.taskPhase(task, TaskPhaseFailureHooks)
+			payload.Error = genErr.Error()
+			if err := w.config.Hooks.Run(ctx, hooks.EventOnFailure, payload); err != nil {
+				slog.WarnContext(ctx, "on_failure hook failed", "server", serverName, "error", err)
+			}
+		}
+		return genErr
 	}
+
 	w.state.SetServer(state)
 
+	if genErr == nil && !generateOpts.DryRun {
+		w.state.Forget(replacedKeys)
+	}
+
 	if genErr != nil {
-		slog.Info("pack directory already exists, state updated to prevent regeneration",
+		slog.InfoContext(ctx, "pack already exists, state updated to prevent regeneration",
 			"server", serverName,
 			"version", task.Server.Version,
 			"package_type", task.Package.RegistryType,
@@ -386,12 +842,136 @@ func (w *Watcher) generatePack(ctx context.Context, task ServerGenerateTask) err
 		return genErr // Still return error for non-critical error tracking
 	}
 
-	slog.Info("pack generated successfully",
+	slog.InfoContext(ctx, "pack generated successfully",
 		"server", serverName,
 		"version", task.Server.Version,
 		"package_type", task.Package.RegistryType,
 		"transport_type", task.Package.Transport.Type,
 	)
 
+	// Otherwise the pack is committed with the others at the end of the poll cycle
+	if state.CommitPending && w.config.GitCommitMode == config.GitCommitModeGeneration {
+		w.status.taskPhase(task, TaskPhaseCommitting)
+		entry := w.commitEntry(state)
+		if err := w.config.Git.Commit(ctx, []gitrepo.Entry{entry}); err != nil {
+			// The pack stays pending, so the commit is retried at the end of the poll cycle
+			output.Warning("Failed to commit pack %s: %v", entry, err)
+			slog.ErrorContext(ctx, "failed to commit pack", "pack", entry.PackName, "error", err)
+		} else {
+			w.state.MarkCommitted([]string{key})
+		}
+	}
+
+	return nil
+}
+
+// taskOptions returns the generator options for a task, recording the registry its server came from
+func (w *Watcher) taskOptions(task ServerGenerateTask) generator.Options {
+	opts := w.generateOpts
+	opts.RegistryName = task.Registry.Name
+	opts.RegistryURL = task.Registry.URL
+	opts.Overrides = config.ResolveOverrides(w.config.Overrides, task.Server.Name)
+	return opts
+}
+
+// registryNames returns the names of the registries in precedence order
+func registryNames(sources []*RegistrySource) []string {
+	names := make([]string, 0, len(sources))
+	for _, source := range sources {
+		names = append(names, source.Name)
+	}
+	return names
+}
+
+// serverChecksum returns the SHA-256 checksum of the server definition a pack is generated from
+func serverChecksum(srv *v0.ServerJSON) string {
+	data, err := json.Marshal(srv)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// packExists reports whether generation was skipped because the pack directory, archive or jobspec already exists
+func packExists(err error) bool {
+	return errors.Is(err, generator.ErrPackDirectoryExists) || errors.Is(err, generator.ErrPackArchiveExists) || errors.Is(err, generator.ErrJobspecExists)
+}
+
+// recordGeneration counts a finished generation task by result
+func recordGeneration(task ServerGenerateTask, err error) {
+	result := metrics.ResultSuccess
+	switch {
+	case packExists(err):
+		result = metrics.ResultSkipped
+	case err != nil:
+		result = metrics.ResultFailure
+	}
+
+	metrics.Generations.WithLabelValues(result, task.Package.RegistryType, utils.MapFromRegistryTransportType(task.Package.Transport.Type)).Inc()
+}
+
+// commitsPending reports whether packs are waiting to be committed
+func (w *Watcher) commitsPending() bool {
+	return w.config.Git != nil && !w.generateOpts.DryRun && len(w.state.PendingCommits()) > 0
+}
+
+// commitPendingPacks commits the packs recorded in state as generated but not committed,
+// including those whose commit failed in an earlier poll cycle or before a restart.
+// A failure is recorded in the summary and the packs stay pending for the next cycle.
+func (w *Watcher) commitPendingPacks(ctx context.Context, summary *PollSummary) error {
+	if !w.commitsPending() {
+		return nil
+	}
+
+	pending := w.state.PendingCommits()
+	entries := make([]gitrepo.Entry, 0, len(pending))
+	keys := make([]string, 0, len(pending))
+	for _, server := range pending {
+		entries = append(entries, w.commitEntry(server))
+		keys = append(keys, server.Key())
+	}
+
+	if err := w.config.Git.Commit(ctx, entries); err != nil {
+		output.Warning("Failed to commit %d packs: %v", len(entries), err)
+		slog.ErrorContext(ctx, "failed to commit packs", "packs", len(entries), "error", err)
+
+		err = fmt.Errorf("failed to commit %d packs: %w", len(entries), err)
+		summary.GitError = err.Error()
+		return err
+	}
+
+	w.state.MarkCommitted(keys)
+	output.Success("Committed %d packs to git", len(entries))
+
 	return nil
 }
+
+// commitEntry describes the pack of a server in state for committing to the git repository
+func (w *Watcher) commitEntry(server *ServerState) gitrepo.Entry {
+	srv := &v0.ServerJSON{Name: server.Namespace + "/" + server.Name, Version: server.Version}
+	pkg := &model.Package{RegistryType: server.PackageType, Transport: model.Transport{Type: server.TransportType}}
+
+	return gitrepo.Entry{
+		ServerName:    srv.Name,
+		ServerVersion: srv.Version,
+		PackageType:   server.PackageType,
+		TransportType: utils.MapFromRegistryTransportType(server.TransportType),
+		PackName:      generator.PackName(srv, pkg),
+		PackPath:      generator.PackPath(srv, pkg, w.generateOpts),
+	}
+}
+
+func hookServerInfo(task ServerGenerateTask) *hooks.ServerInfo {
+	return &hooks.ServerInfo{
+		Registry:          task.Registry.Name,
+		Name:              task.Server.Name,
+		Version:           task.Server.Version,
+		Description:       task.Server.Description,
+		PackageType:       task.Package.RegistryType,
+		TransportType:     utils.MapFromRegistryTransportType(task.Package.Transport.Type),
+		PackageIdentifier: task.Package.Identifier,
+		PackageVersion:    task.Package.Version,
+	}
+}
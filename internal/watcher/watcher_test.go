@@ -0,0 +1,365 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/generator"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/gitrepo"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/hooks"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/registry"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/schedule"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/statestore"
+	v0 "github.com/modelcontextprotocol/registry/pkg/api/v0"
+	"github.com/modelcontextprotocol/registry/pkg/model"
+)
+
+// newTestSource serves the given server names, each at version 1.0.0, as a single page
+func newTestSource(t *testing.T, name string, servers ...string) *RegistrySource {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var list []map[string]any
+		for _, server := range servers {
+			list = append(list, map[string]any{"server": map[string]any{"name": server, "version": "1.0.0"}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"servers": list, "metadata": map[string]any{"count": len(list)}})
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := registry.New(srv.URL+"/", "", config.RegistryConfig{Timeout: 5})
+	if err != nil {
+		t.Fatalf("registry.New() unexpected error = %v", err)
+	}
+
+	return &RegistrySource{
+		Name:            name,
+		URL:             srv.URL,
+		Client:          client,
+		NameFilter:      &ServerNameFilter{},
+		PackageFilter:   &PackageTypeFilter{},
+		TransportFilter: &TransportTypeFilter{},
+	}
+}
+
+func TestFetchServersPrecedence(t *testing.T) {
+	internal := newTestSource(t, "internal", "io.github.example/weather-mcp", "io.github.example/internal-mcp")
+	public := newTestSource(t, "default", "io.github.example/weather-mcp", "io.github.example/public-mcp")
+
+	// Servers excluded by a registry's name filter don't shadow registries after it
+	filtered := newTestSource(t, "filtered", "io.github.example/public-mcp")
+	filtered.NameFilter = &ServerNameFilter{Names: []string{"io.github.example/other-mcp"}}
+
+	w := &Watcher{config: &WatcherConfig{Registries: []*RegistrySource{internal, filtered, public}}}
+
+	servers, err := w.fetchServers(context.Background())
+	if err != nil {
+		t.Fatalf("fetchServers() unexpected error = %v", err)
+	}
+
+	expected := map[string]string{
+		"io.github.example/weather-mcp":  "internal",
+		"io.github.example/internal-mcp": "internal",
+		"io.github.example/public-mcp":   "default",
+	}
+
+	if len(servers) != len(expected) {
+		t.Fatalf("fetchServers() returned %d servers, expected %d", len(servers), len(expected))
+	}
+	for _, s := range servers {
+		if registry := expected[s.response.Server.Name]; s.source.Name != registry {
+			t.Errorf("server %s fetched from %s, expected %s", s.response.Server.Name, s.source.Name, registry)
+		}
+	}
+}
+
+func TestQuietUntil(t *testing.T) {
+	var windows []schedule.QuietWindow
+	for _, spec := range []string{"22:00-24:00", "00:00-06:00", "12:00-13:00"} {
+		window, err := schedule.ParseQuietWindow(spec)
+		if err != nil {
+			t.Fatalf("ParseQuietWindow(%q) unexpected error = %v", spec, err)
+		}
+		windows = append(windows, window)
+	}
+
+	w := &Watcher{config: &WatcherConfig{QuietWindows: windows}}
+
+	// Windows that start as an earlier one ends are followed on
+	until, ok := w.quietUntil(time.Date(2026, 10, 16, 23, 0, 0, 0, time.Local))
+	if expected := time.Date(2026, 10, 17, 6, 0, 0, 0, time.Local); !ok || !until.Equal(expected) {
+		t.Errorf("quietUntil() = %v, %v, expected %v, true", until, ok, expected)
+	}
+
+	if _, ok := w.quietUntil(time.Date(2026, 10, 16, 9, 0, 0, 0, time.Local)); ok {
+		t.Error("quietUntil() expected no quiet window")
+	}
+}
+
+func TestFilterServersOverrides(t *testing.T) {
+	source := &RegistrySource{
+		Name:            "default",
+		NameFilter:      &ServerNameFilter{},
+		PackageFilter:   &PackageTypeFilter{Types: []string{"oci"}},
+		TransportFilter: &TransportTypeFilter{Types: []string{"stdio", "http"}},
+	}
+
+	newServer := func(name string) registryServer {
+		return registryServer{source: source, response: v0.ServerResponse{Server: v0.ServerJSON{
+			Name:    name,
+			Version: "1.0.0",
+			Packages: []model.Package{
+				{RegistryType: "oci", Identifier: name, Transport: model.Transport{Type: "stdio"}},
+				{RegistryType: "npm", Identifier: name, Transport: model.Transport{Type: "stdio"}},
+				{RegistryType: "npm", Identifier: name, Transport: model.Transport{Type: "streamable-http"}},
+			},
+		}}}
+	}
+
+	w := &Watcher{
+		state: NewWatchState(),
+		config: &WatcherConfig{Overrides: []config.OverrideConfig{
+			{Servers: []string{"io.github.example/weather-*"}, PackageTypes: []string{"npm", "oci"}, TransportTypes: []string{"http"}},
+		}},
+	}
+
+	tasks := w.filterServers(t.Context(), []registryServer{newServer("io.github.example/weather-mcp"), newServer("io.github.example/other-mcp")})
+
+	// The overridden server gets only its most preferred package, ignoring the filters
+	expected := map[string]string{
+		"io.github.example/weather-mcp": "npm:streamable-http",
+		"io.github.example/other-mcp":   "oci:stdio",
+	}
+	if len(tasks) != len(expected) {
+		t.Fatalf("filterServers() returned %d tasks, expected %d", len(tasks), len(expected))
+	}
+	for _, task := range tasks {
+		if got := task.Package.RegistryType + ":" + task.Package.Transport.Type; got != expected[task.Server.Name] {
+			t.Errorf("server %s package = %s, expected %s", task.Server.Name, got, expected[task.Server.Name])
+		}
+	}
+}
+
+func TestReloadAppliesAtNextCycle(t *testing.T) {
+	store := statestore.NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	source := &RegistrySource{Name: "default", NameFilter: &ServerNameFilter{}}
+
+	w := &Watcher{config: &WatcherConfig{Store: store, Registries: []*RegistrySource{source}, PollInterval: 300}}
+
+	reloaded := &RegistrySource{Name: "default", NameFilter: &ServerNameFilter{Names: []string{"io.github.example/*"}}}
+	w.Reload(&WatcherConfig{Registries: []*RegistrySource{reloaded}, PollInterval: 60})
+
+	// The running cycle keeps the current configuration
+	if w.config.PollInterval != 300 || w.config.Registries[0] != source {
+		t.Fatal("Reload() changed the configuration before the next cycle")
+	}
+
+	w.applyPendingConfig()
+
+	if w.config.PollInterval != 60 {
+		t.Errorf("poll interval = %d, expected 60", w.config.PollInterval)
+	}
+	if w.config.Registries[0] != reloaded {
+		t.Error("applyPendingConfig() kept the previous registry filters")
+	}
+	if w.config.Store != store {
+		t.Error("applyPendingConfig() replaced the state store")
+	}
+
+	// Without another reload the configuration is kept
+	current := w.config
+	w.applyPendingConfig()
+	if w.config != current {
+		t.Error("applyPendingConfig() changed the configuration without a reload")
+	}
+}
+
+func TestCommitPendingPacksRetriesFailedCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	for _, mode := range []config.GitCommitMode{config.GitCommitModeGeneration, config.GitCommitModePoll} {
+		t.Run(string(mode), func(t *testing.T) {
+			outputDir := t.TempDir()
+			remote := filepath.Join(t.TempDir(), "remote.git")
+			repo, err := gitrepo.Open(context.Background(), outputDir, config.GitConfig{
+				AuthorName:  "Test",
+				AuthorEmail: "test@example.com",
+				Push:        true,
+				Remote:      remote,
+			})
+			if err != nil {
+				t.Fatalf("gitrepo.Open() error = %v", err)
+			}
+
+			cfg := &WatcherConfig{
+				Store:         statestore.NewFileStore(filepath.Join(t.TempDir(), "state.json")),
+				Registries:    []*RegistrySource{newTestSource(t, "default", "io.github.example/weather-mcp")},
+				Git:           repo,
+				GitCommitMode: mode,
+			}
+			opts := generator.Options{OutputDir: outputDir, OutputType: "packdir"}
+			w, err := NewWatcher(context.Background(), cfg, opts)
+			if err != nil {
+				t.Fatalf("NewWatcher() error = %v", err)
+			}
+
+			// The push fails as the remote doesn't exist, leaving the pack pending
+			task := testTask("io.github.example/weather-mcp")
+			task.Registry = &RegistrySource{Name: "default"}
+			if err := w.generatePack(context.Background(), task); err != nil {
+				t.Fatalf("generatePack() error = %v", err)
+			}
+			if pending := w.state.PendingCommits(); len(pending) != 1 {
+				t.Fatalf("pending commits = %d, expected the pack to be pending", len(pending))
+			}
+
+			// A poll with nothing to generate still retries the commit, reporting its failure
+			summary, err := w.RunOnce(context.Background())
+			if !errors.Is(err, ErrPollIncomplete) {
+				t.Fatalf("RunOnce() error = %v, expected ErrPollIncomplete", err)
+			}
+			if summary.Outcome != PollOutcomeNonCritical || summary.GitError == "" {
+				t.Errorf("summary outcome = %s, git error = %q, expected non-critical errors with the git error", summary.Outcome, summary.GitError)
+			}
+
+			// The pending commit is saved, so it is retried after a restart
+			if out, err := exec.Command("git", "init", "--bare", remote).CombinedOutput(); err != nil {
+				t.Fatalf("failed to create remote: %v: %s", err, out)
+			}
+
+			restarted, err := NewWatcher(context.Background(), cfg, opts)
+			if err != nil {
+				t.Fatalf("NewWatcher() error = %v", err)
+			}
+			summary, err = restarted.RunOnce(context.Background())
+			if err != nil {
+				t.Fatalf("RunOnce() after restart error = %v", err)
+			}
+			if summary.Outcome != PollOutcomeUnchanged || summary.GitError != "" {
+				t.Errorf("summary outcome = %s, git error = %q, expected unchanged", summary.Outcome, summary.GitError)
+			}
+			if pending := restarted.state.PendingCommits(); len(pending) != 0 {
+				t.Errorf("pending commits = %d, expected the pack to be committed", len(pending))
+			}
+		})
+	}
+}
+
+func TestGeneratePackRetriesFailedGeneration(t *testing.T) {
+	task := testTask("io.github.example/weather-mcp")
+	task.Package.Identifier = "ghcr.io/example/weather-mcp"
+	task.Registry = &RegistrySource{Name: "default"}
+
+	key := testServerState(time.Time{}).Key()
+
+	t.Run("failed generation", func(t *testing.T) {
+		w := &Watcher{
+			config:       &WatcherConfig{},
+			state:        NewWatchState(),
+			generateOpts: generator.Options{OutputDir: t.TempDir(), OutputType: "packdir"},
+			status:       newStatusTracker(),
+		}
+		w.state.RecordFailure(testServerState(time.Now()), errors.New("failed to generate job template"), RetryPolicy{})
+
+		if err := w.generatePack(context.Background(), task); err != nil {
+			t.Fatalf("generatePack() error = %v", err)
+		}
+
+		packPath := generator.PackPath(&task.Server, task.Package, w.generateOpts)
+		if _, err := os.Stat(filepath.Join(packPath, "templates", "_helpers.tpl")); err != nil {
+			t.Errorf("generatePack() did not write a complete pack; %v", err)
+		}
+		server, _ := w.state.GetServer(key)
+		if server == nil || server.Failed || server.Attempts != 0 {
+			t.Fatalf("state = %+v, expected a successful generation", server)
+		}
+		if server.Checksum != serverChecksum(&task.Server) || !strings.HasPrefix(server.Checksum, "sha256:") {
+			t.Errorf("state checksum = %q, expected the server definition's checksum", server.Checksum)
+		}
+	})
+
+	t.Run("failed post_generate hook", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "ready")
+		w := &Watcher{
+			config: &WatcherConfig{
+				Hooks: hooks.NewRunner(config.HooksConfig{
+					PostGenerate: []config.HookConfig{{Command: "test -f " + marker, FailGeneration: true}},
+				}),
+				Retry: RetryPolicy{QuarantineAfter: 3},
+			},
+			state:        NewWatchState(),
+			generateOpts: generator.Options{OutputDir: t.TempDir(), OutputType: "packdir"},
+			status:       newStatusTracker(),
+		}
+
+		var hookErr *hooks.HookError
+		if err := w.generatePack(context.Background(), task); !errors.As(err, &hookErr) {
+			t.Fatalf("generatePack() error = %v, expected a hook failure", err)
+		}
+		if server, _ := w.state.GetServer(key); server == nil || !server.Failed || server.Attempts != 1 {
+			t.Fatalf("state = %+v, expected a recorded failure", server)
+		}
+
+		// The existing pack isn't regenerated, but its post_generate hooks are retried
+		if err := os.WriteFile(marker, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.generatePack(context.Background(), task); !errors.Is(err, generator.ErrPackDirectoryExists) {
+			t.Fatalf("generatePack() retry error = %v, expected ErrPackDirectoryExists", err)
+		}
+		if server, _ := w.state.GetServer(key); server == nil || server.Failed {
+			t.Errorf("state = %+v, expected the retried hook to succeed", server)
+		}
+	})
+}
+
+func TestGeneratePackReplacesOtherRegistryPack(t *testing.T) {
+	w := &Watcher{
+		config:       &WatcherConfig{},
+		state:        NewWatchState(),
+		generateOpts: generator.Options{OutputDir: t.TempDir(), OutputType: "packdir"},
+		status:       newStatusTracker(),
+	}
+
+	public := testTask("io.github.example/weather-mcp")
+	public.Package.Identifier = "ghcr.io/example/weather-mcp"
+	public.Registry = &RegistrySource{Name: "default", URL: "https://registry.modelcontextprotocol.io"}
+
+	if err := w.generatePack(context.Background(), public); err != nil {
+		t.Fatalf("generatePack() error = %v", err)
+	}
+
+	// The server is now found in a registry that takes precedence, generating the same pack
+	internal := public
+	internal.Registry = &RegistrySource{Name: "internal", URL: "https://registry.example.com"}
+
+	if err := w.generatePack(context.Background(), internal); err != nil {
+		t.Fatalf("generatePack() error = %v, expected the pack to be regenerated", err)
+	}
+
+	metadata, err := os.ReadFile(filepath.Join(generator.PackPath(&internal.Server, internal.Package, w.generateOpts), "metadata.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(metadata), "internal MCP registry") {
+		t.Errorf("metadata.hcl = %s, expected the pack to be generated from the internal registry", metadata)
+	}
+
+	servers := w.state.Select(StateFilter{})
+	if len(servers) != 1 || servers[0].Registry != "internal" {
+		t.Errorf("state = %v, expected only the internal registry's server", servers)
+	}
+}
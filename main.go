@@ -4,10 +4,11 @@ import (
 	"os"
 
 	"github.com/leefowlercu/nomad-mcp-pack/cmd"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/exitcode"
 )
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitcode.FromError(err))
 	}
 }
@@ -0,0 +1,198 @@
+package packapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxErrorBody bounds how much of an error response is read for its message
+const maxErrorBody = 64 * 1024
+
+// Error is returned for responses with an error status
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("pack registry returned %d %s; %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound reports whether err is an API error for a pack or file that doesn't exist
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Client calls the pack registry API served by the server command
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests, e.g. one presenting a client certificate
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sets an API token sent as a bearer token with every request
+func WithToken(token string) ClientOption {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// NewClient creates a Client for the server at baseURL, such as http://localhost:8080
+func NewClient(baseURL string, opts ...ClientOption) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse pack registry URL; %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("could not parse pack registry URL; scheme must be http or https, got %q", u.Scheme)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	c := &Client{baseURL: u, httpClient: http.DefaultClient}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Health checks that the server is running
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := c.getJSON(ctx, "/healthz", &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// ListPacks lists the packs sorted by name, without their files
+func (c *Client) ListPacks(ctx context.Context) ([]Pack, error) {
+	var list PackList
+	if err := c.getJSON(ctx, "/v1/packs", &list); err != nil {
+		return nil, err
+	}
+	return list.Packs, nil
+}
+
+// GetPack returns a pack's details, including its files
+func (c *Client) GetPack(ctx context.Context, name string) (*Pack, error) {
+	var pack Pack
+	if err := c.getJSON(ctx, "/v1/packs/"+url.PathEscape(name), &pack); err != nil {
+		return nil, err
+	}
+	return &pack, nil
+}
+
+// DownloadPack returns a pack archive in the format, FormatZip or FormatTarGz. The caller
+// must close the returned reader.
+func (c *Client) DownloadPack(ctx context.Context, name, format string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, "/v1/packs/"+url.PathEscape(name)+"/download?format="+url.QueryEscape(format))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// GetFile returns the content of a file in a pack, by its path in the pack
+func (c *Client) GetFile(ctx context.Context, name, filePath string) ([]byte, error) {
+	segments := strings.Split(filePath, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	resp, err := c.get(ctx, "/v1/packs/"+url.PathEscape(name)+"/files/"+strings.Join(segments, "/"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack file; %w", err)
+	}
+	return data, nil
+}
+
+// OpenAPIDocument returns the server's OpenAPI document
+func (c *Client) OpenAPIDocument(ctx context.Context) (json.RawMessage, error) {
+	var doc json.RawMessage
+	if err := c.getJSON(ctx, "/openapi.json", &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode pack registry response; %w", err)
+	}
+	return nil
+}
+
+// get sends a GET request for the escaped path and query, returning the response when its status is 200
+func (c *Client) get(ctx context.Context, pathAndQuery string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL.String()+pathAndQuery, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create pack registry request; %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("pack registry request failed; %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+
+	return resp, nil
+}
+
+// responseError returns the error for a response, with the message from its JSON error body when it has one
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	message := strings.TrimSpace(string(body))
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/json" {
+		var errResp ErrorResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+			message = errResp.Error
+		}
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+
+	return &Error{StatusCode: resp.StatusCode, Message: message}
+}
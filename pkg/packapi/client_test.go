@@ -0,0 +1,156 @@
+package packapi_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/leefowlercu/nomad-mcp-pack/internal/config"
+	"github.com/leefowlercu/nomad-mcp-pack/internal/packregistry"
+	"github.com/leefowlercu/nomad-mcp-pack/pkg/packapi"
+)
+
+const testPack = "io-github-example-weather-mcp-1-0-0-npm-stdio"
+
+const testPackMetadata = `app {
+  url = "https://github.com/example/weather-mcp"
+}
+
+pack {
+  name        = "io-github-example-weather-mcp-1-0-0-npm-stdio"
+  description = "Weather forecasts for MCP clients"
+  version     = "1.0.0"
+}
+`
+
+// newTestServer serves an output directory with a single pack directory
+func newTestServer(t *testing.T, auth *packregistry.Authenticator) *httptest.Server {
+	t.Helper()
+
+	dir := t.TempDir()
+	packDir := filepath.Join(dir, testPack)
+	if err := os.MkdirAll(filepath.Join(packDir, "templates"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"metadata.hcl":                    testPackMetadata,
+		"templates/weather-mcp.nomad.tpl": "job \"weather-mcp\" {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(packDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv := httptest.NewServer(packregistry.NewHandler(packregistry.NewCatalog(dir, nil, ""), auth))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	client, err := packapi.NewClient(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+
+	health, err := client.Health(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Status != "ok" {
+		t.Errorf("health status = %q, expected ok", health.Status)
+	}
+
+	packs, err := client.ListPacks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packs) != 1 || packs[0].Name != testPack || packs[0].Version != "1.0.0" {
+		t.Errorf("packs = %+v, expected %s at version 1.0.0", packs, testPack)
+	}
+
+	pack, err := client.GetPack(ctx, testPack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(pack.Files, "templates/weather-mcp.nomad.tpl") {
+		t.Errorf("pack files = %v, expected the job template", pack.Files)
+	}
+
+	data, err := client.GetFile(ctx, testPack, "templates/weather-mcp.nomad.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "job \"weather-mcp\" {}\n" {
+		t.Errorf("file content = %q", data)
+	}
+
+	archive, err := client.DownloadPack(ctx, testPack, packapi.FormatTarGz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	gr, err := gzip.NewReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tar.NewReader(gr).Next(); err != nil {
+		t.Errorf("failed to read pack archive: %v", err)
+	}
+
+	if _, err := client.OpenAPIDocument(ctx); err != nil {
+		t.Errorf("failed to get OpenAPI document: %v", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	srv := newTestServer(t, packregistry.NewAuthenticator([]packregistry.Token{
+		{Name: "reader", Token: "read-token", Scopes: []config.ServerScope{config.ServerScopeRead}},
+	}, nil))
+	ctx := t.Context()
+
+	anonymous, err := packapi.NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = anonymous.ListPacks(ctx)
+	var apiErr *packapi.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("error = %v, expected a 401 API error", err)
+	}
+
+	client, err := packapi.NewClient(srv.URL, packapi.WithToken("read-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ListPacks(ctx); err != nil {
+		t.Errorf("failed to list packs with a token: %v", err)
+	}
+
+	_, err = client.GetPack(ctx, "missing-pack")
+	if !packapi.IsNotFound(err) {
+		t.Errorf("error = %v, expected not found", err)
+	}
+	_, err = client.GetFile(ctx, testPack, "missing.hcl")
+	if !packapi.IsNotFound(err) {
+		t.Errorf("error = %v, expected not found", err)
+	}
+	_, err = client.DownloadPack(ctx, testPack, "rar")
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("error = %v, expected a 400 API error", err)
+	}
+
+	if _, err := packapi.NewClient("ftp://example.com"); err == nil {
+		t.Error("expected an error for a non-HTTP URL")
+	}
+}
@@ -0,0 +1,48 @@
+// Package packapi holds the types of the pack registry API served by the server command,
+// along with a client for it
+package packapi
+
+import "time"
+
+// APIVersion is the version of the API described by the server's OpenAPI document
+const APIVersion = "1.0.0"
+
+// Download formats
+const (
+	FormatZip   = "zip"
+	FormatTarGz = "tar.gz"
+)
+
+// Pack describes a generated pack, from its metadata.hcl and the watch state entry that generated it
+type Pack struct {
+	Name          string    `json:"name" description:"Pack name, which is also its directory or archive name"`
+	Description   string    `json:"description,omitempty" description:"Description of the MCP server"`
+	Version       string    `json:"version,omitempty" description:"Pack version"`
+	AppURL        string    `json:"app_url,omitempty" description:"URL of the MCP server's project"`
+	Registry      string    `json:"registry,omitempty" description:"Name of the MCP registry the pack was generated from"`
+	RegistryURL   string    `json:"registry_url,omitempty" description:"URL of the MCP registry the pack was generated from"`
+	ServerName    string    `json:"server_name,omitempty" description:"MCP server name, when the watch state records the pack"`
+	PackageType   string    `json:"package_type,omitempty" description:"Package type of the server package"`
+	TransportType string    `json:"transport_type,omitempty" description:"Transport type of the server package"`
+	GeneratedAt   time.Time `json:"generated_at,omitzero" description:"When the pack was first generated"`
+	UpdatedAt     time.Time `json:"updated_at,omitzero" description:"When the pack was last generated"`
+	Checksum      string    `json:"checksum,omitempty" description:"Checksum of the server definition the pack was generated from"`
+	Archive       bool      `json:"archive" description:"Whether the pack is stored as a ZIP archive rather than a directory"`
+	ModifiedAt    time.Time `json:"modified_at" description:"When the pack was last modified on disk"`
+	Files         []string  `json:"files,omitempty" description:"Paths of the pack's files, in pack details only"`
+}
+
+// PackList is the body of the pack list response
+type PackList struct {
+	Packs []Pack `json:"packs"`
+}
+
+// Health is the body of the health check response
+type Health struct {
+	Status string `json:"status" description:"Always ok"`
+}
+
+// ErrorResponse is the body of API error responses
+type ErrorResponse struct {
+	Error string `json:"error" description:"Error message"`
+}
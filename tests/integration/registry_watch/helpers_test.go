@@ -34,6 +34,7 @@ type WatchState struct {
 
 // ServerState represents a server entry in the state file
 type ServerState struct {
+	Registry      string    `json:"registry"`
 	Namespace     string    `json:"namespace"`
 	Name          string    `json:"name"`
 	Version       string    `json:"version"`
@@ -118,8 +118,12 @@ func TestWatch_BasicPolling(t *testing.T) {
 			t.Errorf("Server %s missing transport type", key)
 		}
 
-		// Verify key format includes transport type
-		expectedKey := server.Namespace + "/" + server.Name + "@" + server.Version + ":" + server.PackageType + ":" + server.TransportType
+		if server.Registry != "default" {
+			t.Errorf("Server %s registry = %q, expected default", key, server.Registry)
+		}
+
+		// Verify key format includes registry and transport type
+		expectedKey := server.Registry + ":" + server.Namespace + "/" + server.Name + "@" + server.Version + ":" + server.PackageType + ":" + server.TransportType
 		if key != expectedKey {
 			t.Errorf("Invalid state key format. Expected %s, got %s", expectedKey, key)
 		}
@@ -444,7 +448,7 @@ func TestWatch_InvalidServerName(t *testing.T) {
 
 	// Verify all servers in state have valid key format
 	for key, server := range state.Servers {
-		expectedKey := server.Namespace + "/" + server.Name + "@" + server.Version + ":" + server.PackageType + ":" + server.TransportType
+		expectedKey := server.Registry + ":" + server.Namespace + "/" + server.Name + "@" + server.Version + ":" + server.PackageType + ":" + server.TransportType
 		if key != expectedKey {
 			t.Errorf("Invalid state key format. Expected %s, got %s", expectedKey, key)
 		}